@@ -3,15 +3,29 @@ package main
 import (
 	"DeltaReceiver/internal/sizif/app"
 	"DeltaReceiver/internal/sizif/conf"
+	"DeltaReceiver/internal/sizif/svc"
+	bmodel "DeltaReceiver/pkg/binance/model"
 	"context"
+	"flag"
+	"fmt"
+	"os"
 	"os/signal"
+	"regexp"
 	"syscall"
 	"time"
 )
 
 func main() {
 	// cfgPath := flag.String("cfg", "sizif.yaml", "path to config file")
-	// flag.Parse()
+	backfillMode := flag.Bool("backfill", false, "archive hot keys again instead of running the service")
+	market := flag.String("market", string(bmodel.Spot), "backfill market: spot, usd or coin")
+	dataType := flag.String("data", "deltas", "backfill data type: deltas, book_ticks or snapshots")
+	symbols := flag.String("symbols", ".*", "backfill symbols regexp, matched against the whole symbol")
+	from := flag.String("from", "", "backfill hours from, inclusive, UTC "+svc.ProcessingKeyLayout)
+	to := flag.String("to", "", "backfill hours to, exclusive, UTC "+svc.ProcessingKeyLayout)
+	dryRun := flag.Bool("dry-run", false, "only list keys to backfill")
+	replace := flag.Bool("replace", false, "clear ledger records of backfilled keys, so archived objects are replaced")
+	flag.Parse()
 	// cfgData, err := os.ReadFile(*cfgPath)
 	// if err != nil {
 	// 	log.Println(err.Error())
@@ -20,6 +34,14 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 	cfg := conf.AppConfigFromEnv("sizif")
+	if *backfillMode {
+		req, err := newBackfillRequest(*symbols, *from, *to, *dryRun, *replace)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(2)
+		}
+		os.Exit(backfill(ctx, app.NewApp(cfg), bmodel.DataType(*market), *dataType, req))
+	}
 	a := app.NewApp(cfg)
 	a.Start()
 	<-ctx.Done()
@@ -27,3 +49,43 @@ func main() {
 	defer cancel()
 	a.Stop(ctx)
 }
+
+func newBackfillRequest(symbols, from, to string, dryRun, replace bool) (*svc.BackfillRequest, error) {
+	symbolsRe, err := regexp.Compile("^(?:" + symbols + ")$")
+	if err != nil {
+		return nil, err
+	}
+	fromTime, err := time.Parse(svc.ProcessingKeyLayout, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+	toTime, err := time.Parse(svc.ProcessingKeyLayout, to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+	return &svc.BackfillRequest{
+		Symbols:    symbolsRe,
+		FromHourNo: fromTime.Unix() / 3600,
+		ToHourNo:   (toTime.Unix() + 3599) / 3600,
+		DryRun:     dryRun,
+		Replace:    replace,
+	}, nil
+}
+
+// backfill prints a line per key and returns the exit code, which is non-zero if any key failed.
+func backfill(ctx context.Context, a *app.App, market bmodel.DataType, dataType string, req *svc.BackfillRequest) int {
+	results, err := a.Backfill(ctx, market, dataType, req)
+	exitCode := 0
+	for _, result := range results {
+		fmt.Println(result.String())
+		if result.Outcome == svc.KeyFailed || result.Outcome == svc.KeyLocked {
+			exitCode = 1
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Printf("%d keys\n", len(results))
+	return exitCode
+}
@@ -0,0 +1,37 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+	"fmt"
+
+	"github.com/gocql/gocql"
+)
+
+// CsKeyScanCfg configures scans of keys tables. The token ring is split into TokenRanges
+// ranges, each of them read in pages of PageSize rows, so a range without live replicas
+// does not fail the rest of a scan.
+type CsKeyScanCfg struct {
+	Consistency string `yaml:"consistency"`
+	PageSize    int    `yaml:"page.size"`
+	TokenRanges int    `yaml:"token.ranges"`
+}
+
+func NewCsKeyScanCfgFromEnv(envPrefix string) *CsKeyScanCfg {
+	cfg := &CsKeyScanCfg{
+		Consistency: env.GetStringOrDefault(envPrefix+".consistency", "ONE"),
+		PageSize:    env.GetIntOrDefault(envPrefix+".page.size", 5000),
+		TokenRanges: env.GetIntOrDefault(envPrefix+".token.ranges", 64),
+	}
+	if _, err := gocql.ParseConsistencyWrapper(cfg.Consistency); err != nil {
+		panic(err)
+	}
+	if cfg.PageSize <= 0 || cfg.TokenRanges <= 0 {
+		panic(fmt.Sprintf("invalid key scan page size %d or token ranges %d", cfg.PageSize, cfg.TokenRanges))
+	}
+	return cfg
+}
+
+func (s *CsKeyScanCfg) GetConsistency() gocql.Consistency {
+	consistency, _ := gocql.ParseConsistencyWrapper(s.Consistency)
+	return consistency
+}
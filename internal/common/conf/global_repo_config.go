@@ -2,6 +2,7 @@ package conf
 
 import (
 	"DeltaReceiver/pkg/clickhouse"
+	"DeltaReceiver/pkg/env"
 )
 
 type GlobalRepoConfig struct {
@@ -12,4 +13,19 @@ type GlobalRepoConfig struct {
 	SnapshotTable     string                `yaml:"snapshot.table.name"`
 	ExchangeInfoTable string                `yaml:"ex.info.table"`
 	BookTickerTable   string                `yaml:"book.ticker.table"`
+	InsertCfg         *ChInsertCfg          `yaml:"insert"`
+}
+
+type ChInsertCfg struct {
+	BlockSize          int  `yaml:"block.size"`
+	AsyncInsert        bool `yaml:"async"`
+	WaitForAsyncInsert bool `yaml:"wait.for.async"`
+}
+
+func NewChInsertCfgFromEnv(envPrefix string) *ChInsertCfg {
+	return &ChInsertCfg{
+		BlockSize:          env.GetIntOrDefault(envPrefix+".block.size", 10000),
+		AsyncInsert:        env.GetBoolOrDefault(envPrefix+".async", false),
+		WaitForAsyncInsert: env.GetBoolOrDefault(envPrefix+".wait.for.async", true),
+	}
 }
@@ -2,11 +2,23 @@ package conf
 
 import (
 	"DeltaReceiver/pkg/conf"
+	"os"
 )
 
 type S3Cfg struct {
 	URICfg *conf.BaseUriConfig `yaml:"uri"`
 	Region string              `yaml:"region"`
 	Id     string              `yaml:"id"`
-	Secret string              `yaml:"secret"`
+	Secret string              `yaml:"-"`
+	Bucket string              `yaml:"bucket"`
+}
+
+func NewS3CfgFromEnv(envPrefix string) *S3Cfg {
+	return &S3Cfg{
+		URICfg: conf.NewBaseUriConfigFromEnv(envPrefix + ".uri"),
+		Region: os.Getenv(envPrefix + ".region"),
+		Id:     os.Getenv(envPrefix + ".id"),
+		Secret: os.Getenv(envPrefix + ".secret"),
+		Bucket: os.Getenv(envPrefix + ".bucket"),
+	}
 }
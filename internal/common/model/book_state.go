@@ -0,0 +1,27 @@
+package model
+
+// BookState is the top of a book reconstructed from archived deltas at Timestamp, levels
+// are ordered from the best price. Synced is false while the book is not known to match
+// the exchange, before the first snapshot or after a hole until the next one. CrossedHole
+// marks states after which replay crossed a hole in update ids since the previous state,
+// holes are detected in spot books only.
+type BookState struct {
+	Timestamp           int64    `json:"timestamp" parquet:"timestampMs"`
+	Symbol              string   `json:"symbol" parquet:"symbol"`
+	UpdateId            int64    `json:"updateId" parquet:"updateId"`
+	SnapshotTimestampMs int64    `json:"snapshotTimestampMs" parquet:"snapshotTimestampMs"`
+	Synced              bool     `json:"synced" parquet:"synced"`
+	CrossedHole         bool     `json:"crossedHole" parquet:"crossedHole"`
+	BidPrices           []string `json:"bidPrices" parquet:"bidPrices,list"`
+	BidQuantities       []string `json:"bidQuantities" parquet:"bidQuantities,list"`
+	AskPrices           []string `json:"askPrices" parquet:"askPrices,list"`
+	AskQuantities       []string `json:"askQuantities" parquet:"askQuantities,list"`
+}
+
+func (s BookState) GetTimestampMs() int64 {
+	return s.Timestamp
+}
+
+func (s BookState) GetUpdateId() int64 {
+	return s.UpdateId
+}
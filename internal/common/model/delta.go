@@ -90,3 +90,11 @@ func (s Delta) GetTimestampMs() int64 {
 func (s Delta) GetSymbol() string {
 	return s.Symbol
 }
+
+func (s Delta) GetUpdateId() int64 {
+	return s.UpdateId
+}
+
+func (s Delta) GetFirstUpdateId() int64 {
+	return s.FirstUpdateId
+}
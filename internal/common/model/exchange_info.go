@@ -37,6 +37,10 @@ func NewExchangeInfo(rawExInfo bmodel.ExInfo) *ExchangeInfo {
 	}
 }
 
+func (s ExchangeInfo) GetTimestampMs() int64 {
+	return s.ServerTime
+}
+
 func (s ExchangeInfoWithMongoId) MongoId() primitive.ObjectID {
 	return s.Id
 }
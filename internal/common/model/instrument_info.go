@@ -0,0 +1,117 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+// InstrumentInfo is a symbol of an exchange info version flattened for point-in-time
+// lookups. Spot and futures payloads share the columns, fields a market does not have are
+// left empty. Filters keeps the raw filters of the symbol.
+type InstrumentInfo struct {
+	Timestamp           int64  `json:"timestamp" parquet:"timestampMs"`
+	Symbol              string `json:"symbol" parquet:"symbol"`
+	Status              string `json:"status" parquet:"status"`
+	BaseAsset           string `json:"baseAsset" parquet:"baseAsset"`
+	QuoteAsset          string `json:"quoteAsset" parquet:"quoteAsset"`
+	MarginAsset         string `json:"marginAsset" parquet:"marginAsset"`
+	ContractType        string `json:"contractType" parquet:"contractType"`
+	BaseAssetPrecision  int64  `json:"baseAssetPrecision" parquet:"baseAssetPrecision"`
+	QuotePrecision      int64  `json:"quotePrecision" parquet:"quotePrecision"`
+	QuoteAssetPrecision int64  `json:"quoteAssetPrecision" parquet:"quoteAssetPrecision"`
+	PricePrecision      int64  `json:"pricePrecision" parquet:"pricePrecision"`
+	QuantityPrecision   int64  `json:"quantityPrecision" parquet:"quantityPrecision"`
+	TickSize            string `json:"tickSize" parquet:"tickSize"`
+	MinPrice            string `json:"minPrice" parquet:"minPrice"`
+	MaxPrice            string `json:"maxPrice" parquet:"maxPrice"`
+	StepSize            string `json:"stepSize" parquet:"stepSize"`
+	MinQty              string `json:"minQty" parquet:"minQty"`
+	MaxQty              string `json:"maxQty" parquet:"maxQty"`
+	MinNotional         string `json:"minNotional" parquet:"minNotional"`
+	Filters             string `json:"filters" parquet:"filters"`
+}
+
+func (s InstrumentInfo) GetTimestampMs() int64 {
+	return s.Timestamp
+}
+
+// rawExchangeInfo reads symbols of spot and futures payloads, futures have contractStatus
+// instead of status.
+type rawExchangeInfo struct {
+	Symbols []struct {
+		Symbol              string          `json:"symbol"`
+		Status              string          `json:"status"`
+		ContractStatus      string          `json:"contractStatus"`
+		BaseAsset           string          `json:"baseAsset"`
+		QuoteAsset          string          `json:"quoteAsset"`
+		MarginAsset         string          `json:"marginAsset"`
+		ContractType        string          `json:"contractType"`
+		BaseAssetPrecision  int64           `json:"baseAssetPrecision"`
+		QuotePrecision      int64           `json:"quotePrecision"`
+		QuoteAssetPrecision int64           `json:"quoteAssetPrecision"`
+		PricePrecision      int64           `json:"pricePrecision"`
+		QuantityPrecision   int64           `json:"quantityPrecision"`
+		Filters             json.RawMessage `json:"filters"`
+	} `json:"symbols"`
+}
+
+type rawSymbolFilter struct {
+	FilterType  string `json:"filterType"`
+	TickSize    string `json:"tickSize"`
+	MinPrice    string `json:"minPrice"`
+	MaxPrice    string `json:"maxPrice"`
+	StepSize    string `json:"stepSize"`
+	MinQty      string `json:"minQty"`
+	MaxQty      string `json:"maxQty"`
+	MinNotional string `json:"minNotional"`
+	Notional    string `json:"notional"`
+}
+
+// NewInstrumentInfos flattens symbols of the payload, rows get the time of the version.
+func NewInstrumentInfos(exInfo *ExchangeInfo) ([]InstrumentInfo, error) {
+	var raw rawExchangeInfo
+	if err := json.Unmarshal([]byte(exInfo.Payload), &raw); err != nil {
+		return nil, err
+	}
+	instruments := make([]InstrumentInfo, 0, len(raw.Symbols))
+	for _, symbol := range raw.Symbols {
+		instrument := InstrumentInfo{
+			Timestamp:           exInfo.ServerTime,
+			Symbol:              symbol.Symbol,
+			Status:              symbol.Status,
+			BaseAsset:           symbol.BaseAsset,
+			QuoteAsset:          symbol.QuoteAsset,
+			MarginAsset:         symbol.MarginAsset,
+			ContractType:        symbol.ContractType,
+			BaseAssetPrecision:  symbol.BaseAssetPrecision,
+			QuotePrecision:      symbol.QuotePrecision,
+			QuoteAssetPrecision: symbol.QuoteAssetPrecision,
+			PricePrecision:      symbol.PricePrecision,
+			QuantityPrecision:   symbol.QuantityPrecision,
+			Filters:             string(symbol.Filters),
+		}
+		if instrument.Status == "" {
+			instrument.Status = symbol.ContractStatus
+		}
+		var filters []rawSymbolFilter
+		if len(symbol.Filters) > 0 {
+			if err := json.Unmarshal(symbol.Filters, &filters); err != nil {
+				return nil, err
+			}
+		}
+		for _, filter := range filters {
+			switch filter.FilterType {
+			case "PRICE_FILTER":
+				instrument.TickSize, instrument.MinPrice, instrument.MaxPrice = filter.TickSize, filter.MinPrice, filter.MaxPrice
+			case "LOT_SIZE":
+				instrument.StepSize, instrument.MinQty, instrument.MaxQty = filter.StepSize, filter.MinQty, filter.MaxQty
+			case "NOTIONAL", "MIN_NOTIONAL":
+				instrument.MinNotional = filter.MinNotional
+				if instrument.MinNotional == "" {
+					instrument.MinNotional = filter.Notional
+				}
+			}
+		}
+		instruments = append(instruments, instrument)
+	}
+	return instruments, nil
+}
@@ -10,6 +10,10 @@ type WithSymbol interface {
 	GetSymbol() string
 }
 
+type WithUpdateId interface {
+	GetUpdateId() int64
+}
+
 type BinanceDataRow interface {
 	WithTimestampMs
 	WithSymbol
@@ -0,0 +1,26 @@
+package model
+
+// MidBar aggregates book ticks of a symbol in [Timestamp, Timestamp+IntervalMs). Open, High,
+// Low and Close are mid prices of the ticks, spreads are ask minus bid. TwaSpread weights
+// spreads by the time they were in effect within the bar, a spread of the last tick before
+// the bar is in effect from the start of the bar. Bars have no volume, book ticks hold
+// quantities resting at the best prices, not traded quantities, so volume can not be
+// derived from them.
+type MidBar struct {
+	Timestamp  int64   `json:"timestamp" parquet:"timestampMs"`
+	Symbol     string  `json:"symbol" parquet:"symbol"`
+	IntervalMs int64   `json:"intervalMs" parquet:"intervalMs"`
+	Open       float64 `json:"open" parquet:"open"`
+	High       float64 `json:"high" parquet:"high"`
+	Low        float64 `json:"low" parquet:"low"`
+	Close      float64 `json:"close" parquet:"close"`
+	TickCount  int64   `json:"tickCount" parquet:"tickCount"`
+	MinSpread  float64 `json:"minSpread" parquet:"minSpread"`
+	MaxSpread  float64 `json:"maxSpread" parquet:"maxSpread"`
+	MeanSpread float64 `json:"meanSpread" parquet:"meanSpread"`
+	TwaSpread  float64 `json:"twaSpread" parquet:"twaSpread"`
+}
+
+func (s MidBar) GetTimestampMs() int64 {
+	return s.Timestamp
+}
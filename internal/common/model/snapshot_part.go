@@ -62,3 +62,7 @@ func (s DepthSnapshotPart) GetTimestampMs() int64 {
 func (s DepthSnapshotPart) GetSymbol() string {
 	return s.Symbol
 }
+
+func (s DepthSnapshotPart) GetUpdateId() int64 {
+	return s.LastUpdateId
+}
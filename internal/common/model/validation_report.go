@@ -0,0 +1,62 @@
+package model
+
+// ValidationReport describes problems found in data of one archive hour. Hours with
+// problems are quarantined by sizif and their reports are kept by dwarf.
+type ValidationReport struct {
+	StorageName          string          `json:"storage_name" bson:"storage_name"`
+	Symbol               string          `json:"symbol" bson:"symbol"`
+	HourNo               int64           `json:"hour_no" bson:"hour_no"`
+	TimestampMs          int64           `json:"timestamp_ms" bson:"timestamp_ms"`
+	Rows                 int             `json:"rows" bson:"rows"`
+	HoleCount            int             `json:"hole_count" bson:"hole_count"`
+	Holes                []UpdateIdRange `json:"holes" bson:"holes"`
+	OutOfRangeCount      int             `json:"out_of_range_count" bson:"out_of_range_count"`
+	OutOfRangeTimestamps []int64         `json:"out_of_range_timestamps" bson:"out_of_range_timestamps"`
+	DuplicateCount       int             `json:"duplicate_count" bson:"duplicate_count"`
+	DuplicateUpdateIds   []int64         `json:"duplicate_update_ids" bson:"duplicate_update_ids"`
+	CreatedAtMs          int64           `json:"created_at_ms" bson:"created_at_ms"`
+}
+
+// UpdateIdRange is a range of missing update ids, both ends exclusive.
+type UpdateIdRange struct {
+	LastUpdateId  int64 `json:"last_update_id" bson:"last_update_id"`
+	FirstUpdateId int64 `json:"first_update_id" bson:"first_update_id"`
+	TimestampMs   int64 `json:"timestamp_ms" bson:"timestamp_ms"`
+}
+
+// MaxReportedValues bounds the lists of a report, counters are not bounded.
+const MaxReportedValues = 1000
+
+func NewValidationReport(key *ProcessingKey, rows int) *ValidationReport {
+	return &ValidationReport{
+		Symbol:      key.Symbol,
+		HourNo:      key.HourNo,
+		TimestampMs: key.GetStartTime().UnixMilli(),
+		Rows:        rows,
+	}
+}
+
+func (s *ValidationReport) AddHole(hole UpdateIdRange) {
+	s.HoleCount++
+	if len(s.Holes) < MaxReportedValues {
+		s.Holes = append(s.Holes, hole)
+	}
+}
+
+func (s *ValidationReport) AddOutOfRange(timestampMs int64) {
+	s.OutOfRangeCount++
+	if len(s.OutOfRangeTimestamps) < MaxReportedValues {
+		s.OutOfRangeTimestamps = append(s.OutOfRangeTimestamps, timestampMs)
+	}
+}
+
+func (s *ValidationReport) AddDuplicate(updateId int64) {
+	s.DuplicateCount++
+	if len(s.DuplicateUpdateIds) < MaxReportedValues {
+		s.DuplicateUpdateIds = append(s.DuplicateUpdateIds, updateId)
+	}
+}
+
+func (s *ValidationReport) IsValid() bool {
+	return s.Rows > 0 && s.HoleCount == 0 && s.OutOfRangeCount == 0 && s.DuplicateCount == 0
+}
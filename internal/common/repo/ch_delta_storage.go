@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"DeltaReceiver/internal/common/conf"
 	"DeltaReceiver/internal/common/model"
 	"DeltaReceiver/internal/common/svc"
 	"DeltaReceiver/pkg/clickhouse"
@@ -19,6 +20,7 @@ type ChDeltaStorage struct {
 	pool      *clickhouse.ChPoolHolder
 	dbName    string
 	tableName string
+	insertCfg *conf.ChInsertCfg
 }
 
 const ChDateTimeLayout = "2006-01-02 15:04:05.999999999"
@@ -33,12 +35,13 @@ const (
 	SymbolCol        = "symbol"
 )
 
-func NewChDeltaStorage(pool *clickhouse.ChPoolHolder, dbName, tableName string) *ChDeltaStorage {
+func NewChDeltaStorage(pool *clickhouse.ChPoolHolder, dbName, tableName string, insertCfg *conf.ChInsertCfg) *ChDeltaStorage {
 	return &ChDeltaStorage{
 		logger:    log.GetLogger("ChDeltaStorage"),
 		pool:      pool,
 		dbName:    dbName,
 		tableName: tableName,
+		insertCfg: insertCfg,
 	}
 }
 
@@ -85,8 +88,9 @@ func (s ChDeltaStorage) SendDeltas(ctx context.Context, deltas []model.Delta) er
 	input := prepareDeltasInsertBlock(deltas)
 	insertQuery := fmt.Sprintf("INSERT INTO %s.%s VALUES", s.dbName, s.tableName)
 	err := s.pool.Do(ctx, ch.Query{
-		Body:  insertQuery,
-		Input: input,
+		Body:     insertQuery,
+		Input:    input,
+		Settings: clickhouse.InsertSettings(ctx, s.insertCfg.AsyncInsert, s.insertCfg.WaitForAsyncInsert),
 	})
 	if err != nil {
 		return fmt.Errorf("error while sending deltas %w", err)
@@ -94,6 +98,10 @@ func (s ChDeltaStorage) SendDeltas(ctx context.Context, deltas []model.Delta) er
 	return nil
 }
 
+func (s ChDeltaStorage) Save(ctx context.Context, deltas []model.Delta) error {
+	return clickhouse.InsertInBlocks(ctx, deltas, s.insertCfg.BlockSize, s.SendDeltas)
+}
+
 func formListOfSymbolsForChQuery(symbols map[string]struct{}) string {
 	if len(symbols) == 0 {
 		return "()"
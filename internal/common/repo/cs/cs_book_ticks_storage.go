@@ -1,6 +1,7 @@
 package cs
 
 import (
+	"DeltaReceiver/internal/common/conf"
 	"DeltaReceiver/internal/common/model"
 	bmodel "DeltaReceiver/pkg/binance/model"
 	"DeltaReceiver/pkg/log"
@@ -14,16 +15,16 @@ import (
 )
 
 type CsBookTicksStorage struct {
-	logger              *zap.Logger
-	session             *gocql.Session
-	metrics             CsStorageMetrics
-	tableName           string
-	keysTableName       string
-	dataUploader        *CsDataUploader[bmodel.SymbolTick]
-	selectStatement     string
-	selectKeysStatement string
-	deleteStatement     string
-	deleteKeyStatement  string
+	logger             *zap.Logger
+	session            *gocql.Session
+	metrics            CsStorageMetrics
+	tableName          string
+	keysTableName      string
+	dataUploader       *CsDataUploader[bmodel.SymbolTick]
+	selectStatement    string
+	keyScanner         *CsKeyScanner
+	deleteStatement    string
+	deleteKeyStatement string
 }
 
 func NewCsBookTicksStorageWO(loggerParam string, session *gocql.Session, metrics CsStorageMetrics, tableName string, keysTableName string) *CsBookTicksStorage {
@@ -40,13 +41,14 @@ func NewCsBookTicksStorageWO(loggerParam string, session *gocql.Session, metrics
 	return bookTicksStorage
 }
 
-func NewCsBookTicksStorageRO(session *gocql.Session, tableName string, keysTableName string) *CsBookTicksStorage {
+func NewCsBookTicksStorageRO(session *gocql.Session, tableName string, keysTableName string, keyScanCfg *conf.CsKeyScanCfg) *CsBookTicksStorage {
 	logger := log.GetLogger("CsBookTicksStorage")
 	bookTicksStorage := &CsBookTicksStorage{
 		logger:        logger,
 		session:       session,
 		tableName:     tableName,
 		keysTableName: keysTableName,
+		keyScanner:    NewCsKeyScanner(logger, session, keysTableName, keyScanCfg),
 	}
 	bookTicksStorage.initStatements()
 	return bookTicksStorage
@@ -54,7 +56,6 @@ func NewCsBookTicksStorageRO(session *gocql.Session, tableName string, keysTable
 
 func (s *CsBookTicksStorage) initStatements() {
 	s.selectStatement = fmt.Sprintf("SELECT symbol, timestamp_ms, update_id, ask_price, ask_quantity, bid_price, bid_quantity FROM %s WHERE symbol = ? AND hour = ?", s.tableName)
-	s.selectKeysStatement = fmt.Sprintf("SELECT (symbol, hour) FROM %s", s.keysTableName)
 	s.deleteStatement = fmt.Sprintf("DELETE FROM %s WHERE symbol = ? AND hour = ?", s.tableName)
 	s.deleteKeyStatement = fmt.Sprintf("DELETE FROM %s WHERE symbol = ? AND hour = ?", s.keysTableName)
 }
@@ -94,17 +95,7 @@ func (s CsBookTicksStorage) Get(ctx context.Context, key *model.ProcessingKey) (
 }
 
 func (s CsBookTicksStorage) GetKeys(ctx context.Context) ([]model.ProcessingKey, error) {
-	var key model.ProcessingKey
-	var keys []model.ProcessingKey
-	it := s.session.Query(s.selectKeysStatement).Consistency(gocql.All).WithContext(ctx).Iter()
-	for it.Scan(&key.Symbol, &key.HourNo) {
-		keys = append(keys, key)
-	}
-	err := it.Close()
-	if err != nil {
-		s.logger.Error(err.Error())
-	}
-	return keys, err
+	return s.keyScanner.ScanKeys(ctx)
 }
 
 func (s CsBookTicksStorage) Delete(ctx context.Context, key *model.ProcessingKey) error {
@@ -1,6 +1,7 @@
 package cs
 
 import (
+	"DeltaReceiver/internal/common/conf"
 	"DeltaReceiver/internal/common/model"
 	"DeltaReceiver/pkg/log"
 	"context"
@@ -13,16 +14,16 @@ import (
 )
 
 type CsDeltaStorage struct {
-	logger              *zap.Logger
-	session             *gocql.Session
-	metrics             CsStorageMetrics
-	tableName           string
-	dataUploader        *CsDataUploader[model.Delta]
-	keysTableName       string
-	selectStatement     string
-	selectKeysStatement string
-	deleteStatement     string
-	deleteKeyStatement  string
+	logger             *zap.Logger
+	session            *gocql.Session
+	metrics            CsStorageMetrics
+	tableName          string
+	dataUploader       *CsDataUploader[model.Delta]
+	keysTableName      string
+	selectStatement    string
+	keyScanner         *CsKeyScanner
+	deleteStatement    string
+	deleteKeyStatement string
 }
 
 func NewCsDeltaStorageWO(loggerParam string, session *gocql.Session, metrics CsStorageMetrics, tableName string, keysTableName string) *CsDeltaStorage {
@@ -38,13 +39,14 @@ func NewCsDeltaStorageWO(loggerParam string, session *gocql.Session, metrics CsS
 	return deltaStorage
 }
 
-func NewCsDeltaStorageRO(session *gocql.Session, tableName string, keysTableName string) *CsDeltaStorage {
+func NewCsDeltaStorageRO(session *gocql.Session, tableName string, keysTableName string, keyScanCfg *conf.CsKeyScanCfg) *CsDeltaStorage {
 	logger := log.GetLogger("CsDeltaStorage")
 	deltaStorage := &CsDeltaStorage{
 		logger:        logger,
 		session:       session,
 		tableName:     tableName,
 		keysTableName: keysTableName,
+		keyScanner:    NewCsKeyScanner(logger, session, keysTableName, keyScanCfg),
 	}
 	deltaStorage.initStatements()
 	return deltaStorage
@@ -52,7 +54,6 @@ func NewCsDeltaStorageRO(session *gocql.Session, tableName string, keysTableName
 
 func (s *CsDeltaStorage) initStatements() {
 	s.selectStatement = fmt.Sprintf("SELECT symbol, timestamp_ms, type, price, count, first_update_id, update_id FROM %s WHERE symbol = ? AND hour = ?", s.tableName)
-	s.selectKeysStatement = fmt.Sprintf("SELECT (symbol, hour) FROM %s", s.keysTableName)
 	s.deleteStatement = fmt.Sprintf("DELETE FROM %s WHERE symbol = ? AND hour = ?", s.tableName)
 	s.deleteKeyStatement = fmt.Sprintf("DELETE FROM %s WHERE symbol = ? AND hour = ?", s.keysTableName)
 }
@@ -92,17 +93,7 @@ func (s CsDeltaStorage) Get(ctx context.Context, key *model.ProcessingKey) ([]mo
 }
 
 func (s CsDeltaStorage) GetKeys(ctx context.Context) ([]model.ProcessingKey, error) {
-	var key model.ProcessingKey
-	var keys []model.ProcessingKey
-	it := s.session.Query(s.selectKeysStatement).Consistency(gocql.All).WithContext(ctx).Iter()
-	for it.Scan(&key.Symbol, &key.HourNo) {
-		keys = append(keys, key)
-	}
-	err := it.Close()
-	if err != nil {
-		s.logger.Error(err.Error())
-	}
-	return keys, err
+	return s.keyScanner.ScanKeys(ctx)
 }
 
 func (s CsDeltaStorage) Delete(ctx context.Context, key *model.ProcessingKey) error {
@@ -18,6 +18,7 @@ type CsExchangeInfoStorage struct {
 	insertStatement            string
 	selectDaysExInfoStatemenet string
 	selectLastExInfoStatemenet string
+	selectDayExInfoStatement   string
 }
 
 func NewExchangeInfoStorage(loggerParam string, session *gocql.Session, tableName string) *CsExchangeInfoStorage {
@@ -35,6 +36,7 @@ func (s *CsExchangeInfoStorage) initStatements() {
 	s.insertStatement = fmt.Sprintf("INSERT INTO %s (day, timestamp_ms, ex_info_hash, ex_info) VALUES (?, ?, ?, ?)", s.tableName)
 	s.selectDaysExInfoStatemenet = fmt.Sprintf("SELECT DISTINCT day FROM %s", s.tableName)
 	s.selectLastExInfoStatemenet = fmt.Sprintf("SELECT ex_info FROM %s WHERE day = ? ORDER BY timestamp_ms DESC LIMIT 1", s.tableName)
+	s.selectDayExInfoStatement = fmt.Sprintf("SELECT timestamp_ms, ex_info_hash, ex_info FROM %s WHERE day = ?", s.tableName)
 }
 
 func (s CsExchangeInfoStorage) Save(ctx context.Context, exInfo []model.ExchangeInfo) error {
@@ -85,6 +87,38 @@ func (s CsExchangeInfoStorage) GetLastDayExInfo(ctx context.Context) int64 {
 	return maxDay
 }
 
+// GetDays returns days with exchange info, unordered.
+func (s CsExchangeInfoStorage) GetDays(ctx context.Context) ([]int64, error) {
+	respIt := s.session.Query(s.selectDaysExInfoStatemenet).WithContext(ctx).Iter()
+	var days []int64
+	var day int64
+	for respIt.Scan(&day) {
+		days = append(days, day)
+	}
+	if err := respIt.Close(); err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	return days, nil
+}
+
+// GetDay returns exchange info versions of the day ordered by time.
+func (s CsExchangeInfoStorage) GetDay(ctx context.Context, day int64) ([]model.ExchangeInfo, error) {
+	query := s.session.Query(s.selectDayExInfoStatement, day).WithContext(ctx)
+	query.SetConsistency(gocql.LocalQuorum)
+	respIt := query.Iter()
+	var exInfos []model.ExchangeInfo
+	var exInfo model.ExchangeInfo
+	for respIt.Scan(&exInfo.ServerTime, &exInfo.ExInfoHash, &exInfo.Payload) {
+		exInfos = append(exInfos, exInfo)
+	}
+	if err := respIt.Close(); err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	return exInfos, nil
+}
+
 func (s CsExchangeInfoStorage) Connect(ctx context.Context) error {
 	return nil
 }
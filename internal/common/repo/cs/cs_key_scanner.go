@@ -0,0 +1,84 @@
+package cs
+
+import (
+	"DeltaReceiver/internal/common/conf"
+	"DeltaReceiver/internal/common/model"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gocql/gocql"
+	"go.uber.org/zap"
+)
+
+// CsKeyScanner reads a keys table by token ranges with paging instead of a single
+// unpaged query over the whole table.
+type CsKeyScanner struct {
+	logger      *zap.Logger
+	session     *gocql.Session
+	statement   string
+	consistency gocql.Consistency
+	pageSize    int
+	tokenRanges int
+}
+
+func NewCsKeyScanner(logger *zap.Logger, session *gocql.Session, keysTableName string, cfg *conf.CsKeyScanCfg) *CsKeyScanner {
+	return &CsKeyScanner{
+		logger:      logger,
+		session:     session,
+		statement:   fmt.Sprintf("SELECT symbol, hour FROM %s WHERE token(symbol, hour) >= ? AND token(symbol, hour) <= ?", keysTableName),
+		consistency: cfg.GetConsistency(),
+		pageSize:    cfg.PageSize,
+		tokenRanges: cfg.TokenRanges,
+	}
+}
+
+// ScanKeys returns keys of all token ranges which were read. Failed ranges are skipped and
+// reported with the error, so callers can still use keys of the rest.
+func (s *CsKeyScanner) ScanKeys(ctx context.Context) ([]model.ProcessingKey, error) {
+	var keys []model.ProcessingKey
+	var errs []error
+	for _, tokenRange := range splitTokenRing(s.tokenRanges) {
+		rangeKeys, err := s.scanRange(ctx, tokenRange[0], tokenRange[1])
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("token range [%d, %d] not scanned: %s", tokenRange[0], tokenRange[1], err.Error()))
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		keys = append(keys, rangeKeys...)
+	}
+	if len(errs) > 0 {
+		return keys, fmt.Errorf("%d of %d token ranges not scanned: %w", len(errs), s.tokenRanges, errors.Join(errs...))
+	}
+	return keys, nil
+}
+
+func (s *CsKeyScanner) scanRange(ctx context.Context, fromToken, toToken int64) ([]model.ProcessingKey, error) {
+	var key model.ProcessingKey
+	var keys []model.ProcessingKey
+	it := s.session.Query(s.statement, fromToken, toToken).Consistency(s.consistency).PageSize(s.pageSize).WithContext(ctx).Iter()
+	for it.Scan(&key.Symbol, &key.HourNo) {
+		keys = append(keys, key)
+	}
+	return keys, it.Close()
+}
+
+// splitTokenRing splits the murmur3 token ring into n adjacent inclusive ranges.
+func splitTokenRing(n int) [][2]int64 {
+	step := math.MaxUint64 / uint64(n)
+	ranges := make([][2]int64, n)
+	from := int64(math.MinInt64)
+	for i := range ranges {
+		to := int64(math.MaxInt64)
+		if i < n-1 {
+			to = from + int64(step-1)
+		}
+		ranges[i] = [2]int64{from, to}
+		from = to + 1
+	}
+	return ranges
+}
@@ -1,6 +1,7 @@
 package cs
 
 import (
+	"DeltaReceiver/internal/common/conf"
 	"DeltaReceiver/internal/common/model"
 	"DeltaReceiver/pkg/log"
 	"context"
@@ -13,16 +14,16 @@ import (
 )
 
 type CsSnapshotStorage struct {
-	logger              *zap.Logger
-	session             *gocql.Session
-	metrics             CsStorageMetrics
-	tableName           string
-	keysTableName       string
-	dataUploader        *CsDataUploader[model.DepthSnapshotPart]
-	selectStatement     string
-	selectKeysStatement string
-	deleteStatement     string
-	deleteKeyStatement  string
+	logger             *zap.Logger
+	session            *gocql.Session
+	metrics            CsStorageMetrics
+	tableName          string
+	keysTableName      string
+	dataUploader       *CsDataUploader[model.DepthSnapshotPart]
+	selectStatement    string
+	keyScanner         *CsKeyScanner
+	deleteStatement    string
+	deleteKeyStatement string
 }
 
 func NewCsSnapshotStorageWO(loggerParam string, session *gocql.Session, metrics CsStorageMetrics, tableName string, keysTableName string) *CsSnapshotStorage {
@@ -39,13 +40,14 @@ func NewCsSnapshotStorageWO(loggerParam string, session *gocql.Session, metrics
 	return snapshotStorage
 }
 
-func NewCsSnapshotStorageRO(session *gocql.Session, tableName string, keysTableName string) *CsSnapshotStorage {
+func NewCsSnapshotStorageRO(session *gocql.Session, tableName string, keysTableName string, keyScanCfg *conf.CsKeyScanCfg) *CsSnapshotStorage {
 	logger := log.GetLogger("CsSnapshotStorage")
 	snapshotStorage := &CsSnapshotStorage{
 		logger:        logger,
 		session:       session,
 		tableName:     tableName,
 		keysTableName: keysTableName,
+		keyScanner:    NewCsKeyScanner(logger, session, keysTableName, keyScanCfg),
 	}
 	snapshotStorage.initStatements()
 	return snapshotStorage
@@ -53,7 +55,6 @@ func NewCsSnapshotStorageRO(session *gocql.Session, tableName string, keysTableN
 
 func (s *CsSnapshotStorage) initStatements() {
 	s.selectStatement = fmt.Sprintf("SELECT symbol, timestamp_ms, type, price, count, last_update_id FROM %s WHERE symbol = ? AND hour = ?", s.tableName)
-	s.selectKeysStatement = fmt.Sprintf("SELECT (symbol, hour) FROM %s", s.keysTableName)
 	s.deleteStatement = fmt.Sprintf("DELETE FROM %s WHERE symbol = ? AND hour = ?", s.tableName)
 	s.deleteKeyStatement = fmt.Sprintf("DELETE FROM %s WHERE symbol = ? AND hour = ?", s.keysTableName)
 }
@@ -93,17 +94,7 @@ func (s CsSnapshotStorage) Get(ctx context.Context, key *model.ProcessingKey) ([
 }
 
 func (s CsSnapshotStorage) GetKeys(ctx context.Context) ([]model.ProcessingKey, error) {
-	var key model.ProcessingKey
-	var keys []model.ProcessingKey
-	it := s.session.Query(s.selectKeysStatement).Consistency(gocql.All).WithContext(ctx).Iter()
-	for it.Scan(&key.Symbol, &key.HourNo) {
-		keys = append(keys, key)
-	}
-	err := it.Close()
-	if err != nil {
-		s.logger.Error(err.Error())
-	}
-	return keys, err
+	return s.keyScanner.ScanKeys(ctx)
 }
 
 func (s CsSnapshotStorage) Delete(ctx context.Context, key *model.ProcessingKey) error {
@@ -35,7 +35,15 @@ func NewDwarfHttpClient(urlCfg *conf.BaseUriConfig) *DwarfHttpClient {
 }
 
 func (s *DwarfHttpClient) SaveDeltaHole(ctx context.Context, hole model.DeltaHole) error {
-	req, err := s.createRequest(hole)
+	return s.post(ctx, "delta/hole", hole)
+}
+
+func (s *DwarfHttpClient) SaveValidationReport(ctx context.Context, report *model.ValidationReport) error {
+	return s.post(ctx, "validation/report", report)
+}
+
+func (s *DwarfHttpClient) post(ctx context.Context, path string, payload any) error {
+	req, err := s.createRequest(ctx, path, payload)
 	if err != nil {
 		s.logger.Error(err.Error())
 		return err
@@ -45,6 +53,7 @@ func (s *DwarfHttpClient) SaveDeltaHole(ctx context.Context, hole model.DeltaHol
 		s.logger.Error(err.Error())
 		return err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusInternalServerError {
 		err := errors.New("500 response status from dwarf")
 		s.logger.Error(err.Error())
@@ -53,12 +62,12 @@ func (s *DwarfHttpClient) SaveDeltaHole(ctx context.Context, hole model.DeltaHol
 	return nil
 }
 
-func (s *DwarfHttpClient) createRequest(hole model.DeltaHole) (*http.Request, error) {
-	body, err := json.Marshal(hole)
+func (s *DwarfHttpClient) createRequest(ctx context.Context, path string, payload any) (*http.Request, error) {
+	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/delta/hole", s.url), bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/%s", s.url, path), bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/dwarf/svc"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type ReportsRouter struct {
+	logger   *zap.Logger
+	dwarfSvc *svc.DwarfSvc
+}
+
+func NewReportsRouter(dwarfSvc *svc.DwarfSvc) *ReportsRouter {
+	return &ReportsRouter{
+		logger:   log.GetLogger("ReportsRouter"),
+		dwarfSvc: dwarfSvc,
+	}
+}
+
+func (s *ReportsRouter) SaveValidationReportHandler(w http.ResponseWriter, r *http.Request) {
+	serviceName := r.Header.Get(ServiceNameHeaderName)
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		s.logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var report model.ValidationReport
+	err = json.Unmarshal(body, &report)
+	if err != nil {
+		s.logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if ok := s.dwarfSvc.SaveValidationReport(context.Background(), serviceName, report); ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (s *ReportsRouter) GetValidationReportsHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		s.logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var reqBody svc.GetValidationReportsRequest
+	err = json.Unmarshal(body, &reqBody)
+	if err != nil {
+		s.logger.Error(err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	reports, err := s.dwarfSvc.GetValidationReports(context.Background(), &reqBody)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	s.logger.Debug(fmt.Sprintf("Got %d validation reports", len(reports)))
+	respBody, err := json.Marshal(reports)
+	if err != nil {
+		s.logger.Error(err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err = w.Write(respBody); err != nil {
+		s.logger.Error(err.Error())
+	}
+}
@@ -23,6 +23,7 @@ type App struct {
 	httpServer        *http.Server
 	dwarfSvc          *svc.DwarfSvc
 	deltaHolesStorage svc.HolesStorage
+	reportsStorage    svc.ValidationReportsStorage
 }
 
 func NewApp(cfg *cfg.AppConfig) *App {
@@ -34,7 +35,8 @@ func NewApp(cfg *cfg.AppConfig) *App {
 	}
 	fmt.Println(string(rawCfg))
 	deltaHolesStorage := repo.NewMongoDeltaHoleStorage(cfg.HolesStorageCfg)
-	dwarfSvc := svc.NewDwarfSvc(deltaHolesStorage)
+	reportsStorage := repo.NewMongoValidationReportStorage(cfg.HolesStorageCfg)
+	dwarfSvc := svc.NewDwarfSvc(deltaHolesStorage, reportsStorage)
 	metrics := metrics.NewApiMetrics()
 	return &App{
 		logger:   logger,
@@ -44,6 +46,7 @@ func NewApp(cfg *cfg.AppConfig) *App {
 			Handler: initApi(dwarfSvc, metrics, logger),
 		},
 		deltaHolesStorage: deltaHolesStorage,
+		reportsStorage:    reportsStorage,
 	}
 }
 
@@ -52,6 +55,9 @@ func (s *App) Start() {
 	if err := s.deltaHolesStorage.Connect(baseContext); err != nil {
 		s.logger.Error(err.Error())
 	}
+	if err := s.reportsStorage.Connect(baseContext); err != nil {
+		s.logger.Error(err.Error())
+	}
 	go func() {
 		http.Handle("/metrics", promhttp.Handler())
 		err := http.ListenAndServe(":9001", nil)
@@ -79,6 +85,7 @@ func (s *App) Stop(ctx context.Context) {
 
 func initApi(dwarfSvc *svc.DwarfSvc, metrics svc.Metrics, logger *zap.Logger) http.Handler {
 	holesRouter := api.NewHolesRouter(dwarfSvc)
+	reportsRouter := api.NewReportsRouter(dwarfSvc)
 	r := mux.NewRouter()
 	r.
 		HandleFunc("/delta/hole", func(w http.ResponseWriter, r *http.Request) {
@@ -92,6 +99,12 @@ func initApi(dwarfSvc *svc.DwarfSvc, metrics svc.Metrics, logger *zap.Logger) ht
 			holesRouter.GetDeltaHolesHandler(w, r)
 		}).
 		Methods(http.MethodGet)
+	r.
+		HandleFunc("/validation/report", reportsRouter.SaveValidationReportHandler).
+		Methods(http.MethodPost)
+	r.
+		HandleFunc("/validation/report", reportsRouter.GetValidationReportsHandler).
+		Methods(http.MethodGet)
 	r.Use(log.CreateMiddleware(logger))
 	return r
 }
@@ -7,14 +7,16 @@ import (
 )
 
 type HolesStorageConfig struct {
-	MongoConfig       *mconf.MongoRepoConfig `yaml:"mongo"`
-	DeltaHolesColName string                 `yaml:"spot.deltas.table"`
+	MongoConfig              *mconf.MongoRepoConfig `yaml:"mongo"`
+	DeltaHolesColName        string                 `yaml:"spot.deltas.table"`
+	ValidationReportsColName string                 `yaml:"validation.reports.table"`
 }
 
 func NewHolesStorageConfigFromEnv(envPrefix string) *HolesStorageConfig {
 	return &HolesStorageConfig{
-		DeltaHolesColName: os.Getenv(envPrefix + ".spot.deltas.table"),
-		MongoConfig:       mconf.NewMongoRepoConfigFromEnv(envPrefix + ".mongo"),
+		DeltaHolesColName:        os.Getenv(envPrefix + ".spot.deltas.table"),
+		ValidationReportsColName: os.Getenv(envPrefix + ".validation.reports.table"),
+		MongoConfig:              mconf.NewMongoRepoConfigFromEnv(envPrefix + ".mongo"),
 	}
 }
 
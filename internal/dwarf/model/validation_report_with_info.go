@@ -0,0 +1,15 @@
+package model
+
+import cmodel "DeltaReceiver/internal/common/model"
+
+type ValidationReportWithInfo struct {
+	ServiceName             string `json:"service_name" bson:"service_name"`
+	cmodel.ValidationReport `bson:",inline"`
+}
+
+func NewValidationReportWithInfo(serviceName string, report *cmodel.ValidationReport) *ValidationReportWithInfo {
+	return &ValidationReportWithInfo{
+		ServiceName:      serviceName,
+		ValidationReport: *report,
+	}
+}
@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/dwarf/cfg"
+	"DeltaReceiver/internal/dwarf/model"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type MongoValidationReportStorage struct {
+	logger     *zap.Logger
+	ReportsCol *mongo.Collection
+	cfg        *cfg.HolesStorageConfig
+}
+
+func NewMongoValidationReportStorage(cfg *cfg.HolesStorageConfig) *MongoValidationReportStorage {
+	return &MongoValidationReportStorage{
+		logger:     log.GetLogger("MongoValidationReportStorage"),
+		cfg:        cfg,
+		ReportsCol: &mongo.Collection{},
+	}
+}
+
+func (s MongoValidationReportStorage) Connect(ctx context.Context) error {
+	s.logger.Debug("start connection to mongo")
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.MongoConfig.TimeoutS)*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(s.cfg.MongoConfig.URI.GetBaseUri()))
+	if err != nil {
+		return fmt.Errorf("error while connecting to mongo %w", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Duration(s.cfg.MongoConfig.TimeoutS)*time.Second)
+	defer cancel()
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error while pinging to mongo %w", err)
+	}
+	s.logger.Debug("successfully connected to mongo")
+	db := client.Database(s.cfg.MongoConfig.DatabaseName)
+	*s.ReportsCol = *db.Collection(s.cfg.ValidationReportsColName)
+	return nil
+}
+
+// SaveValidationReport replaces the report of the same storage, symbol and hour, so
+// retried uploads do not duplicate reports.
+func (s MongoValidationReportStorage) SaveValidationReport(ctx context.Context, report *model.ValidationReportWithInfo) error {
+	filter := bson.M{"storage_name": report.StorageName, "symbol": report.Symbol, "hour_no": report.HourNo}
+	_, err := s.ReportsCol.ReplaceOne(ctx, filter, report, options.Replace().SetUpsert(true))
+	if err != nil {
+		err = fmt.Errorf("error while saving validation report %w", err)
+	}
+	return err
+}
+
+// GetValidationReports returns reports of hours started in [fromTsMs, toTsMs], empty
+// storageName and symbol match any.
+func (s MongoValidationReportStorage) GetValidationReports(ctx context.Context, storageName, symbol string, fromTsMs, toTsMs int64) ([]model.ValidationReportWithInfo, error) {
+	s.logger.Debug(fmt.Sprintf("Get validation reports request from %d to %d", fromTsMs, toTsMs))
+	filter := bson.M{"timestamp_ms": bson.M{"$gte": fromTsMs, "$lte": toTsMs}}
+	if storageName != "" {
+		filter["storage_name"] = storageName
+	}
+	if symbol != "" {
+		filter["symbol"] = symbol
+	}
+	cur, err := s.ReportsCol.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "timestamp_ms", Value: 1}}))
+	if err != nil {
+		err = fmt.Errorf("error while getting validation reports %w", err)
+		return nil, err
+	}
+	var reports []model.ValidationReportWithInfo
+	if err := cur.All(ctx, &reports); err != nil {
+		err = fmt.Errorf("error while getting validation reports %w", err)
+		return nil, err
+	}
+	return reports, nil
+}
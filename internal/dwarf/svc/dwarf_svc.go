@@ -12,14 +12,16 @@ import (
 )
 
 type DwarfSvc struct {
-	logger       *zap.Logger
-	HolesStorage HolesStorage
+	logger         *zap.Logger
+	HolesStorage   HolesStorage
+	ReportsStorage ValidationReportsStorage
 }
 
-func NewDwarfSvc(holesStorage HolesStorage) *DwarfSvc {
+func NewDwarfSvc(holesStorage HolesStorage, reportsStorage ValidationReportsStorage) *DwarfSvc {
 	return &DwarfSvc{
-		logger:       log.GetLogger("DwarfSvc"),
-		HolesStorage: holesStorage,
+		logger:         log.GetLogger("DwarfSvc"),
+		HolesStorage:   holesStorage,
+		ReportsStorage: reportsStorage,
 	}
 }
 
@@ -29,6 +31,12 @@ type HolesStorage interface {
 	GetDeltaHoles(context.Context, int64, int64) ([]model.DeltaHoleWithInfo, error)
 }
 
+type ValidationReportsStorage interface {
+	Connect(context.Context) error
+	SaveValidationReport(context.Context, *model.ValidationReportWithInfo) error
+	GetValidationReports(ctx context.Context, storageName, symbol string, fromTsMs, toTsMs int64) ([]model.ValidationReportWithInfo, error)
+}
+
 type Metrics interface {
 	IncNumCallsCreateDeltaHole(string)
 }
@@ -78,3 +86,32 @@ func (s *DwarfSvc) GetDeltaHoles(ctx context.Context, req *GetDeltaHolesRequest)
 	}
 	return deltaHoles, nil
 }
+
+func (s *DwarfSvc) SaveValidationReport(ctx context.Context, serviceName string, report cmodel.ValidationReport) bool {
+	reportWithInfo := model.NewValidationReportWithInfo(serviceName, &report)
+	for i := 0; i < 3; i++ {
+		if err := s.ReportsStorage.SaveValidationReport(ctx, reportWithInfo); err == nil {
+			return true
+		} else {
+			s.logger.Error(err.Error())
+		}
+	}
+	return false
+}
+
+type GetValidationReportsRequest struct {
+	FromTs      RFC3339JSONTime `json:"timestamp_from"`
+	ToTs        RFC3339JSONTime `json:"timestamp_to"`
+	StorageName string          `json:"storage_name"`
+	Symbol      string          `json:"symbol"`
+}
+
+func (s *DwarfSvc) GetValidationReports(ctx context.Context, req *GetValidationReportsRequest) ([]model.ValidationReportWithInfo, error) {
+	s.logger.Debug(fmt.Sprintf("Get validation reports request from %s to %s", req.FromTs.ts, req.ToTs.ts))
+	reports, err := s.ReportsStorage.GetValidationReports(ctx, req.StorageName, req.Symbol, req.FromTs.ts.UnixMilli(), req.ToTs.ts.UnixMilli())
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	return reports, nil
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"DeltaReceiver/internal/nestor/book"
+	"DeltaReceiver/internal/nestor/conf"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/log"
+	"DeltaReceiver/pkg/nestorpb"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const zeroQuantity = "0"
+
+// BookServer serves the books of the market builders over gRPC.
+type BookServer struct {
+	nestorpb.UnimplementedBookServiceServer
+	logger       *zap.Logger
+	builders     map[bmodel.DataType]*book.Builder
+	defaultDepth int
+	maxDepth     int
+	port         int
+	grpcServer   *grpc.Server
+	shutdownCh   chan struct{}
+}
+
+func NewBookServer(builders map[bmodel.DataType]*book.Builder, cfg *conf.GrpcCfg) *BookServer {
+	server := &BookServer{
+		logger:       log.GetLogger("BookServer"),
+		builders:     builders,
+		defaultDepth: cfg.BookDefaultDepth,
+		maxDepth:     cfg.BookMaxDepth,
+		port:         cfg.Port,
+		grpcServer:   grpc.NewServer(),
+		shutdownCh:   make(chan struct{}),
+	}
+	nestorpb.RegisterBookServiceServer(server.grpcServer, server)
+	return server
+}
+
+func (s *BookServer) Start() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return err
+	}
+	go func() {
+		s.logger.Info(fmt.Sprintf("book server listens on %s", listener.Addr()))
+		if err := s.grpcServer.Serve(listener); err != nil {
+			s.logger.Error(err.Error())
+		}
+	}()
+	return nil
+}
+
+// Shutdown ends the streams, they never finish on their own.
+func (s *BookServer) Shutdown(ctx context.Context) {
+	close(s.shutdownCh)
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+	}
+}
+
+func (s *BookServer) GetBook(ctx context.Context, req *nestorpb.BookRequest) (*nestorpb.BookSnapshot, error) {
+	builder, symbol, depth, err := s.parseRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	view, ok := builder.GetBook(symbol, depth)
+	if !ok {
+		return nil, status.Errorf(codes.Unavailable, "book of %s is not in sync", symbol)
+	}
+	return toSnapshot(req.Market, view), nil
+}
+
+// StreamBook sends a snapshot and then the differences between consecutive views of
+// the book. Views are taken on notifications of the builder which are coalesced, so a
+// slow stream skips intermediate states instead of blocking the ingestion.
+func (s *BookServer) StreamBook(req *nestorpb.BookRequest, stream nestorpb.BookService_StreamBookServer) error {
+	builder, symbol, depth, err := s.parseRequest(req)
+	if err != nil {
+		return err
+	}
+	notifyCh, stopWatching, err := builder.Watch(symbol)
+	if err != nil {
+		return status.Errorf(codes.NotFound, "book of %s: %s", symbol, err.Error())
+	}
+	defer stopWatching()
+	var sequence uint64
+	var lastView *book.View
+	for {
+		if view, ok := builder.GetBook(symbol, depth); ok {
+			update := &nestorpb.BookUpdate{}
+			if lastView == nil || view.Epoch != lastView.Epoch {
+				update.Update = &nestorpb.BookUpdate_Snapshot{Snapshot: toSnapshot(req.Market, view)}
+			} else if view.UpdateId != lastView.UpdateId {
+				delta := &nestorpb.BookDelta{
+					TimestampMs: view.TimestampMs,
+					UpdateId:    view.UpdateId,
+					Bids:        diffLevels(lastView.Bids, view.Bids),
+					Asks:        diffLevels(lastView.Asks, view.Asks),
+				}
+				if len(delta.Bids) > 0 || len(delta.Asks) > 0 {
+					update.Update = &nestorpb.BookUpdate_Delta{Delta: delta}
+				}
+			}
+			if update.Update != nil {
+				sequence++
+				update.Sequence = sequence
+				if err := stream.Send(update); err != nil {
+					return err
+				}
+			}
+			lastView = view
+		}
+		select {
+		case <-notifyCh:
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-s.shutdownCh:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		}
+	}
+}
+
+func (s *BookServer) parseRequest(req *nestorpb.BookRequest) (*book.Builder, string, int, error) {
+	builder, ok := s.builders[bmodel.DataType(req.Market)]
+	if !ok {
+		return nil, "", 0, status.Errorf(codes.InvalidArgument, "unknown market %s", req.Market)
+	}
+	if req.Symbol == "" {
+		return nil, "", 0, status.Error(codes.InvalidArgument, "empty symbol")
+	}
+	depth := int(req.Depth)
+	if depth < 0 || depth > s.maxDepth {
+		return nil, "", 0, status.Errorf(codes.InvalidArgument, "depth must be in [0, %d]", s.maxDepth)
+	}
+	if depth == 0 {
+		depth = s.defaultDepth
+	}
+	return builder, strings.ToUpper(req.Symbol), depth, nil
+}
+
+func toSnapshot(market string, view *book.View) *nestorpb.BookSnapshot {
+	return &nestorpb.BookSnapshot{
+		Market:      market,
+		Symbol:      view.Symbol,
+		TimestampMs: view.TimestampMs,
+		UpdateId:    view.UpdateId,
+		Bids:        toPriceLevels(view.Bids),
+		Asks:        toPriceLevels(view.Asks),
+	}
+}
+
+func toPriceLevels(levels []book.Level) []*nestorpb.PriceLevel {
+	priceLevels := make([]*nestorpb.PriceLevel, 0, len(levels))
+	for _, level := range levels {
+		priceLevels = append(priceLevels, &nestorpb.PriceLevel{Price: level.Price, Quantity: level.Quantity})
+	}
+	return priceLevels
+}
+
+// diffLevels returns levels that appeared or changed quantity and removed levels with zero quantity.
+func diffLevels(prevLevels, levels []book.Level) []*nestorpb.PriceLevel {
+	prevQuantities := make(map[string]string, len(prevLevels))
+	for _, level := range prevLevels {
+		prevQuantities[level.Price] = level.Quantity
+	}
+	var changed []*nestorpb.PriceLevel
+	for _, level := range levels {
+		if prevQuantity, ok := prevQuantities[level.Price]; !ok || prevQuantity != level.Quantity {
+			changed = append(changed, &nestorpb.PriceLevel{Price: level.Price, Quantity: level.Quantity})
+		}
+		delete(prevQuantities, level.Price)
+	}
+	for _, level := range prevLevels {
+		if _, removed := prevQuantities[level.Price]; removed {
+			changed = append(changed, &nestorpb.PriceLevel{Price: level.Price, Quantity: zeroQuantity})
+		}
+	}
+	return changed
+}
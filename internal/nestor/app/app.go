@@ -2,17 +2,28 @@ package app
 
 import (
 	cconf "DeltaReceiver/internal/common/conf"
+	"DeltaReceiver/internal/nestor/api"
+	"DeltaReceiver/internal/nestor/book"
 	"DeltaReceiver/internal/nestor/conf"
+	"DeltaReceiver/internal/nestor/fanout"
+	"DeltaReceiver/internal/nestor/metrics"
+	"DeltaReceiver/internal/nestor/shard"
 	"DeltaReceiver/pkg/binance"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/clickhouse"
 	"DeltaReceiver/pkg/log"
+	pmongo "DeltaReceiver/pkg/mongo"
+	mconf "DeltaReceiver/pkg/mongo/conf"
 	"context"
 	"fmt"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/go-zookeeper/zk"
 	"github.com/gocql/gocql"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 	"gopkg.in/yaml.v3"
 )
@@ -22,6 +33,10 @@ type App struct {
 	binanceSpotCtx *BinanceMarketCtx
 	binanceUSDCtx  *BinanceMarketCtx
 	binanceCoinCtx *BinanceMarketCtx
+	fanoutServer   *fanout.Server
+	bookServer     *api.BookServer
+	sharder        *shard.ZkSharder
+	chPool         *clickhouse.ChPoolHolder
 	cfg            *conf.AppConfig
 }
 
@@ -42,17 +57,66 @@ func NewApp(cfg *conf.AppConfig) *App {
 	var binanceUSDCtx *BinanceMarketCtx
 	var binanceCoinCtx *BinanceMarketCtx
 
+	var mongoDb *mongo.Database
+	var mongoTimeoutS int
+	mongoRepoCfg := cfg.MongoRepoCfg
+	if mongoRepoCfg == nil {
+		mongoRepoCfg = &conf.MongoRepoConfig{}
+	} else {
+		mongoDb = initMongo(mongoRepoCfg.MongoConfig)
+		mongoTimeoutS = int(mongoRepoCfg.MongoConfig.TimeoutS)
+	}
+
+	var chPool *clickhouse.ChPoolHolder
+	var spotChRepoCfg, usdChRepoCfg, coinChRepoCfg *cconf.GlobalRepoConfig
+	if chRepoCfg := cfg.ChRepoCfg; chRepoCfg != nil {
+		chPool = initCh(logger, chRepoCfg.PoolCfg)
+		spotChRepoCfg = chRepoCfg.MarketRepoCfg(chRepoCfg.BinanceSpotCfg)
+		usdChRepoCfg = chRepoCfg.MarketRepoCfg(chRepoCfg.BinanceUSDCfg)
+		coinChRepoCfg = chRepoCfg.MarketRepoCfg(chRepoCfg.BinanceCoinCfg)
+	}
+
+	var sharder *shard.ZkSharder
+	if cfg.ShardingCfg.Enabled {
+		sharder = shard.NewZkSharder(initZk(cfg.ShardingCfg.ZkCfg), cfg.ShardingCfg, metrics.NewShardingMetrics())
+	}
+
+	var fanoutHub *fanout.Hub
+	var fanoutServer *fanout.Server
+	if cfg.FanoutCfg.Enabled {
+		markets := []bmodel.DataType{bmodel.Spot}
+		if cfg.Mode == conf.Future {
+			markets = []bmodel.DataType{bmodel.FuturesUSD, bmodel.FuturesCoin}
+		}
+		fanoutHub = fanout.NewHub(markets, cfg.FanoutCfg.SlowConsumerPolicy, metrics.NewFanoutMetrics())
+		fanoutServer = fanout.NewServer(fanoutHub, cfg.FanoutCfg)
+	}
+
 	if cfg.Mode == conf.Spot {
-		binanceSpotCtx = NewBinanceMarketCtx(cfg.BinanceSpotCfg, csCfg.BinanceSpotCfg, csSession, binanceReconnectPeriod)
+		binanceSpotCtx = NewBinanceMarketCtx(cfg.BinanceSpotCfg, csCfg.BinanceSpotCfg, mongoRepoCfg.BinanceSpotCfg, cfg.SpoolCfg, cfg.FixerCfg, csSession, mongoDb, mongoTimeoutS, chPool, spotChRepoCfg, fanoutHub, cfg.FanoutCfg, cfg.GrpcCfg, sharder, cfg.ServerClockCfg, binanceReconnectPeriod)
 	} else {
-		binanceUSDCtx = NewBinanceMarketCtx(cfg.BinanceUSDCfg, csCfg.BinanceUSDCfg, csSession, binanceReconnectPeriod)
-		binanceCoinCtx = NewBinanceMarketCtx(cfg.BinanceCoinCfg, csCfg.BinanceCoinCfg, csSession, binanceReconnectPeriod)
+		binanceUSDCtx = NewBinanceMarketCtx(cfg.BinanceUSDCfg, csCfg.BinanceUSDCfg, mongoRepoCfg.BinanceUSDCfg, cfg.SpoolCfg, cfg.FixerCfg, csSession, mongoDb, mongoTimeoutS, chPool, usdChRepoCfg, fanoutHub, cfg.FanoutCfg, cfg.GrpcCfg, sharder, cfg.ServerClockCfg, binanceReconnectPeriod)
+		binanceCoinCtx = NewBinanceMarketCtx(cfg.BinanceCoinCfg, csCfg.BinanceCoinCfg, mongoRepoCfg.BinanceCoinCfg, cfg.SpoolCfg, cfg.FixerCfg, csSession, mongoDb, mongoTimeoutS, chPool, coinChRepoCfg, fanoutHub, cfg.FanoutCfg, cfg.GrpcCfg, sharder, cfg.ServerClockCfg, binanceReconnectPeriod)
+	}
+	var bookServer *api.BookServer
+	if cfg.GrpcCfg.Enabled {
+		bookBuilders := make(map[bmodel.DataType]*book.Builder)
+		for _, marketCtx := range []*BinanceMarketCtx{binanceSpotCtx, binanceUSDCtx, binanceCoinCtx} {
+			if marketCtx != nil {
+				bookBuilders[marketCtx.marketType] = marketCtx.BookBuilder()
+			}
+		}
+		bookServer = api.NewBookServer(bookBuilders, cfg.GrpcCfg)
 	}
 	return &App{
 		logger:         logger,
 		binanceSpotCtx: binanceSpotCtx,
 		binanceUSDCtx:  binanceUSDCtx,
 		binanceCoinCtx: binanceCoinCtx,
+		fanoutServer:   fanoutServer,
+		bookServer:     bookServer,
+		sharder:        sharder,
+		chPool:         chPool,
 		cfg:            cfg,
 	}
 }
@@ -68,6 +132,34 @@ func initCs(cfg *cconf.CsRepoConfig) *gocql.Session {
 	return session
 }
 
+func initZk(cfg *cconf.ZkConfig) *zk.Conn {
+	zkConn, _, err := zk.Connect(cfg.Servers, time.Second*time.Duration(cfg.SessionTimeoutS))
+	if err != nil {
+		panic(err)
+	}
+	return zkConn
+}
+
+func initMongo(cfg *mconf.MongoRepoConfig) *mongo.Database {
+	db, ok := pmongo.ConnectMongo(log.GetLogger("Mongo"), cfg)
+	if !ok {
+		panic("failed to connect to mongo")
+	}
+	return db
+}
+
+// initCh does not fail the start: the pool holder redials on the next write and the
+// batches are kept by the fallback storages meanwhile.
+func initCh(logger *zap.Logger, cfg *clickhouse.ChPoolCfg) *clickhouse.ChPoolHolder {
+	chPool := clickhouse.NewChPoolHolder(cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.DialTimeoutS)*time.Second)
+	defer cancel()
+	if err := chPool.Connect(ctx); err != nil {
+		logger.Error(fmt.Sprintf("failed to connect to clickhouse: %s", err.Error()))
+	}
+	return chPool
+}
+
 func (s *App) Start() {
 	baseContext := context.Background()
 	go func() {
@@ -78,6 +170,20 @@ func (s *App) Start() {
 		}
 	}()
 	time.Sleep(2 * time.Second)
+	if s.sharder != nil {
+		// the replica would take all symbols without the first assignment
+		if err := s.sharder.Start(baseContext); err != nil {
+			panic(err)
+		}
+	}
+	if s.fanoutServer != nil {
+		s.fanoutServer.Start()
+	}
+	if s.bookServer != nil {
+		if err := s.bookServer.Start(); err != nil {
+			panic(err)
+		}
+	}
 	s.logger.Info("App started")
 	if s.cfg.Mode == conf.Spot {
 		go s.binanceSpotCtx.Start(baseContext)
@@ -89,6 +195,15 @@ func (s *App) Start() {
 
 func (s *App) Stop(ctx context.Context) {
 	s.logger.Info("Begin of graceful shutdown")
+	if s.fanoutServer != nil {
+		s.fanoutServer.Shutdown(ctx)
+	}
+	if s.bookServer != nil {
+		s.bookServer.Shutdown(ctx)
+	}
+	if s.sharder != nil {
+		s.sharder.Stop()
+	}
 	var wg sync.WaitGroup
 	if s.cfg.Mode == conf.Spot {
 		wg.Add(1)
@@ -109,5 +224,8 @@ func (s *App) Stop(ctx context.Context) {
 	}
 	wg.Wait()
 	time.Sleep(30 * time.Second)
+	if s.chPool != nil {
+		s.chPool.Disconnect()
+	}
 	s.logger.Info("End of graceful shutdown")
 }
@@ -4,133 +4,293 @@ import (
 	cconf "DeltaReceiver/internal/common/conf"
 	cm "DeltaReceiver/internal/common/metrics"
 	cmodel "DeltaReceiver/internal/common/model"
+	crepo "DeltaReceiver/internal/common/repo"
 	"DeltaReceiver/internal/common/repo/cs"
+	"DeltaReceiver/internal/nestor/book"
 	"DeltaReceiver/internal/nestor/cache"
 	"DeltaReceiver/internal/nestor/conf"
+	"DeltaReceiver/internal/nestor/fanout"
 	"DeltaReceiver/internal/nestor/metrics"
 	"DeltaReceiver/internal/nestor/model"
 	"DeltaReceiver/internal/nestor/repo"
+	"DeltaReceiver/internal/nestor/shard"
 	"DeltaReceiver/internal/nestor/svc"
 	"DeltaReceiver/internal/nestor/web"
 	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/clickhouse"
 	"DeltaReceiver/pkg/log"
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/gocql/gocql"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
 
 type BinanceMarketCtx struct {
-	logger              *zap.Logger
-	marketType          bmodel.DataType
-	deltaSvc            *svc.WsSvc[bmodel.DeltaMessage, cmodel.Delta]
-	ticksSvc            *svc.WsSvc[bmodel.SymbolTick, bmodel.SymbolTick]
-	snapshotSvc         *svc.SnapshotSvc
-	exInfoSvc           *svc.ExchangeInfoSvc
-	exchangeInfoStorage svc.ExchangeInfoStorage
-	exInfoCache         *cache.ExchangeInfoCache
-	binanceClient       svc.BinanceClient
-	deltaFixer          svc.Fixer
-	ticksFixer          svc.Fixer
-	snapshotFixer       svc.Fixer
-	exInfoFixer         svc.Fixer
+	logger          *zap.Logger
+	marketType      bmodel.DataType
+	deltaSvc        *svc.WsSvc[bmodel.DeltaMessage, cmodel.Delta]
+	ticksSvc        *svc.WsSvc[bmodel.SymbolTick, bmodel.SymbolTick]
+	tradesSvc       *svc.TradesSvc
+	snapshotSvc     *svc.SnapshotSvc
+	exInfoSvc       *svc.ExchangeInfoSvc
+	exInfoCache     *cache.ExchangeInfoCache
+	binanceClient   svc.BinanceClient
+	deltaFixer      svc.Fixer
+	ticksFixer      svc.Fixer
+	snapshotFixer   svc.Fixer
+	exInfoFixer     svc.Fixer
+	bookBuilder     *book.Builder
+	serverClock     *svc.ServerClockSvc
+	spoolClosers    []func(context.Context)
+	stopFixers      context.CancelFunc
+	stopServerClock context.CancelFunc
 }
 
+const bookResyncQueueSize = 1024
+
 func NewBinanceMarketCtx(
 	marketCfg *conf.BinanceMarketCfg,
 	marketCsRepoCfg *cconf.BinanceMarketCsRepoCfg,
+	marketMongoRepoCfg *conf.BinanceMarketMongoRepoCfg,
+	spoolCfg *conf.SpoolCfg,
+	fixerCfg *conf.DataFixerCfg,
 	csSession *gocql.Session,
+	mongoDb *mongo.Database,
+	mongoTimeoutS int,
+	chPool *clickhouse.ChPoolHolder,
+	marketChRepoCfg *cconf.GlobalRepoConfig,
+	fanoutHub *fanout.Hub,
+	fanoutCfg *conf.FanoutCfg,
+	grpcCfg *conf.GrpcCfg,
+	sharder *shard.ZkSharder,
+	serverClockCfg *conf.ServerClockCfg,
 	binanceReconnectPeriod time.Duration,
 ) *BinanceMarketCtx {
 	marketType := bmodel.DataType(marketCfg.DataType)
 	exInfoCache := cache.NewExchangeInfoCache()
 	binanceClient := web.NewBinanceClient(marketType, marketCfg.BinanceHttpCfg, exInfoCache)
+	var chRepo *repo.ClickhouseRepo
+	if marketChRepoCfg != nil {
+		chRepo = repo.NewClickhouseRepo(chPool, marketChRepoCfg)
+	}
+	var deltasTransformator svc.DataTransformator[bmodel.DeltaMessage, cmodel.Delta] = model.NewDeltaDataTransformator()
+	var ticksTransformator svc.DataTransformator[bmodel.SymbolTick, bmodel.SymbolTick] = model.NewNoChangeTransformator[bmodel.SymbolTick]()
+	var serverClock *svc.ServerClockSvc
+	if serverClockCfg.Enabled {
+		serverClock = svc.NewServerClockSvc(marketType, binanceClient, metrics.NewServerClockMetrics(marketType), serverClockCfg)
+		if serverClockCfg.CorrectReceiveTime {
+			ticksTransformator = svc.NewReceiveTimeTransformator(ticksTransformator, serverClock)
+		}
+	}
+	var symbolsFilter svc.SymbolsFilter
+	var deltasReassignCh, ticksReassignCh, tradesReassignCh <-chan struct{}
+	if sharder != nil {
+		symbolsFilter = sharder
+		deltasReassignCh = sharder.Subscribe()
+		ticksReassignCh = sharder.Subscribe()
+		if fanoutHub != nil {
+			tradesReassignCh = sharder.Subscribe()
+		}
+		if marketCfg.BinanceHttpCfg.UseAllTickersStream {
+			ticksTransformator = svc.NewFilteringTransformator(ticksTransformator, symbolsFilter)
+		}
+	}
+	var snapshotPublisher svc.DataPublisher[cmodel.DepthSnapshotPart]
+	var bookBuilder *book.Builder
+	var bookResyncCh chan string
+	if fanoutHub != nil || grpcCfg.Enabled {
+		bookResyncCh = make(chan string, bookResyncQueueSize)
+		isTracked := func(symbol string) bool {
+			if symbolsFilter != nil && !symbolsFilter.Owns(symbol) {
+				return false
+			}
+			return slices.Contains(exInfoCache.GetTradingSymbols(), symbol)
+		}
+		bookBuilder = book.NewBuilder(marketType, isTracked, bookResyncCh)
+		deltasTransformator = svc.NewPublishingTransformator(deltasTransformator, book.NewDeltasPublisher(bookBuilder))
+		snapshotPublisher = book.NewSnapshotPublisher(bookBuilder)
+	}
+	if fanoutHub != nil {
+		deltasTransformator = svc.NewPublishingTransformator(deltasTransformator, fanout.NewDeltaPublisher(fanoutHub, marketType, bookBuilder, fanoutCfg.BookDepth))
+		ticksTransformator = svc.NewPublishingTransformator(ticksTransformator, fanout.NewBookTicksPublisher(fanoutHub, marketType))
+	}
 
 	// deltas
 	loggerParam := string("deltas_" + marketType)
-	deltaCsStorage := cs.NewCsDeltaStorageWO(loggerParam, csSession, cm.NewCsStorageMetrics(marketCsRepoCfg.DeltaTableName, marketType), marketCsRepoCfg.DeltaTableName, marketCsRepoCfg.DeltaKeyTableName)
-	deltaFileStorage := repo.NewFileRepo[cmodel.Delta](loggerParam)
-	deltaStorages := []svc.BatchedDataStorage[cmodel.Delta]{deltaCsStorage, deltaFileStorage}
-	deltasTransformator := model.NewDeltaDataTransformator()
-	deltasMetrics := metrics.NewWsPipelineMetrics[cmodel.Delta](loggerParam)
-	deltaWorkerProvider := svc.NewDeltaWorkerProvider(marketCfg.BinanceHttpCfg, loggerParam, marketType, deltasTransformator, marketCfg.DeltasPipelineCfg.BatchSize, deltaStorages, deltasMetrics)
-	deltaWorkersProvider := svc.NewTradingSymbolsWorkersProvider(loggerParam, marketCfg.DeltasPipelineCfg.NumWorkers, deltaWorkerProvider, exInfoCache)
-	deltaSvc := svc.NewWsSvc(loggerParam, deltaWorkersProvider, deltaStorages, deltasMetrics, binanceReconnectPeriod, exInfoCache)
-	deltaFixer := svc.NewDataFixer(loggerParam, deltaCsStorage, []svc.AuxBatchedDataStorage[cmodel.Delta]{deltaFileStorage})
+	deltaStorageChain := newStorageChain(marketCfg.DeltasPipelineCfg.Storages, map[conf.StorageType]storageFactory[cmodel.Delta]{
+		conf.CassandraStorage: func() svc.BatchedDataStorage[cmodel.Delta] {
+			return cs.NewCsDeltaStorageWO(loggerParam, csSession, cm.NewCsStorageMetrics(marketCsRepoCfg.DeltaTableName, marketType), marketCsRepoCfg.DeltaTableName, marketCsRepoCfg.DeltaKeyTableName)
+		},
+		conf.MongoStorage: func() svc.BatchedDataStorage[cmodel.Delta] {
+			return repo.NewLocalMongoRepo[cmodel.Delta, cmodel.DeltaWithId](mongoTimeoutS, marketMongoRepoCfg.DeltaColName, mongoDb)
+		},
+		conf.ClickhouseStorage: func() svc.BatchedDataStorage[cmodel.Delta] {
+			return crepo.NewChDeltaStorage(chPool, marketChRepoCfg.DatabaseName, marketChRepoCfg.DeltaTable, marketChRepoCfg.InsertCfg)
+		},
+		conf.FileStorage: func() svc.BatchedDataStorage[cmodel.Delta] {
+			return repo.NewFileRepo[cmodel.Delta](loggerParam, spoolCfg)
+		},
+	})
+	deltasMetrics := metrics.NewWsPipelineMetrics[cmodel.Delta](loggerParam, "deltas", marketType, true, marketCfg.DeltasPipelineCfg.MetricsCfg)
+	deltaWorkerProvider := svc.NewDeltaWorkerProvider(marketCfg.BinanceHttpCfg, loggerParam, marketType, deltasTransformator, marketCfg.DeltasPipelineCfg, deltaStorageChain.storages, deltaStorageChain.spoolStorage, deltasMetrics)
+	deltaWorkersProvider := svc.NewTradingSymbolsWorkersProvider(loggerParam, marketCfg.DeltasPipelineCfg.NumWorkers, deltaWorkerProvider, exInfoCache, symbolsFilter)
+	deltaSvc := svc.NewWsSvc(loggerParam, deltaWorkersProvider, deltaStorageChain.storages, deltasMetrics, binanceReconnectPeriod, deltasReassignCh, exInfoCache)
+	deltaFixer := svc.NewDataFixer(loggerParam, deltaStorageChain.mainStorage, deltaStorageChain.auxStorages, metrics.NewDataFixerMetrics("deltas", marketType), fixerCfg)
 
 	// book ticks
 	loggerParam = string("book_ticks_" + marketType)
-	ticksCsStorage := cs.NewCsBookTicksStorageWO(loggerParam, csSession, cm.NewCsStorageMetrics(marketCsRepoCfg.BookTicksTableName, marketType), marketCsRepoCfg.BookTicksTableName, marketCsRepoCfg.BookTicksKeyTableName)
-	ticksFileStorage := repo.NewFileRepo[bmodel.SymbolTick](loggerParam)
-	ticksStorages := []svc.BatchedDataStorage[bmodel.SymbolTick]{ticksCsStorage, ticksFileStorage}
-	ticksTransformator := model.NewNoChangeTransformator[bmodel.SymbolTick]()
-	ticksMetrics := metrics.NewWsPipelineMetrics[bmodel.SymbolTick](loggerParam)
+	ticksStorageChain := newStorageChain(marketCfg.BookTicksPipelineCfg.Storages, map[conf.StorageType]storageFactory[bmodel.SymbolTick]{
+		conf.CassandraStorage: func() svc.BatchedDataStorage[bmodel.SymbolTick] {
+			return cs.NewCsBookTicksStorageWO(loggerParam, csSession, cm.NewCsStorageMetrics(marketCsRepoCfg.BookTicksTableName, marketType), marketCsRepoCfg.BookTicksTableName, marketCsRepoCfg.BookTicksKeyTableName)
+		},
+		conf.MongoStorage: func() svc.BatchedDataStorage[bmodel.SymbolTick] {
+			return repo.NewLocalMongoRepo[bmodel.SymbolTick, cmodel.SymbolTickWithMongoId](mongoTimeoutS, marketMongoRepoCfg.BookTickerColName, mongoDb)
+		},
+		conf.ClickhouseStorage: func() svc.BatchedDataStorage[bmodel.SymbolTick] {
+			return repo.NewChBookTicksStorage(chRepo)
+		},
+		conf.FileStorage: func() svc.BatchedDataStorage[bmodel.SymbolTick] {
+			return repo.NewFileRepo[bmodel.SymbolTick](loggerParam, spoolCfg)
+		},
+	})
+	ticksMetrics := metrics.NewWsPipelineMetrics[bmodel.SymbolTick](loggerParam, "book_ticks", marketType, false, marketCfg.BookTicksPipelineCfg.MetricsCfg)
 	var ticksWorkersProvider svc.WsDataWorkersProvider[svc.WsDataProcessWorker[bmodel.SymbolTick, bmodel.SymbolTick]]
 	if !marketCfg.BinanceHttpCfg.UseAllTickersStream {
-		ticksWorkerProvider := svc.NewBookTicksWorkerProvider(marketCfg.BinanceHttpCfg, loggerParam, marketType, ticksTransformator, marketCfg.BookTicksPipelineCfg.BatchSize, ticksStorages, ticksMetrics)
-		ticksWorkersProvider = svc.NewTradingSymbolsWorkersProvider(loggerParam, marketCfg.BookTicksPipelineCfg.NumWorkers, ticksWorkerProvider, exInfoCache)
+		ticksWorkerProvider := svc.NewBookTicksWorkerProvider(marketCfg.BinanceHttpCfg, loggerParam, marketType, ticksTransformator, marketCfg.BookTicksPipelineCfg, ticksStorageChain.storages, ticksStorageChain.spoolStorage, ticksMetrics)
+		ticksWorkersProvider = svc.NewTradingSymbolsWorkersProvider(loggerParam, marketCfg.BookTicksPipelineCfg.NumWorkers, ticksWorkerProvider, exInfoCache, symbolsFilter)
 	} else {
-		ticksWorkersProvider = svc.NewBookTicksAllStreamsWorkerProvider(marketCfg.BinanceHttpCfg, loggerParam, ticksTransformator, marketCfg.BookTicksPipelineCfg.BatchSize, ticksStorages, ticksMetrics)
+		ticksWorkersProvider = svc.NewBookTicksAllStreamsWorkerProvider(marketCfg.BinanceHttpCfg, loggerParam, ticksTransformator, marketCfg.BookTicksPipelineCfg, ticksStorageChain.storages, ticksStorageChain.spoolStorage, ticksMetrics)
+	}
+	ticksSvc := svc.NewWsSvc(loggerParam, ticksWorkersProvider, ticksStorageChain.storages, ticksMetrics, binanceReconnectPeriod, ticksReassignCh, exInfoCache)
+	ticksFixer := svc.NewDataFixer(loggerParam, ticksStorageChain.mainStorage, ticksStorageChain.auxStorages, metrics.NewDataFixerMetrics("book_ticks", marketType), fixerCfg)
+
+	// trades are only published to the fan-out clients
+	var tradesSvc *svc.TradesSvc
+	if fanoutHub != nil {
+		loggerParam = string("trades_" + marketType)
+		tradesWorkerProvider := svc.NewTradesWorkerProvider(marketCfg.BinanceHttpCfg, loggerParam, fanout.NewTradesPublisher(fanoutHub, marketType))
+		tradesWorkersProvider := svc.NewTradingSymbolsWorkersProvider(loggerParam, fanoutCfg.TradesNumWorkers, tradesWorkerProvider, exInfoCache, symbolsFilter)
+		tradesSvc = svc.NewTradesSvc(loggerParam, tradesWorkersProvider, binanceReconnectPeriod, tradesReassignCh)
 	}
-	ticksSvc := svc.NewWsSvc(loggerParam, ticksWorkersProvider, ticksStorages, ticksMetrics, binanceReconnectPeriod, exInfoCache)
-	ticksFixer := svc.NewDataFixer(loggerParam, ticksCsStorage, []svc.AuxBatchedDataStorage[bmodel.SymbolTick]{ticksFileStorage})
 
 	// depth snapshots
 	loggerParam = string("snapshots_" + marketType)
-	snapshotCsStorage := cs.NewCsSnapshotStorageWO(loggerParam, csSession, cm.NewCsStorageMetrics(marketCsRepoCfg.SnapshotTableName, marketType), marketCsRepoCfg.SnapshotTableName, marketCsRepoCfg.SnapshotKeyTableName)
-	snapshotFileStorage := repo.NewFileRepo[cmodel.DepthSnapshotPart](loggerParam)
-	snapshotStorages := []svc.BatchedDataStorage[cmodel.DepthSnapshotPart]{snapshotCsStorage, snapshotFileStorage}
-	snapshotSvc := svc.NewSnapshotSvc(loggerParam, marketCfg.SnapshotsDepth, binanceClient, snapshotStorages, exInfoCache)
-	snapshotFixer := svc.NewDataFixer(loggerParam, snapshotCsStorage, []svc.AuxBatchedDataStorage[cmodel.DepthSnapshotPart]{snapshotFileStorage})
+	snapshotStorageChain := newStorageChain(marketCfg.SnapshotsStorages, map[conf.StorageType]storageFactory[cmodel.DepthSnapshotPart]{
+		conf.CassandraStorage: func() svc.BatchedDataStorage[cmodel.DepthSnapshotPart] {
+			return cs.NewCsSnapshotStorageWO(loggerParam, csSession, cm.NewCsStorageMetrics(marketCsRepoCfg.SnapshotTableName, marketType), marketCsRepoCfg.SnapshotTableName, marketCsRepoCfg.SnapshotKeyTableName)
+		},
+		conf.MongoStorage: func() svc.BatchedDataStorage[cmodel.DepthSnapshotPart] {
+			return repo.NewLocalMongoRepo[cmodel.DepthSnapshotPart, cmodel.DepthSnapshotPartWithMongoId](mongoTimeoutS, marketMongoRepoCfg.SnapshotColName, mongoDb)
+		},
+		conf.ClickhouseStorage: func() svc.BatchedDataStorage[cmodel.DepthSnapshotPart] {
+			return repo.NewChSnapshotStorage(chRepo)
+		},
+		conf.FileStorage: func() svc.BatchedDataStorage[cmodel.DepthSnapshotPart] {
+			return repo.NewFileRepo[cmodel.DepthSnapshotPart](loggerParam, spoolCfg)
+		},
+	})
+	snapshotSvc := svc.NewSnapshotSvc(loggerParam, marketCfg.SnapshotsDepth, binanceClient, snapshotStorageChain.storages, snapshotPublisher, bookResyncCh, symbolsFilter, exInfoCache)
+	snapshotFixer := svc.NewDataFixer(loggerParam, snapshotStorageChain.mainStorage, snapshotStorageChain.auxStorages, metrics.NewDataFixerMetrics("snapshots", marketType), fixerCfg)
 
 	// binance spot exchange info
 	loggerParam = string("exchange_info_" + marketType)
-	exchangeInfoCsStorage := cs.NewExchangeInfoStorage(loggerParam, csSession, marketCsRepoCfg.ExchangeInfoTableName)
-	exchangeInfoFileStorage := repo.NewFileRepo[cmodel.ExchangeInfo](loggerParam)
-	exInfoStorages := []svc.BatchedDataStorage[cmodel.ExchangeInfo]{exchangeInfoCsStorage, exchangeInfoFileStorage}
-	exInfoSvc := svc.NewExchangeInfoSvc(marketType, time.Duration(marketCfg.ExchangeInfoUpdPerM)*time.Minute, binanceClient, exInfoStorages, exInfoCache)
-	exInfoFixer := svc.NewDataFixer(loggerParam, exchangeInfoCsStorage, []svc.AuxBatchedDataStorage[cmodel.ExchangeInfo]{exchangeInfoFileStorage})
+	exInfoStorageChain := newStorageChain(marketCfg.ExchangeInfoStorages, map[conf.StorageType]storageFactory[cmodel.ExchangeInfo]{
+		conf.CassandraStorage: func() svc.BatchedDataStorage[cmodel.ExchangeInfo] {
+			return cs.NewExchangeInfoStorage(loggerParam, csSession, marketCsRepoCfg.ExchangeInfoTableName)
+		},
+		conf.MongoStorage: func() svc.BatchedDataStorage[cmodel.ExchangeInfo] {
+			return repo.NewLocalMongoRepo[cmodel.ExchangeInfo, cmodel.ExchangeInfoWithMongoId](mongoTimeoutS, marketMongoRepoCfg.ExInfoColName, mongoDb)
+		},
+		conf.ClickhouseStorage: func() svc.BatchedDataStorage[cmodel.ExchangeInfo] {
+			return repo.NewChExchangeInfoStorage(chRepo)
+		},
+		conf.FileStorage: func() svc.BatchedDataStorage[cmodel.ExchangeInfo] {
+			return repo.NewFileRepo[cmodel.ExchangeInfo](loggerParam, spoolCfg)
+		},
+	})
+	exInfoSvc := svc.NewExchangeInfoSvc(marketType, time.Duration(marketCfg.ExchangeInfoUpdPerM)*time.Minute, binanceClient, exInfoStorageChain.storages, exInfoCache)
+	exInfoFixer := svc.NewDataFixer(loggerParam, exInfoStorageChain.mainStorage, exInfoStorageChain.auxStorages, metrics.NewDataFixerMetrics("exchange_info", marketType), fixerCfg)
+
+	var spoolClosers []func(context.Context)
+	spoolClosers = append(spoolClosers, deltaStorageChain.closers...)
+	spoolClosers = append(spoolClosers, ticksStorageChain.closers...)
+	spoolClosers = append(spoolClosers, snapshotStorageChain.closers...)
+	spoolClosers = append(spoolClosers, exInfoStorageChain.closers...)
 
 	return &BinanceMarketCtx{
-		logger:              log.GetLogger(fmt.Sprintf("BinanceMarketCtx[%s]", marketType)),
-		marketType:          marketType,
-		deltaSvc:            deltaSvc,
-		ticksSvc:            ticksSvc,
-		snapshotSvc:         snapshotSvc,
-		exInfoSvc:           exInfoSvc,
-		exchangeInfoStorage: exchangeInfoCsStorage,
-		exInfoCache:         exInfoCache,
-		binanceClient:       binanceClient,
-		deltaFixer:          deltaFixer,
-		ticksFixer:          ticksFixer,
-		snapshotFixer:       snapshotFixer,
-		exInfoFixer:         exInfoFixer,
+		logger:        log.GetLogger(fmt.Sprintf("BinanceMarketCtx[%s]", marketType)),
+		marketType:    marketType,
+		deltaSvc:      deltaSvc,
+		ticksSvc:      ticksSvc,
+		tradesSvc:     tradesSvc,
+		snapshotSvc:   snapshotSvc,
+		exInfoSvc:     exInfoSvc,
+		exInfoCache:   exInfoCache,
+		binanceClient: binanceClient,
+		deltaFixer:    deltaFixer,
+		ticksFixer:    ticksFixer,
+		snapshotFixer: snapshotFixer,
+		exInfoFixer:   exInfoFixer,
+		bookBuilder:   bookBuilder,
+		serverClock:   serverClock,
+		spoolClosers:  spoolClosers,
 	}
 }
 
+// BookBuilder returns nil if neither fan-out nor gRPC server is enabled.
+func (s *BinanceMarketCtx) BookBuilder() *book.Builder {
+	return s.bookBuilder
+}
+
 func (s *BinanceMarketCtx) Start(ctx context.Context) {
 	exInfo, err := s.binanceClient.GetFullExchangeInfo(context.Background(), s.marketType)
 	if err != nil {
 		s.logger.Error(err.Error())
-	}
-	if err = s.exchangeInfoStorage.SendExchangeInfo(ctx, cmodel.NewExchangeInfo(exInfo)); err != nil {
+	} else if err = s.exInfoSvc.SaveExInfo(ctx, exInfo); err != nil {
 		s.logger.Error(err.Error())
 	}
+	if s.serverClock != nil {
+		clockCtx, stopServerClock := context.WithCancel(ctx)
+		s.stopServerClock = stopServerClock
+		go s.serverClock.Start(clockCtx)
+	}
 	go s.deltaSvc.Start(ctx)
 	go s.ticksSvc.Start(ctx)
+	if s.tradesSvc != nil {
+		go s.tradesSvc.Start(ctx)
+	}
 	go s.snapshotSvc.StartReceiveAndSaveSnapshots(ctx)
 	go s.exInfoSvc.StartReceiveExInfo(ctx)
-	go s.deltaFixer.Fix()
-	go s.ticksFixer.Fix()
-	go s.snapshotFixer.Fix()
-	go s.exInfoFixer.Fix()
+	fixersCtx, stopFixers := context.WithCancel(ctx)
+	s.stopFixers = stopFixers
+	for _, fixer := range s.fixers() {
+		go fixer.Fix(fixersCtx)
+	}
+}
+
+func (s *BinanceMarketCtx) fixers() []svc.Fixer {
+	return []svc.Fixer{s.deltaFixer, s.ticksFixer, s.snapshotFixer, s.exInfoFixer}
 }
 
 func (s *BinanceMarketCtx) Shutdown(ctx context.Context) {
 	s.logger.Info("Begin of graceful shutdown")
+	if s.stopFixers != nil {
+		s.stopFixers()
+		for _, fixer := range s.fixers() {
+			select {
+			case <-fixer.Done():
+			case <-ctx.Done():
+			}
+		}
+	}
+	if s.stopServerClock != nil {
+		s.stopServerClock()
+	}
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() {
@@ -149,7 +309,13 @@ func (s *BinanceMarketCtx) Shutdown(ctx context.Context) {
 		s.ticksSvc.Shutdown(ctx)
 		wg.Done()
 	}()
+	if s.tradesSvc != nil {
+		s.tradesSvc.Shutdown(ctx)
+	}
 	wg.Wait()
+	for _, closeSpool := range s.spoolClosers {
+		closeSpool(ctx)
+	}
 	time.Sleep(30 * time.Second)
 	s.logger.Info("End of graceful shutdown")
 }
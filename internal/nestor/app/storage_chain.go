@@ -0,0 +1,50 @@
+package app
+
+import (
+	"DeltaReceiver/internal/nestor/conf"
+	"DeltaReceiver/internal/nestor/svc"
+	"context"
+	"fmt"
+)
+
+type storageFactory[T any] func() svc.BatchedDataStorage[T]
+
+// storageChain is the ordered list of storages of one data pipeline. Data is saved
+// to the first storage that accepts it; fixer replays auxiliary storages into the
+// main one. The spool storage is picked among the auxiliary storages only, so it is
+// nil for a chain of the main storage alone, while closers cover all storages.
+type storageChain[T any] struct {
+	mainStorage  *svc.StorageCircuit[T]
+	storages     []svc.BatchedDataStorage[T]
+	auxStorages  []svc.AuxBatchedDataStorage[T]
+	spoolStorage svc.BatchedDataStorage[T]
+	closers      []func(context.Context)
+}
+
+func newStorageChain[T any](storageTypes []conf.StorageType, factories map[conf.StorageType]storageFactory[T]) *storageChain[T] {
+	chain := &storageChain[T]{}
+	for i, storageType := range storageTypes {
+		factory, ok := factories[storageType]
+		if !ok {
+			panic(fmt.Sprintf("storage %s is not available", storageType))
+		}
+		storage := factory()
+		// a file spool or mongo main storage has to be flushed on shutdown as well
+		if closer, ok := storage.(interface{ Close(context.Context) }); ok {
+			chain.closers = append(chain.closers, closer.Close)
+		}
+		if i == 0 {
+			chain.mainStorage = svc.NewStorageCircuit(storage)
+			chain.storages = append(chain.storages, chain.mainStorage)
+			continue
+		}
+		chain.storages = append(chain.storages, storage)
+		if auxStorage, ok := storage.(svc.AuxBatchedDataStorage[T]); ok {
+			chain.auxStorages = append(chain.auxStorages, auxStorage)
+		}
+		if storageType == conf.FileStorage || chain.spoolStorage == nil {
+			chain.spoolStorage = storage
+		}
+	}
+	return chain
+}
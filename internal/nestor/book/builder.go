@@ -0,0 +1,279 @@
+package book
+
+import (
+	cmodel "DeltaReceiver/internal/common/model"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/log"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+type Level struct {
+	Price    string
+	Quantity string
+}
+
+// View is a point-in-time copy of the top of a book. Epoch changes every time the
+// book is seeded by a snapshot, update ids are comparable only within one epoch.
+type View struct {
+	Symbol      string
+	Epoch       int64
+	TimestampMs int64
+	UpdateId    int64
+	Bids        []Level
+	Asks        []Level
+}
+
+// maxPendingEvents bounds the depth events buffered per symbol while its snapshot is
+// in flight, the oldest events are dropped first.
+const maxPendingEvents = 10000
+
+var ErrUnknownSymbol = errors.New("symbol is not received")
+
+type symbolBook struct {
+	mut          sync.Mutex
+	synced       bool
+	pending      [][]cmodel.Delta
+	epoch        int64
+	lastUpdateId int64
+	timestampMs  int64
+	bids         map[float64]Level
+	asks         map[float64]Level
+	watchers     map[chan struct{}]struct{}
+}
+
+// Builder keeps order books seeded by depth snapshots and updated by deltas. A book
+// is available after its first snapshot and becomes unavailable when a gap in spot
+// update ids is detected, a new snapshot of the symbol is requested then. Events
+// received while a book is out of sync are buffered and replayed on top of the next
+// snapshot, as the exchange sync procedure prescribes.
+type Builder struct {
+	logger    *zap.Logger
+	checkGaps bool
+	isTracked func(symbol string) bool
+	resyncCh  chan<- string
+	mut       sync.RWMutex
+	books     map[string]*symbolBook
+}
+
+// NewBuilder creates a builder which serves watchers of the symbols accepted by isTracked,
+// books of other symbols are created only by received snapshots and deltas.
+func NewBuilder(market bmodel.DataType, isTracked func(symbol string) bool, resyncCh chan<- string) *Builder {
+	return &Builder{
+		logger: log.GetLogger(fmt.Sprintf("BookBuilder[%s]", market)),
+		// futures depth events are chained by the previous update id which deltas do not keep
+		checkGaps: market == bmodel.Spot,
+		isTracked: isTracked,
+		resyncCh:  resyncCh,
+		books:     make(map[string]*symbolBook),
+	}
+}
+
+func (s *Builder) getBook(symbol string) *symbolBook {
+	s.mut.RLock()
+	book, ok := s.books[symbol]
+	s.mut.RUnlock()
+	if ok {
+		return book
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if book, ok = s.books[symbol]; !ok {
+		book = &symbolBook{watchers: make(map[chan struct{}]struct{})}
+		s.books[symbol] = book
+	}
+	return book
+}
+
+// ApplySnapshot replaces the book of the snapshot symbol and replays the events buffered
+// after the snapshot. A snapshot older than an in sync book is ignored.
+func (s *Builder) ApplySnapshot(snapshot []cmodel.DepthSnapshotPart) {
+	if len(snapshot) == 0 {
+		return
+	}
+	symbol := snapshot[0].Symbol
+	book := s.getBook(symbol)
+	book.mut.Lock()
+	defer book.mut.Unlock()
+	if book.synced && snapshot[0].LastUpdateId <= book.lastUpdateId {
+		return
+	}
+	book.bids = make(map[float64]Level)
+	book.asks = make(map[float64]Level)
+	for _, part := range snapshot {
+		if part.T {
+			setLevel(book.bids, part.Price, part.Count)
+		} else {
+			setLevel(book.asks, part.Price, part.Count)
+		}
+	}
+	book.epoch++
+	book.lastUpdateId = snapshot[0].LastUpdateId
+	book.timestampMs = snapshot[0].Timestamp
+	book.synced = true
+	pending := book.pending
+	book.pending = nil
+	for i, event := range pending {
+		if event[0].UpdateId <= book.lastUpdateId {
+			continue
+		}
+		if s.checkGaps && event[0].FirstUpdateId > book.lastUpdateId+1 {
+			s.logger.Warn(fmt.Sprintf("snapshot of %s at %d is older than buffered updates from %d", symbol, book.lastUpdateId, event[0].FirstUpdateId))
+			book.synced = false
+			book.pending = pending[i:]
+			s.requestResync(symbol)
+			return
+		}
+		book.apply(event)
+	}
+	book.notifyWatchers()
+}
+
+// ApplyDeltas applies rows of one depth update event.
+func (s *Builder) ApplyDeltas(event []cmodel.Delta) {
+	symbol := event[0].Symbol
+	book := s.getBook(symbol)
+	book.mut.Lock()
+	defer book.mut.Unlock()
+	if !book.synced {
+		book.buffer(event)
+		return
+	}
+	if event[0].UpdateId <= book.lastUpdateId {
+		return
+	}
+	if s.checkGaps && event[0].FirstUpdateId > book.lastUpdateId+1 {
+		s.logger.Warn(fmt.Sprintf("gap in updates of %s after %d, book is out of sync until next snapshot", symbol, book.lastUpdateId))
+		book.synced = false
+		book.buffer(event)
+		s.requestResync(symbol)
+		return
+	}
+	book.apply(event)
+	book.notifyWatchers()
+}
+
+func (s *Builder) requestResync(symbol string) {
+	if s.resyncCh == nil {
+		return
+	}
+	select {
+	case s.resyncCh <- symbol:
+	default:
+		s.logger.Warn(fmt.Sprintf("resync queue is full, %s waits for scheduled snapshot", symbol))
+	}
+}
+
+// GetBook returns the top depth levels of the book, depth <= 0 means the whole book.
+// False is returned if the book is not in sync.
+func (s *Builder) GetBook(symbol string, depth int) (*View, bool) {
+	s.mut.RLock()
+	book, ok := s.books[symbol]
+	s.mut.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	book.mut.Lock()
+	defer book.mut.Unlock()
+	if !book.synced {
+		return nil, false
+	}
+	return &View{
+		Symbol:      symbol,
+		Epoch:       book.epoch,
+		TimestampMs: book.timestampMs,
+		UpdateId:    book.lastUpdateId,
+		Bids:        topLevels(book.bids, depth, true),
+		Asks:        topLevels(book.asks, depth, false),
+	}, true
+}
+
+// Watch returns a channel notified after every change of the book. Notifications are
+// coalesced, so a slow watcher never blocks the updates. The returned func stops watching.
+// ErrUnknownSymbol is returned for symbols which are not tracked by the builder.
+func (s *Builder) Watch(symbol string) (<-chan struct{}, func(), error) {
+	s.mut.RLock()
+	_, ok := s.books[symbol]
+	s.mut.RUnlock()
+	if !ok && !s.isTracked(symbol) {
+		return nil, nil, ErrUnknownSymbol
+	}
+	book := s.getBook(symbol)
+	notifyCh := make(chan struct{}, 1)
+	book.mut.Lock()
+	book.watchers[notifyCh] = struct{}{}
+	book.mut.Unlock()
+	return notifyCh, func() {
+		book.mut.Lock()
+		delete(book.watchers, notifyCh)
+		book.mut.Unlock()
+	}, nil
+}
+
+func (s *symbolBook) buffer(event []cmodel.Delta) {
+	if len(s.pending) == maxPendingEvents {
+		s.pending[0] = nil
+		s.pending = s.pending[1:]
+	}
+	// rows of the event are kept after the batch is handed over to the storages
+	s.pending = append(s.pending, slices.Clone(event))
+}
+
+func (s *symbolBook) apply(event []cmodel.Delta) {
+	for _, delta := range event {
+		if delta.T {
+			setLevel(s.bids, delta.Price, delta.Count)
+		} else {
+			setLevel(s.asks, delta.Price, delta.Count)
+		}
+	}
+	s.lastUpdateId = event[0].UpdateId
+	s.timestampMs = event[0].Timestamp
+}
+
+func (s *symbolBook) notifyWatchers() {
+	for notifyCh := range s.watchers {
+		select {
+		case notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func setLevel(levels map[float64]Level, price, quantity string) {
+	priceKey, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return
+	}
+	if qty, err := strconv.ParseFloat(quantity, 64); err == nil && qty == 0 {
+		delete(levels, priceKey)
+		return
+	}
+	levels[priceKey] = Level{Price: price, Quantity: quantity}
+}
+
+func topLevels(levels map[float64]Level, depth int, descending bool) []Level {
+	prices := make([]float64, 0, len(levels))
+	for price := range levels {
+		prices = append(prices, price)
+	}
+	if descending {
+		sort.Sort(sort.Reverse(sort.Float64Slice(prices)))
+	} else {
+		sort.Float64s(prices)
+	}
+	if depth > 0 && len(prices) > depth {
+		prices = prices[:depth]
+	}
+	top := make([]Level, 0, len(prices))
+	for _, price := range prices {
+		top = append(top, levels[price])
+	}
+	return top
+}
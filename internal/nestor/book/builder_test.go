@@ -0,0 +1,112 @@
+package book
+
+import (
+	cmodel "DeltaReceiver/internal/common/model"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func bidEvent(firstUpdateId, updateId int64, price, qty string) []cmodel.Delta {
+	return []cmodel.Delta{{Symbol: "BTCUSDT", FirstUpdateId: firstUpdateId, UpdateId: updateId, Price: price, Count: qty, T: true}}
+}
+
+func bidSnapshot(lastUpdateId int64, price, qty string) []cmodel.DepthSnapshotPart {
+	return []cmodel.DepthSnapshotPart{{Symbol: "BTCUSDT", LastUpdateId: lastUpdateId, Price: price, Count: qty, T: true}}
+}
+
+func TestBuilderReplaysBufferedEvents(t *testing.T) {
+	tests := []struct {
+		name         string
+		before       [][]cmodel.Delta
+		snapshot     []cmodel.DepthSnapshotPart
+		wantSynced   bool
+		wantUpdateId int64
+		wantBids     []Level
+		wantResync   bool
+	}{
+		{
+			name:         "events older than snapshot dropped",
+			before:       [][]cmodel.Delta{bidEvent(1, 5, "10", "1"), bidEvent(6, 12, "11", "2")},
+			snapshot:     bidSnapshot(8, "10", "3"),
+			wantSynced:   true,
+			wantUpdateId: 12,
+			wantBids:     []Level{{Price: "11", Quantity: "2"}, {Price: "10", Quantity: "3"}},
+		},
+		{
+			name:         "snapshot without buffered events",
+			snapshot:     bidSnapshot(8, "10", "3"),
+			wantSynced:   true,
+			wantUpdateId: 8,
+			wantBids:     []Level{{Price: "10", Quantity: "3"}},
+		},
+		{
+			name:       "snapshot older than buffered events",
+			before:     [][]cmodel.Delta{bidEvent(20, 25, "11", "2")},
+			snapshot:   bidSnapshot(8, "10", "3"),
+			wantResync: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resyncCh := make(chan string, 1)
+			builder := NewBuilder(bmodel.Spot, func(string) bool { return true }, resyncCh)
+			for _, event := range tt.before {
+				builder.ApplyDeltas(event)
+			}
+			builder.ApplySnapshot(tt.snapshot)
+			view, ok := builder.GetBook("BTCUSDT", 0)
+			if ok != tt.wantSynced {
+				t.Fatalf("synced %v, want %v", ok, tt.wantSynced)
+			}
+			if ok && (view.UpdateId != tt.wantUpdateId || !slices.Equal(view.Bids, tt.wantBids)) {
+				t.Fatalf("got %d %v, want %d %v", view.UpdateId, view.Bids, tt.wantUpdateId, tt.wantBids)
+			}
+			if resync := len(resyncCh) > 0; resync != tt.wantResync {
+				t.Fatalf("resync requested %v, want %v", resync, tt.wantResync)
+			}
+		})
+	}
+}
+
+func TestBuilderResyncsOnGap(t *testing.T) {
+	resyncCh := make(chan string, 1)
+	builder := NewBuilder(bmodel.Spot, func(string) bool { return true }, resyncCh)
+	builder.ApplySnapshot(bidSnapshot(8, "10", "3"))
+	builder.ApplyDeltas(bidEvent(9, 10, "10", "4"))
+	builder.ApplyDeltas(bidEvent(15, 16, "11", "1"))
+	if _, ok := builder.GetBook("BTCUSDT", 0); ok {
+		t.Fatal("book is in sync after a gap")
+	}
+	if symbol := <-resyncCh; symbol != "BTCUSDT" {
+		t.Fatalf("resync of %s requested", symbol)
+	}
+	builder.ApplyDeltas(bidEvent(17, 18, "12", "1"))
+	builder.ApplySnapshot(bidSnapshot(15, "10", "5"))
+	view, ok := builder.GetBook("BTCUSDT", 0)
+	want := []Level{{Price: "12", Quantity: "1"}, {Price: "11", Quantity: "1"}, {Price: "10", Quantity: "5"}}
+	if !ok || view.UpdateId != 18 || view.Epoch != 2 || !slices.Equal(view.Bids, want) {
+		t.Fatalf("got %+v, %v", view, ok)
+	}
+	// a snapshot behind the book does not roll it back
+	builder.ApplySnapshot(bidSnapshot(17, "10", "6"))
+	if view, _ = builder.GetBook("BTCUSDT", 0); view.UpdateId != 18 || view.Epoch != 2 {
+		t.Fatalf("book rolled back to %+v", view)
+	}
+}
+
+func TestBuilderWatchRejectsUnknownSymbol(t *testing.T) {
+	builder := NewBuilder(bmodel.Spot, func(symbol string) bool { return symbol == "BTCUSDT" }, nil)
+	if _, _, err := builder.Watch("ETHUSDT"); !errors.Is(err, ErrUnknownSymbol) {
+		t.Fatalf("got %v, want %v", err, ErrUnknownSymbol)
+	}
+	if len(builder.books) != 0 {
+		t.Fatalf("book created for unknown symbol")
+	}
+	_, stopWatching, err := builder.Watch("BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopWatching()
+}
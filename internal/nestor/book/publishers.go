@@ -0,0 +1,43 @@
+package book
+
+import (
+	cmodel "DeltaReceiver/internal/common/model"
+)
+
+// DeltasPublisher feeds the builder from the deltas pipeline.
+type DeltasPublisher struct {
+	builder *Builder
+}
+
+func NewDeltasPublisher(builder *Builder) *DeltasPublisher {
+	return &DeltasPublisher{builder: builder}
+}
+
+func (s *DeltasPublisher) Publish(deltas []cmodel.Delta) {
+	ForEachEvent(deltas, s.builder.ApplyDeltas)
+}
+
+// SnapshotPublisher feeds the builder from the snapshot service.
+type SnapshotPublisher struct {
+	builder *Builder
+}
+
+func NewSnapshotPublisher(builder *Builder) *SnapshotPublisher {
+	return &SnapshotPublisher{builder: builder}
+}
+
+func (s *SnapshotPublisher) Publish(snapshot []cmodel.DepthSnapshotPart) {
+	s.builder.ApplySnapshot(snapshot)
+}
+
+// ForEachEvent splits rows into depth update events, rows of one event share symbol and update id.
+func ForEachEvent(deltas []cmodel.Delta, handle func([]cmodel.Delta)) {
+	for start := 0; start < len(deltas); {
+		end := start + 1
+		for end < len(deltas) && deltas[end].Symbol == deltas[start].Symbol && deltas[end].UpdateId == deltas[start].UpdateId {
+			end++
+		}
+		handle(deltas[start:end])
+		start = end
+	}
+}
@@ -13,6 +13,8 @@ type BinanceMarketCfg struct {
 	BookTicksPipelineCfg *WsPipelineCfg                   `yaml:"book.ticks"`
 	ExchangeInfoUpdPerM  int                              `yaml:"exchange.info.update.period.m"`
 	SnapshotsDepth       int                              `yaml:"snapshots.depth"`
+	SnapshotsStorages    []StorageType                    `yaml:"snapshots.storages"`
+	ExchangeInfoStorages []StorageType                    `yaml:"exchange.info.storages"`
 }
 
 func NewBinanceMarketCfgFromEnv(envPrefix string) *BinanceMarketCfg {
@@ -31,5 +33,14 @@ func NewBinanceMarketCfgFromEnv(envPrefix string) *BinanceMarketCfg {
 		ExchangeInfoUpdPerM:  exchangeInfoUpdatePeriodM,
 		DataType:             os.Getenv(envPrefix + ".data.type"),
 		SnapshotsDepth:       snapshotsDepth,
+		SnapshotsStorages:    NewStorageChainFromEnv(envPrefix + ".snapshots.storages"),
+		ExchangeInfoStorages: NewStorageChainFromEnv(envPrefix + ".exchange.info.storages"),
 	}
 }
+
+func (s *BinanceMarketCfg) UsesStorage(storageType StorageType) bool {
+	return usesStorage(s.DeltasPipelineCfg.Storages, storageType) ||
+		usesStorage(s.BookTicksPipelineCfg.Storages, storageType) ||
+		usesStorage(s.SnapshotsStorages, storageType) ||
+		usesStorage(s.ExchangeInfoStorages, storageType)
+}
@@ -0,0 +1,56 @@
+package conf
+
+import (
+	"DeltaReceiver/internal/common/conf"
+	"DeltaReceiver/pkg/clickhouse"
+	"os"
+)
+
+type ClickhouseRepoCfg struct {
+	PoolCfg        *clickhouse.ChPoolCfg   `yaml:"pool"`
+	DatabaseName   string                  `yaml:"database.name"`
+	InsertCfg      *conf.ChInsertCfg       `yaml:"insert"`
+	BinanceSpotCfg *BinanceMarketChRepoCfg `yaml:"binance.spot"`
+	BinanceUSDCfg  *BinanceMarketChRepoCfg `yaml:"binance.usd"`
+	BinanceCoinCfg *BinanceMarketChRepoCfg `yaml:"binance.coin"`
+}
+
+type BinanceMarketChRepoCfg struct {
+	DeltaTable        string `yaml:"delta.table"`
+	SnapshotTable     string `yaml:"snapshot.table"`
+	BookTicksTable    string `yaml:"book.ticks.table"`
+	ExchangeInfoTable string `yaml:"exchange.info.table"`
+}
+
+func NewClickhouseRepoCfgFromEnv(envPrefix string) *ClickhouseRepoCfg {
+	return &ClickhouseRepoCfg{
+		PoolCfg:        clickhouse.NewChPoolCfgFromEnv(envPrefix + ".pool"),
+		DatabaseName:   os.Getenv(envPrefix + ".database.name"),
+		InsertCfg:      conf.NewChInsertCfgFromEnv(envPrefix + ".insert"),
+		BinanceSpotCfg: NewBinanceMarketChRepoCfgFromEnv(envPrefix + ".binance.spot"),
+		BinanceUSDCfg:  NewBinanceMarketChRepoCfgFromEnv(envPrefix + ".binance.usd"),
+		BinanceCoinCfg: NewBinanceMarketChRepoCfgFromEnv(envPrefix + ".binance.coin"),
+	}
+}
+
+func NewBinanceMarketChRepoCfgFromEnv(envPrefix string) *BinanceMarketChRepoCfg {
+	return &BinanceMarketChRepoCfg{
+		DeltaTable:        os.Getenv(envPrefix + ".delta.table"),
+		SnapshotTable:     os.Getenv(envPrefix + ".snapshot.table"),
+		BookTicksTable:    os.Getenv(envPrefix + ".book.ticks.table"),
+		ExchangeInfoTable: os.Getenv(envPrefix + ".exchange.info.table"),
+	}
+}
+
+// MarketRepoCfg builds the repo config shared by the ClickHouse storages of one market.
+func (s *ClickhouseRepoCfg) MarketRepoCfg(marketCfg *BinanceMarketChRepoCfg) *conf.GlobalRepoConfig {
+	return &conf.GlobalRepoConfig{
+		ChPoolCfg:         s.PoolCfg,
+		DatabaseName:      s.DatabaseName,
+		DeltaTable:        marketCfg.DeltaTable,
+		SnapshotTable:     marketCfg.SnapshotTable,
+		ExchangeInfoTable: marketCfg.ExchangeInfoTable,
+		BookTickerTable:   marketCfg.BookTicksTable,
+		InsertCfg:         s.InsertCfg,
+	}
+}
@@ -10,7 +10,14 @@ type AppConfig struct {
 	Mode             BinanceMode        `yaml:"binance.mode"`
 	ReconnectPeriodM int16              `yaml:"binance.reconnect.period.m"`
 	MongoRepoCfg     *MongoRepoConfig   `yaml:"mongo"`
+	ChRepoCfg        *ClickhouseRepoCfg `yaml:"clickhouse"`
 	CsCfg            *conf.CsRepoConfig `yaml:"socrates"`
+	SpoolCfg         *SpoolCfg          `yaml:"spool"`
+	FixerCfg         *DataFixerCfg      `yaml:"fixer"`
+	FanoutCfg        *FanoutCfg         `yaml:"fanout"`
+	GrpcCfg          *GrpcCfg           `yaml:"grpc"`
+	ShardingCfg      *ShardingCfg       `yaml:"sharding"`
+	ServerClockCfg   *ServerClockCfg    `yaml:"server.clock"`
 	BinanceSpotCfg   *BinanceMarketCfg  `yaml:"binance.spot"`
 	BinanceUSDCfg    *BinanceMarketCfg  `yaml:"binance.usd"`
 	BinanceCoinCfg   *BinanceMarketCfg  `yaml:"binance.coin"`
@@ -39,10 +46,31 @@ func NewAppConfigFromEnv() *AppConfig {
 		usdCfg = NewBinanceMarketCfgFromEnv("binance.usd")
 		coinCfg = NewBinanceMarketCfgFromEnv("binance.coin")
 	}
+	var mongoRepoCfg *MongoRepoConfig
+	var chRepoCfg *ClickhouseRepoCfg
+	for _, marketCfg := range []*BinanceMarketCfg{spotCfg, usdCfg, coinCfg} {
+		if marketCfg == nil {
+			continue
+		}
+		if mongoRepoCfg == nil && marketCfg.UsesStorage(MongoStorage) {
+			mongoRepoCfg = NewMongoRepoConfigFromEnv("mongo")
+		}
+		if chRepoCfg == nil && marketCfg.UsesStorage(ClickhouseStorage) {
+			chRepoCfg = NewClickhouseRepoCfgFromEnv("clickhouse")
+		}
+	}
 	return &AppConfig{
 		Mode:             mode,
+		MongoRepoCfg:     mongoRepoCfg,
+		ChRepoCfg:        chRepoCfg,
 		ReconnectPeriodM: int16(reconnectPeriodM),
 		CsCfg:            conf.NewCsRepoConfigFromEnv("socrates"),
+		SpoolCfg:         NewSpoolCfgFromEnv("spool"),
+		FixerCfg:         NewDataFixerCfgFromEnv("fixer"),
+		FanoutCfg:        NewFanoutCfgFromEnv("fanout"),
+		GrpcCfg:          NewGrpcCfgFromEnv("grpc"),
+		ShardingCfg:      NewShardingCfgFromEnv("sharding", string(mode)),
+		ServerClockCfg:   NewServerClockCfgFromEnv("server.clock"),
 		BinanceSpotCfg:   spotCfg,
 		BinanceUSDCfg:    usdCfg,
 		BinanceCoinCfg:   coinCfg,
@@ -0,0 +1,19 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+)
+
+type DataFixerCfg struct {
+	PollPeriodS  int `yaml:"poll.period.s"`
+	MinBackoffMs int `yaml:"backoff.min.ms"`
+	MaxBackoffMs int `yaml:"backoff.max.ms"`
+}
+
+func NewDataFixerCfgFromEnv(envPrefix string) *DataFixerCfg {
+	return &DataFixerCfg{
+		PollPeriodS:  env.GetIntOrDefault(envPrefix+".poll.period.s", 300),
+		MinBackoffMs: env.GetIntOrDefault(envPrefix+".backoff.min.ms", 1000),
+		MaxBackoffMs: env.GetIntOrDefault(envPrefix+".backoff.max.ms", 300000),
+	}
+}
@@ -0,0 +1,38 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+)
+
+type SlowConsumerPolicy string
+
+const (
+	DropSlowConsumerMsgs   SlowConsumerPolicy = "drop"
+	DisconnectSlowConsumer SlowConsumerPolicy = "disconnect"
+)
+
+type FanoutCfg struct {
+	Enabled            bool               `yaml:"enabled"`
+	Port               int                `yaml:"port"`
+	ClientBufferSize   int                `yaml:"client.buffer.size"`
+	SlowConsumerPolicy SlowConsumerPolicy `yaml:"slow.consumer.policy"`
+	WriteTimeoutMs     int                `yaml:"write.timeout.ms"`
+	BookDepth          int                `yaml:"book.depth"`
+	TradesNumWorkers   int                `yaml:"trades.num.workers"`
+}
+
+func NewFanoutCfgFromEnv(envPrefix string) *FanoutCfg {
+	policy := SlowConsumerPolicy(env.GetStringOrDefault(envPrefix+".slow.consumer.policy", string(DropSlowConsumerMsgs)))
+	if policy != DropSlowConsumerMsgs && policy != DisconnectSlowConsumer {
+		panic("unknown slow consumer policy " + policy)
+	}
+	return &FanoutCfg{
+		Enabled:            env.GetBoolOrDefault(envPrefix+".enabled", false),
+		Port:               env.GetIntOrDefault(envPrefix+".port", 9002),
+		ClientBufferSize:   env.GetIntOrDefault(envPrefix+".client.buffer.size", 1024),
+		SlowConsumerPolicy: policy,
+		WriteTimeoutMs:     env.GetIntOrDefault(envPrefix+".write.timeout.ms", 5000),
+		BookDepth:          env.GetIntOrDefault(envPrefix+".book.depth", 20),
+		TradesNumWorkers:   env.GetIntOrDefault(envPrefix+".trades.num.workers", 4),
+	}
+}
@@ -0,0 +1,21 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+)
+
+type GrpcCfg struct {
+	Enabled          bool `yaml:"enabled"`
+	Port             int  `yaml:"port"`
+	BookDefaultDepth int  `yaml:"book.default.depth"`
+	BookMaxDepth     int  `yaml:"book.max.depth"`
+}
+
+func NewGrpcCfgFromEnv(envPrefix string) *GrpcCfg {
+	return &GrpcCfg{
+		Enabled:          env.GetBoolOrDefault(envPrefix+".enabled", false),
+		Port:             env.GetIntOrDefault(envPrefix+".port", 9003),
+		BookDefaultDepth: env.GetIntOrDefault(envPrefix+".book.default.depth", 20),
+		BookMaxDepth:     env.GetIntOrDefault(envPrefix+".book.max.depth", 1000),
+	}
+}
@@ -0,0 +1,17 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+)
+
+type PipelineMetricsCfg struct {
+	SymbolsAllowlist []string `yaml:"symbols.allowlist"`
+	TopSymbols       int      `yaml:"symbols.top"`
+}
+
+func NewPipelineMetricsCfgFromEnv(envPrefix string) *PipelineMetricsCfg {
+	return &PipelineMetricsCfg{
+		SymbolsAllowlist: env.GetListOrDefault(envPrefix+".symbols.allowlist", nil),
+		TopSymbols:       env.GetIntOrDefault(envPrefix+".symbols.top", 0),
+	}
+}
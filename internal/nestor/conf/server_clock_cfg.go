@@ -0,0 +1,23 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+)
+
+type ServerClockCfg struct {
+	Enabled            bool `yaml:"enabled"`
+	SyncPeriodS        int  `yaml:"sync.period.s"`
+	SamplesPerSync     int  `yaml:"samples.per.sync"`
+	SkewAlarmMs        int  `yaml:"skew.alarm.ms"`
+	CorrectReceiveTime bool `yaml:"correct.receive.time"`
+}
+
+func NewServerClockCfgFromEnv(envPrefix string) *ServerClockCfg {
+	return &ServerClockCfg{
+		Enabled:            env.GetBoolOrDefault(envPrefix+".enabled", true),
+		SyncPeriodS:        env.GetIntOrDefault(envPrefix+".sync.period.s", 60),
+		SamplesPerSync:     max(env.GetIntOrDefault(envPrefix+".samples.per.sync", 3), 1),
+		SkewAlarmMs:        env.GetIntOrDefault(envPrefix+".skew.alarm.ms", 500),
+		CorrectReceiveTime: env.GetBoolOrDefault(envPrefix+".correct.receive.time", false),
+	}
+}
@@ -0,0 +1,36 @@
+package conf
+
+import (
+	"DeltaReceiver/internal/common/conf"
+	"DeltaReceiver/pkg/env"
+	"os"
+)
+
+type ShardingCfg struct {
+	Enabled           bool           `yaml:"enabled"`
+	ReplicaId         string         `yaml:"replica.id"`
+	Group             string         `yaml:"group"`
+	ReplicationFactor int            `yaml:"replication.factor"`
+	VirtualNodes      int            `yaml:"virtual.nodes"`
+	ZkCfg             *conf.ZkConfig `yaml:"zk"`
+}
+
+// NewShardingCfgFromEnv uses the host name as replica id by default, it is stable for
+// the pods of a stateful set. Replicas of one group divide the symbols among themselves.
+func NewShardingCfgFromEnv(envPrefix string, defaultGroup string) *ShardingCfg {
+	if !env.GetBoolOrDefault(envPrefix+".enabled", false) {
+		return &ShardingCfg{Enabled: false}
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		panic(err)
+	}
+	return &ShardingCfg{
+		Enabled:           true,
+		ReplicaId:         env.GetStringOrDefault(envPrefix+".replica.id", hostname),
+		Group:             env.GetStringOrDefault(envPrefix+".group", defaultGroup),
+		ReplicationFactor: env.GetIntOrDefault(envPrefix+".replication.factor", 2),
+		VirtualNodes:      env.GetIntOrDefault(envPrefix+".virtual.nodes", 64),
+		ZkCfg:             conf.ZkConfigFromEnv(envPrefix + ".zk"),
+	}
+}
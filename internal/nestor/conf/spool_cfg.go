@@ -0,0 +1,35 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+)
+
+type FsyncPolicy string
+
+const (
+	FsyncAlways   FsyncPolicy = "always"
+	FsyncInterval FsyncPolicy = "interval"
+	FsyncNever    FsyncPolicy = "never"
+)
+
+type SpoolCfg struct {
+	Dir             string      `yaml:"dir"`
+	SegmentSizeMb   int64       `yaml:"segment.size.mb"`
+	MaxSizeMb       int64       `yaml:"max.size.mb"`
+	FsyncPolicy     FsyncPolicy `yaml:"fsync.policy"`
+	FsyncIntervalMs int         `yaml:"fsync.interval.ms"`
+}
+
+func NewSpoolCfgFromEnv(envPrefix string) *SpoolCfg {
+	fsyncPolicy := FsyncPolicy(env.GetStringOrDefault(envPrefix+".fsync.policy", string(FsyncInterval)))
+	if fsyncPolicy != FsyncAlways && fsyncPolicy != FsyncInterval && fsyncPolicy != FsyncNever {
+		panic("unknown fsync policy " + fsyncPolicy)
+	}
+	return &SpoolCfg{
+		Dir:             env.GetStringOrDefault(envPrefix+".dir", "/var/data"),
+		SegmentSizeMb:   env.GetInt64OrDefault(envPrefix+".segment.size.mb", 64),
+		MaxSizeMb:       env.GetInt64OrDefault(envPrefix+".max.size.mb", 10240),
+		FsyncPolicy:     fsyncPolicy,
+		FsyncIntervalMs: env.GetIntOrDefault(envPrefix+".fsync.interval.ms", 1000),
+	}
+}
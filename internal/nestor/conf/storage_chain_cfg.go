@@ -0,0 +1,44 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+	"fmt"
+)
+
+type StorageType string
+
+const (
+	CassandraStorage  StorageType = "cassandra"
+	MongoStorage      StorageType = "mongo"
+	FileStorage       StorageType = "file"
+	ClickhouseStorage StorageType = "clickhouse"
+)
+
+var defaultStorageChain = []string{string(CassandraStorage), string(FileStorage)}
+
+// NewStorageChainFromEnv reads comma separated list of storages. The first storage
+// is the main one, the rest are fallbacks tried in order.
+func NewStorageChainFromEnv(envKey string) []StorageType {
+	var chain []StorageType
+	for _, rawStorageType := range env.GetListOrDefault(envKey, defaultStorageChain) {
+		storageType := StorageType(rawStorageType)
+		if storageType != CassandraStorage && storageType != MongoStorage && storageType != FileStorage &&
+			storageType != ClickhouseStorage {
+			panic(fmt.Sprintf("unknown storage type %s in %s", storageType, envKey))
+		}
+		chain = append(chain, storageType)
+	}
+	if chain[0] == FileStorage {
+		panic(fmt.Sprintf("file storage can not be the main storage in %s", envKey))
+	}
+	return chain
+}
+
+func usesStorage(chain []StorageType, storageType StorageType) bool {
+	for _, chainStorageType := range chain {
+		if chainStorageType == storageType {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,20 @@
 package conf
 
 import (
+	"DeltaReceiver/pkg/env"
 	"os"
 	"strconv"
 )
 
 type WsPipelineCfg struct {
-	NumWorkers int `yaml:"num.workers"`
-	BatchSize  int `yaml:"batch.size"`
+	NumWorkers      int                 `yaml:"num.workers"`
+	BatchSize       int                 `yaml:"batch.size"`
+	MaxLingerMs     int                 `yaml:"max.linger.ms"`
+	QueueSize       int                 `yaml:"queue.size"`
+	QueueMaxBlockMs int                 `yaml:"queue.max.block.ms"`
+	NumWriters      int                 `yaml:"num.writers"`
+	Storages        []StorageType       `yaml:"storages"`
+	MetricsCfg      *PipelineMetricsCfg `yaml:"metrics"`
 }
 
 func NewWsPipelineCfgFromEnv(envPrefix string) *WsPipelineCfg {
@@ -20,7 +27,13 @@ func NewWsPipelineCfgFromEnv(envPrefix string) *WsPipelineCfg {
 		panic(err)
 	}
 	return &WsPipelineCfg{
-		NumWorkers: numWorkers,
-		BatchSize:  batchSize,
+		NumWorkers:      numWorkers,
+		BatchSize:       batchSize,
+		MaxLingerMs:     env.GetIntOrDefault(envPrefix+".max.linger.ms", 60000),
+		QueueSize:       env.GetIntOrDefault(envPrefix+".queue.size", 16),
+		QueueMaxBlockMs: env.GetIntOrDefault(envPrefix+".queue.max.block.ms", 100),
+		NumWriters:      env.GetIntOrDefault(envPrefix+".num.writers", 1),
+		Storages:        NewStorageChainFromEnv(envPrefix + ".storages"),
+		MetricsCfg:      NewPipelineMetricsCfgFromEnv(envPrefix + ".metrics"),
 	}
 }
@@ -0,0 +1,67 @@
+package fanout
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+	maxRequestSize = 4096
+)
+
+type client struct {
+	conn      *websocket.Conn
+	sendCh    chan []byte
+	closedCh  chan struct{}
+	closeOnce sync.Once
+}
+
+func newClient(conn *websocket.Conn, bufferSize int) *client {
+	return &client{
+		conn:     conn,
+		sendCh:   make(chan []byte, bufferSize),
+		closedCh: make(chan struct{}),
+	}
+}
+
+// trySend never blocks, false means the client buffer is full.
+func (s *client) trySend(payload []byte) bool {
+	select {
+	case s.sendCh <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *client) close() {
+	s.closeOnce.Do(func() {
+		close(s.closedCh)
+		s.conn.Close()
+	})
+}
+
+func (s *client) writeLoop(writeTimeout time.Duration) {
+	pingTicker := time.NewTicker(pingPeriod)
+	defer pingTicker.Stop()
+	defer s.close()
+	for {
+		select {
+		case <-s.closedCh:
+			return
+		case payload := <-s.sendCh:
+			s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := s.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-pingTicker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
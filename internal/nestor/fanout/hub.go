@@ -0,0 +1,165 @@
+package fanout
+
+import (
+	"DeltaReceiver/internal/nestor/conf"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/log"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const allSymbols = ""
+
+type subKey struct {
+	market   bmodel.DataType
+	dataType DataType
+	symbol   string
+}
+
+// Hub routes messages of the pipelines to the subscribed clients. Publishing never
+// blocks: a client with a full buffer loses the message or is disconnected,
+// depending on the slow consumer policy.
+type Hub struct {
+	logger  *zap.Logger
+	markets map[bmodel.DataType]struct{}
+	policy  conf.SlowConsumerPolicy
+	metrics Metrics
+	mut     sync.RWMutex
+	clients map[*client]map[subKey]struct{}
+	subs    map[subKey]map[*client]struct{}
+}
+
+func NewHub(markets []bmodel.DataType, policy conf.SlowConsumerPolicy, metrics Metrics) *Hub {
+	marketsSet := make(map[bmodel.DataType]struct{}, len(markets))
+	for _, market := range markets {
+		marketsSet[market] = struct{}{}
+	}
+	return &Hub{
+		logger:  log.GetLogger("FanoutHub"),
+		markets: marketsSet,
+		policy:  policy,
+		metrics: metrics,
+		clients: make(map[*client]map[subKey]struct{}),
+		subs:    make(map[subKey]map[*client]struct{}),
+	}
+}
+
+// Publish sends the message to every client subscribed to the symbol or to the whole
+// market. The message is built and marshalled only if there is such a client.
+func (s *Hub) Publish(market bmodel.DataType, dataType DataType, symbol string, buildMsg func() any) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	symbolSubs := s.subs[subKey{market: market, dataType: dataType, symbol: symbol}]
+	marketSubs := s.subs[subKey{market: market, dataType: dataType, symbol: allSymbols}]
+	if len(symbolSubs) == 0 && len(marketSubs) == 0 {
+		return
+	}
+	payload, err := json.Marshal(buildMsg())
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	for c := range symbolSubs {
+		s.send(c, payload, market, dataType)
+	}
+	for c := range marketSubs {
+		if _, ok := symbolSubs[c]; !ok {
+			s.send(c, payload, market, dataType)
+		}
+	}
+}
+
+func (s *Hub) HasSubscribers(market bmodel.DataType, dataType DataType, symbol string) bool {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return len(s.subs[subKey{market: market, dataType: dataType, symbol: symbol}]) > 0 ||
+		len(s.subs[subKey{market: market, dataType: dataType, symbol: allSymbols}]) > 0
+}
+
+func (s *Hub) send(c *client, payload []byte, market bmodel.DataType, dataType DataType) {
+	if c.trySend(payload) {
+		s.metrics.IncSentMsgs(string(market), string(dataType))
+		return
+	}
+	s.metrics.IncDroppedMsgs(string(market), string(dataType))
+	if s.policy == conf.DisconnectSlowConsumer {
+		s.metrics.IncSlowConsumerDisconnects()
+		c.close()
+	}
+}
+
+func (s *Hub) register(c *client) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.clients[c] = make(map[subKey]struct{})
+	s.metrics.IncClients()
+}
+
+func (s *Hub) unregister(c *client) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	keys, ok := s.clients[c]
+	if !ok {
+		return
+	}
+	for key := range keys {
+		s.removeSub(c, key)
+	}
+	delete(s.clients, c)
+	s.metrics.DecClients()
+}
+
+func (s *Hub) handleRequest(c *client, req *Request) *Response {
+	if _, ok := s.markets[req.Market]; !ok {
+		return &Response{Method: req.Method, Error: fmt.Sprintf("unknown market %s", req.Market)}
+	}
+	if req.Type != Deltas && req.Type != BookTicks && req.Type != Book && req.Type != Trades {
+		return &Response{Method: req.Method, Error: fmt.Sprintf("unknown type %s", req.Type)}
+	}
+	symbols := req.Symbols
+	if len(symbols) == 0 {
+		symbols = []string{allSymbols}
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	keys, ok := s.clients[c]
+	if !ok {
+		return &Response{Method: req.Method, Error: "client is closed"}
+	}
+	for _, symbol := range symbols {
+		key := subKey{market: req.Market, dataType: req.Type, symbol: strings.ToUpper(symbol)}
+		switch req.Method {
+		case Subscribe:
+			if s.subs[key] == nil {
+				s.subs[key] = make(map[*client]struct{})
+			}
+			s.subs[key][c] = struct{}{}
+			keys[key] = struct{}{}
+		case Unsubscribe:
+			s.removeSub(c, key)
+		default:
+			return &Response{Method: req.Method, Error: fmt.Sprintf("unknown method %s", req.Method)}
+		}
+	}
+	return &Response{Method: req.Method, Result: "ok"}
+}
+
+func (s *Hub) removeSub(c *client, key subKey) {
+	delete(s.clients[c], key)
+	delete(s.subs[key], c)
+	if len(s.subs[key]) == 0 {
+		delete(s.subs, key)
+	}
+}
+
+func (s *Hub) closeAll() {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	for c := range s.clients {
+		c.close()
+	}
+}
@@ -0,0 +1,9 @@
+package fanout
+
+type Metrics interface {
+	IncClients()
+	DecClients()
+	IncSentMsgs(market, dataType string)
+	IncDroppedMsgs(market, dataType string)
+	IncSlowConsumerDisconnects()
+}
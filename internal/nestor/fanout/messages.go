@@ -0,0 +1,157 @@
+package fanout
+
+import (
+	cmodel "DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/nestor/book"
+	bmodel "DeltaReceiver/pkg/binance/model"
+)
+
+type DataType string
+
+// Trades are aggregate trades, i.e. fills of one taker order at one price.
+const (
+	Deltas    DataType = "deltas"
+	BookTicks DataType = "book_ticks"
+	Book      DataType = "book"
+	Trades    DataType = "trades"
+)
+
+type Method string
+
+const (
+	Subscribe   Method = "subscribe"
+	Unsubscribe Method = "unsubscribe"
+)
+
+// Request subscribes or unsubscribes the client. Empty symbols list means all symbols of the market.
+type Request struct {
+	Method  Method          `json:"method"`
+	Market  bmodel.DataType `json:"market"`
+	Type    DataType        `json:"type"`
+	Symbols []string        `json:"symbols"`
+}
+
+type Response struct {
+	Method Method `json:"method"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type DeltaMsg struct {
+	Market        bmodel.DataType `json:"market"`
+	Type          DataType        `json:"type"`
+	Symbol        string          `json:"symbol"`
+	TimestampMs   int64           `json:"timestampMs"`
+	FirstUpdateId int64           `json:"firstUpdateId"`
+	UpdateId      int64           `json:"updateId"`
+	Bids          [][2]string     `json:"bids"`
+	Asks          [][2]string     `json:"asks"`
+}
+
+// newDeltaMsg builds a message from rows of one depth update event.
+func newDeltaMsg(market bmodel.DataType, event []cmodel.Delta) *DeltaMsg {
+	msg := &DeltaMsg{
+		Market:        market,
+		Type:          Deltas,
+		Symbol:        event[0].Symbol,
+		TimestampMs:   event[0].Timestamp,
+		FirstUpdateId: event[0].FirstUpdateId,
+		UpdateId:      event[0].UpdateId,
+		Bids:          [][2]string{},
+		Asks:          [][2]string{},
+	}
+	for _, delta := range event {
+		if delta.T {
+			msg.Bids = append(msg.Bids, [2]string{delta.Price, delta.Count})
+		} else {
+			msg.Asks = append(msg.Asks, [2]string{delta.Price, delta.Count})
+		}
+	}
+	return msg
+}
+
+type BookTickMsg struct {
+	Market      bmodel.DataType `json:"market"`
+	Type        DataType        `json:"type"`
+	Symbol      string          `json:"symbol"`
+	TimestampMs int64           `json:"timestampMs"`
+	UpdateId    int64           `json:"updateId"`
+	BidPrice    string          `json:"bidPrice"`
+	BidQuantity string          `json:"bidQuantity"`
+	AskPrice    string          `json:"askPrice"`
+	AskQuantity string          `json:"askQuantity"`
+}
+
+func newBookTickMsg(market bmodel.DataType, tick *bmodel.SymbolTick) *BookTickMsg {
+	return &BookTickMsg{
+		Market:      market,
+		Type:        BookTicks,
+		Symbol:      tick.Symbol,
+		TimestampMs: tick.Timestamp,
+		UpdateId:    tick.UpdateId,
+		BidPrice:    tick.BidPrice,
+		BidQuantity: tick.BidQuantity,
+		AskPrice:    tick.AskPrice,
+		AskQuantity: tick.AskQuantity,
+	}
+}
+
+type TradeMsg struct {
+	Market       bmodel.DataType `json:"market"`
+	Type         DataType        `json:"type"`
+	Symbol       string          `json:"symbol"`
+	TimestampMs  int64           `json:"timestampMs"`
+	TradeId      int64           `json:"tradeId"`
+	FirstTradeId int64           `json:"firstTradeId"`
+	LastTradeId  int64           `json:"lastTradeId"`
+	Price        string          `json:"price"`
+	Quantity     string          `json:"quantity"`
+	IsBuyerMaker bool            `json:"isBuyerMaker"`
+}
+
+func newTradeMsg(market bmodel.DataType, trade *bmodel.AggTrade) *TradeMsg {
+	return &TradeMsg{
+		Market:       market,
+		Type:         Trades,
+		Symbol:       trade.Symbol,
+		TimestampMs:  trade.TradeTime,
+		TradeId:      trade.AggTradeId,
+		FirstTradeId: trade.FirstTradeId,
+		LastTradeId:  trade.LastTradeId,
+		Price:        trade.Price,
+		Quantity:     trade.Quantity,
+		IsBuyerMaker: trade.IsBuyerMaker,
+	}
+}
+
+// BookMsg is the top of the order book rebuilt from the last snapshot and the deltas after it.
+// Bids are sorted by price descending, asks ascending.
+type BookMsg struct {
+	Market      bmodel.DataType `json:"market"`
+	Type        DataType        `json:"type"`
+	Symbol      string          `json:"symbol"`
+	TimestampMs int64           `json:"timestampMs"`
+	UpdateId    int64           `json:"updateId"`
+	Bids        [][2]string     `json:"bids"`
+	Asks        [][2]string     `json:"asks"`
+}
+
+func newBookMsg(market bmodel.DataType, view *book.View) *BookMsg {
+	return &BookMsg{
+		Market:      market,
+		Type:        Book,
+		Symbol:      view.Symbol,
+		TimestampMs: view.TimestampMs,
+		UpdateId:    view.UpdateId,
+		Bids:        levelsToPairs(view.Bids),
+		Asks:        levelsToPairs(view.Asks),
+	}
+}
+
+func levelsToPairs(levels []book.Level) [][2]string {
+	pairs := make([][2]string, 0, len(levels))
+	for _, level := range levels {
+		pairs = append(pairs, [2]string{level.Price, level.Quantity})
+	}
+	return pairs
+}
@@ -0,0 +1,85 @@
+package fanout
+
+import (
+	cmodel "DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/nestor/book"
+	bmodel "DeltaReceiver/pkg/binance/model"
+)
+
+type DeltaPublisher struct {
+	hub         *Hub
+	market      bmodel.DataType
+	bookBuilder *book.Builder
+	bookDepth   int
+}
+
+// NewDeltaPublisher expects the book builder to be fed before the publisher, so the
+// book sent after an event already contains it.
+func NewDeltaPublisher(hub *Hub, market bmodel.DataType, bookBuilder *book.Builder, bookDepth int) *DeltaPublisher {
+	return &DeltaPublisher{
+		hub:         hub,
+		market:      market,
+		bookBuilder: bookBuilder,
+		bookDepth:   bookDepth,
+	}
+}
+
+// Publish sends every depth update event as one message followed by the top of the book.
+func (s *DeltaPublisher) Publish(deltas []cmodel.Delta) {
+	book.ForEachEvent(deltas, func(event []cmodel.Delta) {
+		symbol := event[0].Symbol
+		s.hub.Publish(s.market, Deltas, symbol, func() any {
+			return newDeltaMsg(s.market, event)
+		})
+		if !s.hub.HasSubscribers(s.market, Book, symbol) {
+			return
+		}
+		if view, ok := s.bookBuilder.GetBook(symbol, s.bookDepth); ok {
+			s.hub.Publish(s.market, Book, symbol, func() any {
+				return newBookMsg(s.market, view)
+			})
+		}
+	})
+}
+
+type BookTicksPublisher struct {
+	hub    *Hub
+	market bmodel.DataType
+}
+
+func NewBookTicksPublisher(hub *Hub, market bmodel.DataType) *BookTicksPublisher {
+	return &BookTicksPublisher{
+		hub:    hub,
+		market: market,
+	}
+}
+
+func (s *BookTicksPublisher) Publish(ticks []bmodel.SymbolTick) {
+	for i := range ticks {
+		tick := &ticks[i]
+		s.hub.Publish(s.market, BookTicks, tick.Symbol, func() any {
+			return newBookTickMsg(s.market, tick)
+		})
+	}
+}
+
+type TradesPublisher struct {
+	hub    *Hub
+	market bmodel.DataType
+}
+
+func NewTradesPublisher(hub *Hub, market bmodel.DataType) *TradesPublisher {
+	return &TradesPublisher{
+		hub:    hub,
+		market: market,
+	}
+}
+
+func (s *TradesPublisher) Publish(trades []bmodel.AggTrade) {
+	for i := range trades {
+		trade := &trades[i]
+		s.hub.Publish(s.market, Trades, trade.Symbol, func() any {
+			return newTradeMsg(s.market, trade)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package fanout
+
+import (
+	"DeltaReceiver/internal/nestor/conf"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Server accepts downstream WebSocket clients on /ws. Clients send Request messages
+// and receive Response messages followed by the data they subscribed to.
+type Server struct {
+	logger       *zap.Logger
+	hub          *Hub
+	upgrader     websocket.Upgrader
+	httpServer   *http.Server
+	bufferSize   int
+	writeTimeout time.Duration
+}
+
+func NewServer(hub *Hub, cfg *conf.FanoutCfg) *Server {
+	server := &Server{
+		logger: log.GetLogger("FanoutServer"),
+		hub:    hub,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		bufferSize:   max(cfg.ClientBufferSize, 1),
+		writeTimeout: time.Duration(cfg.WriteTimeoutMs) * time.Millisecond,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", server.serveWs)
+	server.httpServer = &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Port),
+		Handler: mux,
+	}
+	return server
+}
+
+func (s *Server) Start() {
+	go func() {
+		s.logger.Info(fmt.Sprintf("fanout server listens on %s", s.httpServer.Addr))
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error(err.Error())
+		}
+	}()
+}
+
+func (s *Server) Shutdown(ctx context.Context) {
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.logger.Error(err.Error())
+	}
+	// hijacked websocket connections are not closed by http server shutdown
+	s.hub.closeAll()
+}
+
+func (s *Server) serveWs(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.logger.Warn(err.Error())
+		return
+	}
+	c := newClient(conn, s.bufferSize)
+	s.hub.register(c)
+	go c.writeLoop(s.writeTimeout)
+	s.readLoop(c)
+}
+
+func (s *Server) readLoop(c *client) {
+	defer func() {
+		s.hub.unregister(c)
+		c.close()
+	}()
+	c.conn.SetReadLimit(maxRequestSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	// only transport errors close the connection, undecodable requests are answered
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req Request
+		if err = json.Unmarshal(data, &req); err != nil {
+			s.reply(c, &Response{Error: fmt.Sprintf("malformed request: %s", err.Error())})
+			continue
+		}
+		s.reply(c, s.hub.handleRequest(c, &req))
+	}
+}
+
+func (s *Server) reply(c *client, resp *Response) {
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	if !c.trySend(payload) {
+		s.logger.Warn("client buffer is full, response dropped")
+	}
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	fixerLabels = []string{"market", "data_type"}
+
+	spoolBacklogBytesVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nestorNamespace,
+		Subsystem: "fixer",
+		Name:      "spool_backlog_bytes",
+	}, append(fixerLabels, "storage"))
+	replayedRowsVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nestorNamespace,
+		Subsystem: "fixer",
+		Name:      "replayed_rows",
+	}, fixerLabels)
+	replayErrorsVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nestorNamespace,
+		Subsystem: "fixer",
+		Name:      "replay_errors",
+	}, fixerLabels)
+)
+
+type DataFixerMetrics struct {
+	spoolBacklogBytes *prometheus.GaugeVec
+	replayedRows      prometheus.Counter
+	replayErrors      prometheus.Counter
+}
+
+func NewDataFixerMetrics(pipelineName string, marketType bmodel.DataType) *DataFixerMetrics {
+	labels := prometheus.Labels{"market": string(marketType), "data_type": pipelineName}
+	return &DataFixerMetrics{
+		spoolBacklogBytes: spoolBacklogBytesVec.MustCurryWith(labels),
+		replayedRows:      replayedRowsVec.With(labels),
+		replayErrors:      replayErrorsVec.With(labels),
+	}
+}
+
+func (s *DataFixerMetrics) SetBacklogBytes(storageNo int, backlogBytes int64) {
+	s.spoolBacklogBytes.WithLabelValues(strconv.Itoa(storageNo)).Set(float64(backlogBytes))
+}
+
+func (s *DataFixerMetrics) AddReplayedRows(numRows int) {
+	s.replayedRows.Add(float64(numRows))
+}
+
+func (s *DataFixerMetrics) IncReplayErr() {
+	s.replayErrors.Inc()
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type FanoutMetrics struct {
+	clients                 prometheus.Gauge
+	sentMsgs                *prometheus.CounterVec
+	droppedMsgs             *prometheus.CounterVec
+	slowConsumerDisconnects prometheus.Counter
+}
+
+func NewFanoutMetrics() *FanoutMetrics {
+	return &FanoutMetrics{
+		clients: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: nestorNamespace,
+			Subsystem: "fanout",
+			Name:      "clients",
+		}),
+		sentMsgs: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: nestorNamespace,
+			Subsystem: "fanout",
+			Name:      "sent_msgs",
+		}, []string{"market", "data_type"}),
+		droppedMsgs: promauto.NewCounterVec(prometheus.CounterOpts{
+			Namespace: nestorNamespace,
+			Subsystem: "fanout",
+			Name:      "dropped_msgs",
+		}, []string{"market", "data_type"}),
+		slowConsumerDisconnects: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: nestorNamespace,
+			Subsystem: "fanout",
+			Name:      "slow_consumer_disconnects",
+		}),
+	}
+}
+
+func (s *FanoutMetrics) IncClients() {
+	s.clients.Inc()
+}
+
+func (s *FanoutMetrics) DecClients() {
+	s.clients.Dec()
+}
+
+func (s *FanoutMetrics) IncSentMsgs(market, dataType string) {
+	s.sentMsgs.WithLabelValues(market, dataType).Inc()
+}
+
+func (s *FanoutMetrics) IncDroppedMsgs(market, dataType string) {
+	s.droppedMsgs.WithLabelValues(market, dataType).Inc()
+}
+
+func (s *FanoutMetrics) IncSlowConsumerDisconnects() {
+	s.slowConsumerDisconnects.Inc()
+}
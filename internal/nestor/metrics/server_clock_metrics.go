@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	bmodel "DeltaReceiver/pkg/binance/model"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	clockLabels = []string{"market"}
+
+	clockOffsetVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nestorNamespace,
+		Subsystem: "server_clock",
+		Name:      "offset_ms",
+	}, clockLabels)
+	clockRttVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nestorNamespace,
+		Subsystem: "server_clock",
+		Name:      "rtt_ms",
+	}, clockLabels)
+	clockSkewAlarmVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nestorNamespace,
+		Subsystem: "server_clock",
+		Name:      "skew_alarm",
+	}, clockLabels)
+	clockSyncErrorsVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nestorNamespace,
+		Subsystem: "server_clock",
+		Name:      "sync_errors",
+	}, clockLabels)
+)
+
+type ServerClockMetrics struct {
+	offset     prometheus.Gauge
+	rtt        prometheus.Gauge
+	skewAlarm  prometheus.Gauge
+	syncErrors prometheus.Counter
+}
+
+func NewServerClockMetrics(marketType bmodel.DataType) *ServerClockMetrics {
+	labels := prometheus.Labels{"market": string(marketType)}
+	return &ServerClockMetrics{
+		offset:     clockOffsetVec.With(labels),
+		rtt:        clockRttVec.With(labels),
+		skewAlarm:  clockSkewAlarmVec.With(labels),
+		syncErrors: clockSyncErrorsVec.With(labels),
+	}
+}
+
+func (s *ServerClockMetrics) SetOffsetMs(offsetMs int64) {
+	s.offset.Set(float64(offsetMs))
+}
+
+func (s *ServerClockMetrics) SetRttMs(rttMs int64) {
+	s.rtt.Set(float64(rttMs))
+}
+
+func (s *ServerClockMetrics) SetSkewAlarm(raised bool) {
+	if raised {
+		s.skewAlarm.Set(1)
+	} else {
+		s.skewAlarm.Set(0)
+	}
+}
+
+func (s *ServerClockMetrics) IncSyncErr() {
+	s.syncErrors.Inc()
+}
@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type ShardingMetrics struct {
+	liveReplicas  prometheus.Gauge
+	reassignments prometheus.Counter
+}
+
+func NewShardingMetrics() *ShardingMetrics {
+	return &ShardingMetrics{
+		liveReplicas: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: nestorNamespace,
+			Subsystem: "sharding",
+			Name:      "live_replicas",
+		}),
+		reassignments: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: nestorNamespace,
+			Subsystem: "sharding",
+			Name:      "reassignments",
+		}),
+	}
+}
+
+func (s *ShardingMetrics) SetLiveReplicas(numReplicas int) {
+	s.liveReplicas.Set(float64(numReplicas))
+}
+
+func (s *ShardingMetrics) IncReassignments() {
+	s.reassignments.Inc()
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"DeltaReceiver/internal/nestor/conf"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	otherSymbolsLabel   = "other"
+	topSymbolsUpdPeriod = time.Minute
+)
+
+// symbolLabeler restricts cardinality of the symbol label: symbols outside of
+// the allowlist and outside of the top N by received rows are reported as "other".
+type symbolLabeler struct {
+	mut          sync.Mutex
+	allowlist    map[string]struct{}
+	topN         int
+	rowsCounts   map[string]int64
+	topSymbols   map[string]struct{}
+	lastTopUpdTs time.Time
+}
+
+func newSymbolLabeler(cfg *conf.PipelineMetricsCfg) *symbolLabeler {
+	labeler := &symbolLabeler{
+		allowlist:  make(map[string]struct{}),
+		rowsCounts: make(map[string]int64),
+		topSymbols: make(map[string]struct{}),
+	}
+	if cfg == nil {
+		return labeler
+	}
+	for _, symbol := range cfg.SymbolsAllowlist {
+		labeler.allowlist[getMetricKey(symbol)] = struct{}{}
+	}
+	labeler.topN = cfg.TopSymbols
+	return labeler
+}
+
+func (s *symbolLabeler) isUnrestricted() bool {
+	return len(s.allowlist) == 0 && s.topN <= 0
+}
+
+func (s *symbolLabeler) countRows(symbol string, numRows int) {
+	if s.topN <= 0 {
+		return
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.rowsCounts[symbol] += int64(numRows)
+	if time.Since(s.lastTopUpdTs) >= topSymbolsUpdPeriod {
+		s.updateTopSymbols()
+	}
+}
+
+func (s *symbolLabeler) updateTopSymbols() {
+	symbols := make([]string, 0, len(s.rowsCounts))
+	for symbol := range s.rowsCounts {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		return s.rowsCounts[symbols[i]] > s.rowsCounts[symbols[j]]
+	})
+	topSymbols := make(map[string]struct{}, s.topN)
+	for i := 0; i < len(symbols) && i < s.topN; i++ {
+		topSymbols[symbols[i]] = struct{}{}
+	}
+	for symbol, count := range s.rowsCounts {
+		if count /= 2; count == 0 {
+			delete(s.rowsCounts, symbol)
+		} else {
+			s.rowsCounts[symbol] = count
+		}
+	}
+	s.topSymbols = topSymbols
+	s.lastTopUpdTs = time.Now()
+}
+
+func (s *symbolLabeler) label(symbol string) string {
+	if s.isUnrestricted() {
+		return symbol
+	}
+	if _, ok := s.allowlist[symbol]; ok {
+		return symbol
+	}
+	if s.topN <= 0 {
+		return otherSymbolsLabel
+	}
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if _, ok := s.topSymbols[symbol]; ok {
+		return symbol
+	}
+	return otherSymbolsLabel
+}
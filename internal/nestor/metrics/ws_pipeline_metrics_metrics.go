@@ -1,8 +1,13 @@
 package metrics
 
 import (
+	cmodel "DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/nestor/conf"
 	"DeltaReceiver/internal/nestor/svc"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/log"
 	"fmt"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -14,22 +19,83 @@ const (
 	binanceSubsystem = "binance"
 )
 
-type WsPipelineMetrics[T any] struct {
+var (
+	pipelineLabels = []string{"market", "data_type", "symbol"}
+	queueLabels    = []string{"market", "data_type"}
+
+	receivedRowsVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nestorNamespace,
+		Subsystem: binanceSubsystem,
+		Name:      "received_rows",
+	}, pipelineLabels)
+	savedRowsVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nestorNamespace,
+		Subsystem: binanceSubsystem,
+		Name:      "saved_rows",
+	}, pipelineLabels)
+	spooledRowsVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nestorNamespace,
+		Subsystem: binanceSubsystem,
+		Name:      "spooled_rows",
+	}, pipelineLabels)
+	failedRowsVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nestorNamespace,
+		Subsystem: binanceSubsystem,
+		Name:      "failed_rows",
+	}, pipelineLabels)
+	recvLatencyVec = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: nestorNamespace,
+		Subsystem: binanceSubsystem,
+		Name:      "exchange_to_receive_latency_ms",
+		Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+	}, pipelineLabels)
+	lastSeenVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nestorNamespace,
+		Subsystem: binanceSubsystem,
+		Name:      "last_seen_timestamp_ms",
+	}, pipelineLabels)
+	saveQueueDepthVec = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nestorNamespace,
+		Subsystem: binanceSubsystem,
+		Name:      "save_queue_depth",
+	}, queueLabels)
+	saveQueueOverflowsVec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nestorNamespace,
+		Subsystem: binanceSubsystem,
+		Name:      "save_queue_overflows",
+	}, queueLabels)
+	saveQueueBlockedTimeVec = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: nestorNamespace,
+		Subsystem: binanceSubsystem,
+		Name:      "save_queue_blocked_time_ms",
+		Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+	}, queueLabels)
+)
+
+type WsPipelineMetrics[T cmodel.BinanceDataRow] struct {
 	logger                *zap.Logger
 	startedSaveGoroutines prometheus.Counter
 	endedSaveGoroutines   prometheus.Counter
 	recvErrors            prometheus.Counter
-	ReceivedDeltas        map[string]prometheus.Counter
-	SentDeltas            map[string]prometheus.Counter
-	SavedDeltas           map[string]prometheus.Counter
-	ReceivedDeltasTotal   prometheus.Counter
-	SentDeltasTotal       prometheus.Counter
-	SavedDeltasTotal      prometheus.Counter
-	RecvDeltaErr          prometheus.Counter
+	symbolLabeler         *symbolLabeler
+	receivedRows          *prometheus.CounterVec
+	savedRows             *prometheus.CounterVec
+	spooledRows           *prometheus.CounterVec
+	failedRows            *prometheus.CounterVec
+	recvLatency           prometheus.ObserverVec
+	exchangeTimestamps    bool
+	lastSeen              *prometheus.GaugeVec
+	saveQueueDepth        prometheus.Gauge
+	saveQueueOverflows    prometheus.Counter
+	saveQueueBlockedTime  prometheus.Observer
 }
 
-func NewWsPipelineMetrics[T any](dataType string) *WsPipelineMetrics[T] {
+// NewWsPipelineMetrics observes exchange to receive latency only if exchangeTimestamps is
+// set, rows stamped with the local receive time, like book ticks, would always show zero.
+func NewWsPipelineMetrics[T cmodel.BinanceDataRow](dataType string, pipelineName string, marketType bmodel.DataType, exchangeTimestamps bool, cfg *conf.PipelineMetricsCfg) *WsPipelineMetrics[T] {
+	labels := prometheus.Labels{"market": string(marketType), "data_type": pipelineName}
 	return &WsPipelineMetrics[T]{
+		logger: log.GetLogger(fmt.Sprintf("WsPipelineMetrics[%s]", dataType)),
 		startedSaveGoroutines: promauto.NewCounter(prometheus.CounterOpts{
 			Namespace: nestorNamespace,
 			Subsystem: binanceSubsystem,
@@ -45,6 +111,17 @@ func NewWsPipelineMetrics[T any](dataType string) *WsPipelineMetrics[T] {
 			Subsystem: binanceSubsystem,
 			Name:      fmt.Sprintf("receive_%s_error", dataType),
 		}),
+		symbolLabeler:        newSymbolLabeler(cfg),
+		receivedRows:         receivedRowsVec.MustCurryWith(labels),
+		savedRows:            savedRowsVec.MustCurryWith(labels),
+		spooledRows:          spooledRowsVec.MustCurryWith(labels),
+		failedRows:           failedRowsVec.MustCurryWith(labels),
+		recvLatency:          recvLatencyVec.MustCurryWith(labels),
+		exchangeTimestamps:   exchangeTimestamps,
+		lastSeen:             lastSeenVec.MustCurryWith(labels),
+		saveQueueDepth:       saveQueueDepthVec.With(labels),
+		saveQueueOverflows:   saveQueueOverflowsVec.With(labels),
+		saveQueueBlockedTime: saveQueueBlockedTimeVec.With(labels),
 	}
 }
 
@@ -62,64 +139,68 @@ func (s *WsPipelineMetrics[T]) IncRecvErr() {
 	s.recvErrors.Inc()
 }
 
-// func (s *DeltaMetrics) updateActiveMetrics(symbols []string) {
-// 	if s.ReceivedDeltasTotal == nil {
-// 		s.ReceivedDeltasTotal = promauto.NewCounter(prometheus.CounterOpts{
-// 			Namespace: BinanceDeltasNamespace,
-// 			Subsystem: SpotSubsystem,
-// 			Name:      "received_total",
-// 		})
-// 		s.SentDeltasTotal = promauto.NewCounter(prometheus.CounterOpts{
-// 			Namespace: BinanceDeltasNamespace,
-// 			Subsystem: SpotSubsystem,
-// 			Name:      "sent_total",
-// 		})
-// 		s.SavedDeltasTotal = promauto.NewCounter(prometheus.CounterOpts{
-// 			Namespace: BinanceDeltasNamespace,
-// 			Subsystem: SpotSubsystem,
-// 			Name:      "saved_total",
-// 		})
-// 	}
-// 	for _, symbol := range symbols {
-// 		metricKey := getMetricKey(symbol)
-// 		if _, ok := s.ReceivedDeltas[metricKey]; !ok {
-// 			s.ReceivedDeltas[symbol] = promauto.NewCounter(prometheus.CounterOpts{
-// 				Namespace: BinanceDeltasNamespace,
-// 				Subsystem: SpotSubsystem,
-// 				Name:      fmt.Sprintf("received_%s", metricKey),
-// 			})
-// 			s.SentDeltas[metricKey] = promauto.NewCounter(prometheus.CounterOpts{
-// 				Namespace: BinanceDeltasNamespace,
-// 				Subsystem: SpotSubsystem,
-// 				Name:      fmt.Sprintf("sent_%s", metricKey),
-// 			})
-// 			s.SavedDeltas[metricKey] = promauto.NewCounter(prometheus.CounterOpts{
-// 				Namespace: BinanceDeltasNamespace,
-// 				Subsystem: SpotSubsystem,
-// 				Name:      fmt.Sprintf("saved_%s", metricKey),
-// 			})
-// 		}
-// 	}
-// }
+func (s *WsPipelineMetrics[T]) IncQueueDepth() {
+	s.saveQueueDepth.Inc()
+}
+
+func (s *WsPipelineMetrics[T]) DecQueueDepth() {
+	s.saveQueueDepth.Dec()
+}
+
+func (s *WsPipelineMetrics[T]) IncQueueOverflow() {
+	s.saveQueueOverflows.Inc()
+}
 
+func (s *WsPipelineMetrics[T]) ObserveQueueBlockedTime(blockedTime time.Duration) {
+	s.saveQueueBlockedTime.Observe(float64(blockedTime.Milliseconds()))
+}
+
+// ProcessDataMetrics is called with rows of a single message on Receive, so the latency is
+// observed once per symbol of the message with its latest row.
 func (s *WsPipelineMetrics[T]) ProcessDataMetrics(data []T, event svc.TypeOfEvent) {
-	// if event == svc.Receive {
-	// 	s.processMetrics(deltas, s.ReceivedDeltas, s.ReceivedDeltasTotal)
-	// } else if event == svc.Send {
-	// 	s.processMetrics(deltas, s.SentDeltas, s.SentDeltasTotal)
-	// } else if event == svc.Save {
-	// 	s.processMetrics(deltas, s.SavedDeltas, s.SavedDeltasTotal)
-	// }
+	if len(data) == 0 {
+		return
+	}
+	var counters *prometheus.CounterVec
+	switch event {
+	case svc.Receive:
+		counters = s.receivedRows
+	case svc.Save:
+		counters = s.savedRows
+	case svc.Spool:
+		counters = s.spooledRows
+	case svc.Fail:
+		counters = s.failedRows
+	default:
+		return
+	}
+	rowsBySymbol := groupRowsBySymbol(data)
+	nowMs := time.Now().UnixMilli()
+	for symbol, rows := range rowsBySymbol {
+		if event == svc.Receive {
+			s.symbolLabeler.countRows(symbol, len(rows))
+		}
+		symbolLabel := s.symbolLabeler.label(symbol)
+		counters.WithLabelValues(symbolLabel).Add(float64(len(rows)))
+		if event != svc.Receive {
+			continue
+		}
+		var lastTimestampMs int64
+		for _, row := range rows {
+			lastTimestampMs = max(lastTimestampMs, row.GetTimestampMs())
+		}
+		if s.exchangeTimestamps {
+			s.recvLatency.WithLabelValues(symbolLabel).Observe(float64(nowMs - lastTimestampMs))
+		}
+		s.lastSeen.WithLabelValues(symbolLabel).Set(float64(lastTimestampMs))
+	}
 }
 
-// func (s *DeltaMetrics) processMetrics(deltas []model.Delta, metrics map[string]prometheus.Counter, totalMetric prometheus.Counter) {
-// 	for _, delta := range deltas {
-// 		metricKey := getMetricKey(delta.Symbol)
-// 		if metric, ok := metrics[metricKey]; !ok {
-// 			s.logger.Warn(fmt.Sprintf("try to process non-existense metric with key [%s]", metricKey))
-// 		} else {
-// 			metric.Inc()
-// 		}
-// 	}
-// 	totalMetric.Add(float64(len(deltas)))
-// }
+func groupRowsBySymbol[T cmodel.BinanceDataRow](data []T) map[string][]T {
+	rowsBySymbol := make(map[string][]T)
+	for _, row := range data {
+		metricKey := getMetricKey(row.GetSymbol())
+		rowsBySymbol[metricKey] = append(rowsBySymbol[metricKey], row)
+	}
+	return rowsBySymbol
+}
@@ -41,6 +41,23 @@ func NewClickhouseRepo(chPoolHolder *clickhouse.ChPoolHolder, cfg *conf.GlobalRe
 	}
 }
 
+func (s ClickhouseRepo) insertSettings(ctx context.Context) []ch.Setting {
+	if s.cfg.InsertCfg == nil {
+		return clickhouse.InsertSettings(ctx, false, false)
+	}
+	return clickhouse.InsertSettings(ctx, s.cfg.InsertCfg.AsyncInsert, s.cfg.InsertCfg.WaitForAsyncInsert)
+}
+
+func (s ClickhouseRepo) blockSize() int {
+	if s.cfg.InsertCfg == nil {
+		return 0
+	}
+	return s.cfg.InsertCfg.BlockSize
+}
+
+// prepareBookTickerInsertBlock stores the receive time of every tick. Ticks used to be
+// stamped with the insert time, which with batching and spool replay can be minutes or
+// hours after they were received.
 func prepareBookTickerInsertBlock(ticks []bmodel.SymbolTick) proto.Input {
 	timestampCol := new(proto.ColDateTime64).WithPrecision(3)
 	var symbolCol proto.ColStr
@@ -48,9 +65,8 @@ func prepareBookTickerInsertBlock(ticks []bmodel.SymbolTick) proto.Input {
 	var bidQuantityCol proto.ColStr
 	var askPriceCol proto.ColStr
 	var askQuantityCol proto.ColStr
-	cutTime := time.Now()
 	for _, tick := range ticks {
-		timestampCol.Append(cutTime)
+		timestampCol.Append(time.UnixMilli(tick.Timestamp))
 		symbolCol.Append(tick.Symbol)
 		bidPriceCol.Append(tick.BidPrice)
 		bidQuantityCol.Append(tick.BidQuantity)
@@ -77,8 +93,9 @@ func (s ClickhouseRepo) SendBookTicks(ctx context.Context, ticks []bmodel.Symbol
 	}
 	input := prepareBookTickerInsertBlock(ticks)
 	err := s.pool.Do(ctx, ch.Query{
-		Body:  fmt.Sprintf("INSERT INTO %s.%s VALUES", s.cfg.DatabaseName, s.cfg.BookTickerTable),
-		Input: input,
+		Body:     fmt.Sprintf("INSERT INTO %s.%s VALUES", s.cfg.DatabaseName, s.cfg.BookTickerTable),
+		Input:    input,
+		Settings: s.insertSettings(ctx),
 	})
 	if err != nil {
 		return fmt.Errorf("error while sending ticks %w", err)
@@ -125,8 +142,9 @@ func (s ClickhouseRepo) SendSnapshot(ctx context.Context, snapshot []model2.Dept
 	input := prepareFullSnapshotInsertBlock(snapshot)
 	//s.logger.Debug(fmt.Sprintf("%d", s.pool.connPool))
 	err := s.pool.Do(ctx, ch.Query{
-		Body:  fmt.Sprintf("INSERT INTO %s.%s VALUES", s.cfg.DatabaseName, s.cfg.SnapshotTable),
-		Input: input,
+		Body:     fmt.Sprintf("INSERT INTO %s.%s VALUES", s.cfg.DatabaseName, s.cfg.SnapshotTable),
+		Input:    input,
+		Settings: s.insertSettings(ctx),
 	})
 	if err != nil {
 		return fmt.Errorf("error while sending snapshot %w", err)
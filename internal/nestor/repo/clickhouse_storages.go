@@ -0,0 +1,115 @@
+package repo
+
+import (
+	cmodel "DeltaReceiver/internal/common/model"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/clickhouse"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ClickHouse/ch-go"
+	"github.com/ClickHouse/ch-go/proto"
+)
+
+type ChBookTicksStorage struct {
+	repo *ClickhouseRepo
+}
+
+func NewChBookTicksStorage(repo *ClickhouseRepo) *ChBookTicksStorage {
+	return &ChBookTicksStorage{repo: repo}
+}
+
+func (s ChBookTicksStorage) Save(ctx context.Context, ticks []bmodel.SymbolTick) error {
+	return clickhouse.InsertInBlocks(ctx, ticks, s.repo.blockSize(), s.repo.SendBookTicks)
+}
+
+type ChSnapshotStorage struct {
+	repo *ClickhouseRepo
+}
+
+func NewChSnapshotStorage(repo *ClickhouseRepo) *ChSnapshotStorage {
+	return &ChSnapshotStorage{repo: repo}
+}
+
+func (s ChSnapshotStorage) Save(ctx context.Context, snapshot []cmodel.DepthSnapshotPart) error {
+	return clickhouse.InsertInBlocks(ctx, snapshot, s.repo.blockSize(), s.repo.SendSnapshot)
+}
+
+type ChExchangeInfoStorage struct {
+	repo *ClickhouseRepo
+}
+
+func NewChExchangeInfoStorage(repo *ClickhouseRepo) *ChExchangeInfoStorage {
+	return &ChExchangeInfoStorage{repo: repo}
+}
+
+func prepareExchangeInfosInsertBlock(exInfos []cmodel.ExchangeInfo) proto.Input {
+	timestampCol := new(proto.ColDateTime64).WithPrecision(3)
+	var exCol proto.ColStr
+	var hashCol proto.ColInt64
+	for _, exInfo := range exInfos {
+		timestampCol.Append(time.UnixMilli(exInfo.ServerTime))
+		exCol.Append(exInfo.Payload)
+		hashCol.Append(exInfo.ExInfoHash)
+	}
+	return proto.Input{
+		{Name: TimestampCol, Data: timestampCol},
+		{Name: ExchangeInfoCol, Data: &exCol},
+		{Name: HashCol, Data: &hashCol},
+	}
+}
+
+func (s ChExchangeInfoStorage) Save(ctx context.Context, exInfos []cmodel.ExchangeInfo) error {
+	if s.repo.pool == nil {
+		return clickhouse.NilConnPool
+	}
+	if len(exInfos) == 0 {
+		return nil
+	}
+	err := s.repo.pool.Do(ctx, ch.Query{
+		Body:     fmt.Sprintf("INSERT INTO %s.%s VALUES", s.repo.cfg.DatabaseName, s.repo.cfg.ExchangeInfoTable),
+		Input:    prepareExchangeInfosInsertBlock(exInfos),
+		Settings: s.repo.insertSettings(ctx),
+	})
+	if err != nil {
+		return fmt.Errorf("error while sending exchange info %w", err)
+	}
+	return nil
+}
+
+func (s ChExchangeInfoStorage) GetLastExchangeInfo(ctx context.Context) *cmodel.ExchangeInfo {
+	if s.repo.pool == nil {
+		return nil
+	}
+	timestampCol := new(proto.ColDateTime64).WithPrecision(3)
+	var exCol proto.ColStr
+	var hashCol proto.ColInt64
+	var exInfo *cmodel.ExchangeInfo
+	if err := s.repo.pool.Do(ctx, ch.Query{
+		Body: fmt.Sprintf("SELECT %s, %s, %s FROM %s.%s ORDER BY %s DESC LIMIT 1",
+			TimestampCol, ExchangeInfoCol, HashCol, s.repo.cfg.DatabaseName, s.repo.cfg.ExchangeInfoTable, TimestampCol),
+		Result: proto.Results{
+			{Name: TimestampCol, Data: timestampCol},
+			{Name: ExchangeInfoCol, Data: &exCol},
+			{Name: HashCol, Data: &hashCol},
+		},
+		OnResult: func(ctx context.Context, block proto.Block) error {
+			if block.Rows != 0 {
+				exInfo = &cmodel.ExchangeInfo{
+					ServerTime: timestampCol.Row(0).UnixMilli(),
+					Payload:    exCol.Row(0),
+					ExInfoHash: hashCol.Row(0),
+				}
+			}
+			return nil
+		},
+	}); err != nil {
+		s.repo.logger.Error(err.Error())
+		return nil
+	}
+	if exInfo == nil {
+		return &cmodel.ExchangeInfo{}
+	}
+	return exInfo
+}
@@ -1,80 +1,585 @@
 package repo
 
 import (
+	"DeltaReceiver/internal/nestor/conf"
 	"DeltaReceiver/pkg/log"
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/binary"
+	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math/rand/v2"
+	"hash/crc32"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"go.uber.org/zap"
 )
 
+var (
+	ErrSpoolQuotaExceeded = errors.New("spool disk quota exceeded")
+	ErrSpoolClosed        = errors.New("spool closed")
+	errTornRecord         = errors.New("torn or corrupted spool record")
+)
+
+const (
+	segmentFileExt     = ".seg"
+	corruptedFileExt   = ".corrupted"
+	checkpointFileName = "checkpoint"
+	recordHeaderSize   = 8
+	checkpointSize     = 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type spoolPosition struct {
+	segmentNo int64
+	offset    int64
+}
+
+// FileRepo is an append-only spool of data batches split into segment files.
+// Every batch is stored as one record: 4 bytes of payload length, 4 bytes of
+// CRC32-C of the payload and the zstd compressed gob encoding of the batch.
+// Records are replayed in the order they were written; the position of the last
+// replayed record is persisted in the checkpoint file.
 type FileRepo[T any] struct {
-	logger  *zap.Logger
-	dirPath string
+	logger           *zap.Logger
+	dirPath          string
+	segmentSize      int64
+	maxSize          int64
+	fsyncPolicy      conf.FsyncPolicy
+	mut              sync.Mutex
+	encoder          *zstd.Encoder
+	decoder          *zstd.Decoder
+	segmentSizes     map[int64]int64
+	totalSize        int64
+	activeSegmentNo  int64
+	activeSegment    *os.File
+	dirty            bool
+	readPos          spoolPosition
+	closed           bool
+	shutdownCh       chan struct{}
+	fsyncCompletedCh chan struct{}
 }
 
-func NewFileRepo[T any](dataType string) *FileRepo[T] {
-	dirPath := fmt.Sprintf("/var/data/%s", dataType)
+func NewFileRepo[T any](dataType string, cfg *conf.SpoolCfg) *FileRepo[T] {
+	dirPath := filepath.Join(cfg.Dir, dataType)
 	logger := log.GetLogger(fmt.Sprintf("FileRepo[%s]", dataType))
-	err := os.MkdirAll(dirPath, 0755)
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		panic(err)
+	}
+	encoder, err := zstd.NewWriter(nil)
 	if err != nil {
-		logger.Error(err.Error())
+		panic(err)
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		panic(err)
+	}
+	repo := &FileRepo[T]{
+		logger:           logger,
+		dirPath:          dirPath,
+		segmentSize:      cfg.SegmentSizeMb << 20,
+		maxSize:          cfg.MaxSizeMb << 20,
+		fsyncPolicy:      cfg.FsyncPolicy,
+		encoder:          encoder,
+		decoder:          decoder,
+		segmentSizes:     make(map[int64]int64),
+		shutdownCh:       make(chan struct{}),
+		fsyncCompletedCh: make(chan struct{}),
 	}
-	return &FileRepo[T]{
-		dirPath: dirPath,
-		logger:  logger,
+	if err := repo.open(); err != nil {
+		panic(err)
 	}
+	repo.migrateLegacyFiles()
+	if cfg.FsyncPolicy == conf.FsyncInterval {
+		go repo.fsyncPeriodically(time.Duration(cfg.FsyncIntervalMs) * time.Millisecond)
+	} else {
+		close(repo.fsyncCompletedCh)
+	}
+	return repo
 }
 
-func (s *FileRepo[T]) Save(ctx context.Context, batch []T) error {
-	file, err := os.Create(fmt.Sprintf("%s/%d_%d_%d", s.dirPath, time.Now().UnixMilli(), rand.Int64(), rand.Int64()))
+func (s *FileRepo[T]) segmentPath(segmentNo int64) string {
+	return filepath.Join(s.dirPath, fmt.Sprintf("%020d%s", segmentNo, segmentFileExt))
+}
+
+func (s *FileRepo[T]) open() error {
+	dir, err := os.ReadDir(s.dirPath)
 	if err != nil {
-		s.logger.Error(err.Error())
 		return err
 	}
-	data, _ := json.Marshal(batch)
-	if _, err = file.Write(data); err != nil {
-		s.logger.Error(err.Error())
+	var segmentNos []int64
+	for _, dirEntry := range dir {
+		name := dirEntry.Name()
+		if !dirEntry.Type().IsRegular() || !strings.HasSuffix(name, segmentFileExt) {
+			continue
+		}
+		segmentNo, err := strconv.ParseInt(strings.TrimSuffix(name, segmentFileExt), 10, 64)
+		if err != nil {
+			s.logger.Warn(fmt.Sprintf("unexpected file %s in spool directory", name))
+			continue
+		}
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+		segmentNos = append(segmentNos, segmentNo)
+		s.segmentSizes[segmentNo] = info.Size()
+		s.totalSize += info.Size()
+	}
+	slices.Sort(segmentNos)
+	if len(segmentNos) == 0 {
+		s.readPos = spoolPosition{segmentNo: 1}
+		return s.createSegment(1)
+	}
+	lastSegmentNo := segmentNos[len(segmentNos)-1]
+	if err = s.recoverSegment(lastSegmentNo); err != nil {
+		return err
+	}
+	s.activeSegment, err = os.OpenFile(s.segmentPath(lastSegmentNo), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
 		return err
 	}
-	file.Close()
+	s.activeSegmentNo = lastSegmentNo
+	s.readPos = s.loadCheckpoint(segmentNos[0])
+	for _, segmentNo := range segmentNos {
+		if segmentNo < s.readPos.segmentNo {
+			s.deleteSegment(segmentNo)
+		}
+	}
+	s.logger.Info(fmt.Sprintf("spool opened with %d segments, %d bytes to replay", len(s.segmentSizes), s.backlogBytes()))
 	return nil
 }
 
-func (s *FileRepo[T]) GetWithDeleteCallback(ctx context.Context) ([]T, error, func() error) {
+// migrateLegacyFiles appends batches left by the previous spool, one JSON file per batch
+// named by its save time, to the spool in the order they were saved and deletes the files.
+// A file which can not be decoded is renamed with the corrupted extension, a file which does
+// not fit the quota is left for the next start.
+func (s *FileRepo[T]) migrateLegacyFiles() {
 	dir, err := os.ReadDir(s.dirPath)
 	if err != nil {
 		s.logger.Error(err.Error())
-		return nil, err, func() error { return nil }
+		return
+	}
+	type legacyFile struct {
+		name    string
+		savedMs int64
 	}
+	var legacyFiles []legacyFile
 	for _, dirEntry := range dir {
-		if dirEntry.Type().IsRegular() {
-			filePath := filepath.Join(s.dirPath, dirEntry.Name())
-			s.logger.Info(fmt.Sprintf("working with file %s", filePath))
-			rawData, err := os.ReadFile(filePath)
-			if err != nil {
-				s.logger.Error(fmt.Errorf("error opening file %s: %w", filePath, err).Error())
-				return nil, err, func() error { return nil }
+		name := dirEntry.Name()
+		savedMs, _, found := strings.Cut(name, "_")
+		if !dirEntry.Type().IsRegular() || !found || strings.Contains(name, ".") {
+			continue
+		}
+		if ms, err := strconv.ParseInt(savedMs, 10, 64); err == nil {
+			legacyFiles = append(legacyFiles, legacyFile{name: name, savedMs: ms})
+		}
+	}
+	slices.SortFunc(legacyFiles, func(a, b legacyFile) int {
+		return cmp.Or(cmp.Compare(a.savedMs, b.savedMs), strings.Compare(a.name, b.name))
+	})
+	for _, file := range legacyFiles {
+		filePath := filepath.Join(s.dirPath, file.name)
+		rawData, err := os.ReadFile(filePath)
+		if err != nil {
+			s.logger.Error(err.Error())
+			return
+		}
+		var data []T
+		if err = json.Unmarshal(rawData, &data); err != nil {
+			s.logger.Error(fmt.Errorf("legacy spool file %s not decoded, rename it: %w", filePath, err).Error())
+			if err = os.Rename(filePath, filePath+corruptedFileExt); err != nil {
+				s.logger.Error(err.Error())
 			}
-			var data []T
-			err = json.Unmarshal(rawData, &data)
-			if err != nil {
-				s.logger.Error(fmt.Errorf("error unmarshaling data %s: %w", filePath, err).Error())
-				return nil, err, func() error { return nil }
+			continue
+		}
+		if len(data) > 0 {
+			if err = s.Save(context.Background(), data); err != nil {
+				s.logger.Error(fmt.Errorf("legacy spool file %s not migrated: %w", filePath, err).Error())
+				return
 			}
-			return data, nil, func() error {
-				err := os.Remove(filePath)
-				if err == nil {
-					s.logger.Info(fmt.Sprintf("file %s deleted", filePath))
+		}
+		if err = os.Remove(filePath); err != nil {
+			s.logger.Error(err.Error())
+			return
+		}
+		s.logger.Info(fmt.Sprintf("legacy spool file %s migrated with %d rows", filePath, len(data)))
+	}
+}
+
+// recoverSegment truncates the segment after the last complete record, so a record
+// torn by a crash is never replayed.
+func (s *FileRepo[T]) recoverSegment(segmentNo int64) error {
+	file, err := os.OpenFile(s.segmentPath(segmentNo), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	size := s.segmentSizes[segmentNo]
+	var offset int64
+	for offset < size {
+		payload, err := readRecord(file, offset, size)
+		if err != nil {
+			break
+		}
+		offset += recordHeaderSize + int64(len(payload))
+	}
+	if offset == size {
+		return nil
+	}
+	s.logger.Warn(fmt.Sprintf("truncate torn tail of segment %d: %d bytes dropped", segmentNo, size-offset))
+	if err = file.Truncate(offset); err != nil {
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		return err
+	}
+	s.segmentSizes[segmentNo] = offset
+	s.totalSize -= size - offset
+	return nil
+}
+
+func readRecord(file *os.File, offset int64, size int64) ([]byte, error) {
+	if size-offset < recordHeaderSize {
+		return nil, errTornRecord
+	}
+	header := make([]byte, recordHeaderSize)
+	if _, err := file.ReadAt(header, offset); err != nil {
+		return nil, err
+	}
+	payloadLen := int64(binary.LittleEndian.Uint32(header[:4]))
+	if size-offset-recordHeaderSize < payloadLen {
+		return nil, errTornRecord
+	}
+	payload := make([]byte, payloadLen)
+	if _, err := file.ReadAt(payload, offset+recordHeaderSize); err != nil {
+		return nil, err
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:]) {
+		return nil, errTornRecord
+	}
+	return payload, nil
+}
+
+func (s *FileRepo[T]) createSegment(segmentNo int64) error {
+	file, err := os.OpenFile(s.segmentPath(segmentNo), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	s.activeSegment = file
+	s.activeSegmentNo = segmentNo
+	s.segmentSizes[segmentNo] = 0
+	s.dirty = false
+	return nil
+}
+
+func (s *FileRepo[T]) rollSegment() error {
+	if s.fsyncPolicy != conf.FsyncNever && s.dirty {
+		if err := s.activeSegment.Sync(); err != nil {
+			return err
+		}
+	}
+	if err := s.activeSegment.Close(); err != nil {
+		s.logger.Warn(err.Error())
+	}
+	return s.createSegment(s.activeSegmentNo + 1)
+}
+
+func (s *FileRepo[T]) deleteSegment(segmentNo int64) {
+	if err := os.Remove(s.segmentPath(segmentNo)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.Error(err.Error())
+		return
+	}
+	s.totalSize -= s.segmentSizes[segmentNo]
+	delete(s.segmentSizes, segmentNo)
+	s.logger.Info(fmt.Sprintf("segment %d deleted", segmentNo))
+}
+
+func (s *FileRepo[T]) quarantineSegment(segmentNo int64) {
+	segmentPath := s.segmentPath(segmentNo)
+	if err := os.Rename(segmentPath, segmentPath+corruptedFileExt); err != nil {
+		s.logger.Error(err.Error())
+	}
+	s.totalSize -= s.segmentSizes[segmentNo]
+	delete(s.segmentSizes, segmentNo)
+}
+
+func (s *FileRepo[T]) loadCheckpoint(firstSegmentNo int64) spoolPosition {
+	firstPos := spoolPosition{segmentNo: firstSegmentNo}
+	rawCheckpoint, err := os.ReadFile(filepath.Join(s.dirPath, checkpointFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return firstPos
+	}
+	if err != nil || len(rawCheckpoint) != checkpointSize ||
+		crc32.Checksum(rawCheckpoint[:16], crcTable) != binary.LittleEndian.Uint32(rawCheckpoint[16:]) {
+		s.logger.Warn("invalid spool checkpoint, replay from the first segment")
+		return firstPos
+	}
+	pos := spoolPosition{
+		segmentNo: int64(binary.LittleEndian.Uint64(rawCheckpoint[:8])),
+		offset:    int64(binary.LittleEndian.Uint64(rawCheckpoint[8:16])),
+	}
+	if size, ok := s.segmentSizes[pos.segmentNo]; !ok || pos.offset > size {
+		if pos.segmentNo > firstSegmentNo {
+			return spoolPosition{segmentNo: pos.segmentNo}
+		}
+		return firstPos
+	}
+	return pos
+}
+
+func (s *FileRepo[T]) storeCheckpoint() error {
+	rawCheckpoint := make([]byte, checkpointSize)
+	binary.LittleEndian.PutUint64(rawCheckpoint[:8], uint64(s.readPos.segmentNo))
+	binary.LittleEndian.PutUint64(rawCheckpoint[8:16], uint64(s.readPos.offset))
+	binary.LittleEndian.PutUint32(rawCheckpoint[16:], crc32.Checksum(rawCheckpoint[:16], crcTable))
+	tmpPath := filepath.Join(s.dirPath, checkpointFileName+".tmp")
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(rawCheckpoint); err != nil {
+		file.Close()
+		return err
+	}
+	if s.fsyncPolicy != conf.FsyncNever {
+		if err = file.Sync(); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, filepath.Join(s.dirPath, checkpointFileName))
+}
+
+func (s *FileRepo[T]) encodeRecord(batch []T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(batch); err != nil {
+		return nil, err
+	}
+	record := make([]byte, recordHeaderSize, recordHeaderSize+buf.Len())
+	record = s.encoder.EncodeAll(buf.Bytes(), record)
+	payload := record[recordHeaderSize:]
+	binary.LittleEndian.PutUint32(record[:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.Checksum(payload, crcTable))
+	return record, nil
+}
+
+func (s *FileRepo[T]) decodeRecord(payload []byte) ([]T, error) {
+	rawData, err := s.decoder.DecodeAll(payload, nil)
+	if err != nil {
+		return nil, err
+	}
+	var data []T
+	if err = gob.NewDecoder(bytes.NewReader(rawData)).Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *FileRepo[T]) Save(ctx context.Context, batch []T) error {
+	record, err := s.encodeRecord(batch)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return err
+	}
+	recordLen := int64(len(record))
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.closed {
+		return ErrSpoolClosed
+	}
+	if s.maxSize > 0 && s.totalSize+recordLen > s.maxSize {
+		s.logger.Error(fmt.Sprintf("%s: %d bytes used", ErrSpoolQuotaExceeded.Error(), s.totalSize))
+		return ErrSpoolQuotaExceeded
+	}
+	activeSegmentSize := s.segmentSizes[s.activeSegmentNo]
+	if s.segmentSize > 0 && activeSegmentSize > 0 && activeSegmentSize+recordLen > s.segmentSize {
+		if err = s.rollSegment(); err != nil {
+			s.logger.Error(err.Error())
+			return err
+		}
+		activeSegmentSize = 0
+	}
+	if _, err = s.activeSegment.Write(record); err != nil {
+		s.logger.Error(err.Error())
+		if truncErr := s.activeSegment.Truncate(activeSegmentSize); truncErr != nil {
+			s.logger.Error(truncErr.Error())
+		}
+		return err
+	}
+	s.segmentSizes[s.activeSegmentNo] += recordLen
+	s.totalSize += recordLen
+	s.dirty = true
+	if s.fsyncPolicy == conf.FsyncAlways {
+		if err = s.activeSegment.Sync(); err != nil {
+			s.logger.Error(err.Error())
+			return err
+		}
+		s.dirty = false
+	}
+	return nil
+}
+
+func (s *FileRepo[T]) fsyncPeriodically(interval time.Duration) {
+	defer close(s.fsyncCompletedCh)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.shutdownCh:
+			return
+		case <-ticker.C:
+			s.mut.Lock()
+			if s.dirty && !s.closed {
+				if err := s.activeSegment.Sync(); err != nil {
+					s.logger.Error(err.Error())
+				} else {
+					s.dirty = false
 				}
-				return err
 			}
+			s.mut.Unlock()
+		}
+	}
+}
+
+// GetWithDeleteCallback returns the oldest not yet replayed batch. The callback
+// marks the batch as replayed and deletes fully replayed segments.
+func (s *FileRepo[T]) GetWithDeleteCallback(ctx context.Context) ([]T, error, func() error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for {
+		pos := s.readPos
+		size, ok := s.segmentSizes[pos.segmentNo]
+		if !ok {
+			nextSegmentNo, found := s.nextSegmentNo(pos.segmentNo)
+			if !found {
+				return nil, nil, func() error { return nil }
+			}
+			s.readPos = spoolPosition{segmentNo: nextSegmentNo}
+			continue
+		}
+		if pos.offset >= size {
+			if pos.segmentNo == s.activeSegmentNo {
+				return nil, nil, func() error { return nil }
+			}
+			s.deleteSegment(pos.segmentNo)
+			continue
+		}
+		payload, err := s.readPayload(pos, size)
+		if errors.Is(err, errTornRecord) && pos.segmentNo != s.activeSegmentNo {
+			s.logger.Error(fmt.Sprintf("corrupted record in segment %d at offset %d, segment quarantined", pos.segmentNo, pos.offset))
+			s.quarantineSegment(pos.segmentNo)
+			continue
+		}
+		if err != nil {
+			s.logger.Error(err.Error())
+			return nil, err, func() error { return nil }
 		}
+		nextPos := spoolPosition{segmentNo: pos.segmentNo, offset: pos.offset + recordHeaderSize + int64(len(payload))}
+		data, err := s.decodeRecord(payload)
+		if err != nil {
+			// the checksum matched, so the record is skipped instead of retried forever
+			s.logger.Error(fmt.Errorf("error decoding record in segment %d at offset %d, record skipped: %w", pos.segmentNo, pos.offset, err).Error())
+			s.readPos = nextPos
+			if err = s.storeCheckpoint(); err != nil {
+				s.logger.Error(err.Error())
+			}
+			continue
+		}
+		return data, nil, func() error {
+			return s.commit(pos, nextPos)
+		}
+	}
+}
+
+func (s *FileRepo[T]) readPayload(pos spoolPosition, size int64) ([]byte, error) {
+	file, err := os.Open(s.segmentPath(pos.segmentNo))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	payload, err := readRecord(file, pos.offset, size)
+	if errors.Is(err, io.EOF) {
+		return nil, errTornRecord
+	}
+	return payload, err
+}
+
+func (s *FileRepo[T]) nextSegmentNo(segmentNo int64) (int64, bool) {
+	var nextSegmentNo int64
+	found := false
+	for no := range s.segmentSizes {
+		if no > segmentNo && (!found || no < nextSegmentNo) {
+			nextSegmentNo = no
+			found = true
+		}
+	}
+	return nextSegmentNo, found
+}
+
+func (s *FileRepo[T]) commit(pos spoolPosition, nextPos spoolPosition) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.readPos != pos {
+		return nil
+	}
+	s.readPos = nextPos
+	if nextPos.segmentNo == s.activeSegmentNo && nextPos.offset == s.segmentSizes[s.activeSegmentNo] && !s.closed {
+		if err := s.rollSegment(); err != nil {
+			s.logger.Error(err.Error())
+		} else {
+			s.deleteSegment(nextPos.segmentNo)
+			s.readPos = spoolPosition{segmentNo: s.activeSegmentNo}
+		}
+	}
+	return s.storeCheckpoint()
+}
+
+func (s *FileRepo[T]) backlogBytes() int64 {
+	return s.totalSize - s.readPos.offset
+}
+
+// BacklogBytes returns the number of spooled bytes not yet replayed.
+func (s *FileRepo[T]) BacklogBytes() int64 {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.backlogBytes()
+}
+
+func (s *FileRepo[T]) Close(ctx context.Context) {
+	s.mut.Lock()
+	if s.closed {
+		s.mut.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.shutdownCh)
+	if s.fsyncPolicy != conf.FsyncNever && s.dirty {
+		if err := s.activeSegment.Sync(); err != nil {
+			s.logger.Error(err.Error())
+		}
+	}
+	if err := s.activeSegment.Close(); err != nil {
+		s.logger.Error(err.Error())
+	}
+	s.mut.Unlock()
+	<-s.fsyncCompletedCh
+	s.decoder.Close()
+	if err := s.encoder.Close(); err != nil {
+		s.logger.Error(err.Error())
 	}
-	return nil, nil, func() error { return nil }
 }
@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/nestor/conf"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+type spoolRow struct {
+	Id     int64
+	Symbol string
+}
+
+func newTestFileRepo(t *testing.T, dir string) *FileRepo[spoolRow] {
+	t.Helper()
+	repo := NewFileRepo[spoolRow]("rows", &conf.SpoolCfg{
+		Dir:           dir,
+		SegmentSizeMb: 1,
+		FsyncPolicy:   conf.FsyncAlways,
+	})
+	t.Cleanup(func() { repo.Close(context.Background()) })
+	return repo
+}
+
+func drainFileRepo(t *testing.T, repo *FileRepo[spoolRow]) [][]spoolRow {
+	t.Helper()
+	var batches [][]spoolRow
+	for {
+		data, err, callback := repo.GetWithDeleteCallback(context.Background())
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		if data == nil {
+			return batches
+		}
+		batches = append(batches, data)
+		if err = callback(); err != nil {
+			t.Fatalf("callback: %v", err)
+		}
+	}
+}
+
+func rowIds(batches [][]spoolRow) []int64 {
+	var ids []int64
+	for _, batch := range batches {
+		for _, row := range batch {
+			ids = append(ids, row.Id)
+		}
+	}
+	return ids
+}
+
+func activeSegmentPath(t *testing.T, dir string) string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "rows", "*"+segmentFileExt))
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("expected one segment, got %v: %v", paths, err)
+	}
+	return paths[0]
+}
+
+func appendToFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err = file.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func rawRecord(payload []byte, checksum uint32) []byte {
+	record := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record[:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], checksum)
+	return append(record, payload...)
+}
+
+func TestFileRepoRecoversTornTail(t *testing.T) {
+	payload := []byte("payload of a record torn by a crash")
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{name: "partial header", tail: []byte{1, 2, 3}},
+		{name: "partial payload", tail: rawRecord(payload, crc32.Checksum(payload, crcTable))[:recordHeaderSize+5]},
+		{name: "checksum mismatch", tail: rawRecord(payload, crc32.Checksum(payload, crcTable)+1)},
+		{name: "length past end", tail: rawRecord(payload, 0)[:recordHeaderSize]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			repo := NewFileRepo[spoolRow]("rows", &conf.SpoolCfg{Dir: dir, SegmentSizeMb: 1, FsyncPolicy: conf.FsyncAlways})
+			for id := int64(1); id <= 2; id++ {
+				if err := repo.Save(context.Background(), []spoolRow{{Id: id, Symbol: "BTCUSDT"}}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			repo.Close(context.Background())
+			segmentPath := activeSegmentPath(t, dir)
+			info, err := os.Stat(segmentPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			appendToFile(t, segmentPath, tt.tail)
+
+			repo = newTestFileRepo(t, dir)
+			if info2, _ := os.Stat(segmentPath); info2.Size() != info.Size() {
+				t.Fatalf("torn tail not truncated: size %d, want %d", info2.Size(), info.Size())
+			}
+			if err = repo.Save(context.Background(), []spoolRow{{Id: 3, Symbol: "BTCUSDT"}}); err != nil {
+				t.Fatal(err)
+			}
+			if ids := rowIds(drainFileRepo(t, repo)); !slices.Equal(ids, []int64{1, 2, 3}) {
+				t.Fatalf("replayed %v, want [1 2 3]", ids)
+			}
+		})
+	}
+}
+
+func TestFileRepoResumesFromCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewFileRepo[spoolRow]("rows", &conf.SpoolCfg{Dir: dir, SegmentSizeMb: 1, FsyncPolicy: conf.FsyncAlways})
+	for id := int64(1); id <= 3; id++ {
+		if err := repo.Save(context.Background(), []spoolRow{{Id: id}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err, callback := repo.GetWithDeleteCallback(context.Background())
+	if err != nil || len(data) != 1 || data[0].Id != 1 {
+		t.Fatalf("got %v, %v", data, err)
+	}
+	if err = callback(); err != nil {
+		t.Fatal(err)
+	}
+	// a batch returned without its callback being called is replayed again
+	if _, err, _ = repo.GetWithDeleteCallback(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	repo.Close(context.Background())
+
+	repo = newTestFileRepo(t, dir)
+	if ids := rowIds(drainFileRepo(t, repo)); !slices.Equal(ids, []int64{2, 3}) {
+		t.Fatalf("replayed %v, want [2 3]", ids)
+	}
+}
+
+func TestFileRepoSkipsUndecodableRecord(t *testing.T) {
+	dir := t.TempDir()
+	repo := NewFileRepo[spoolRow]("rows", &conf.SpoolCfg{Dir: dir, SegmentSizeMb: 1, FsyncPolicy: conf.FsyncAlways})
+	if err := repo.Save(context.Background(), []spoolRow{{Id: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	repo.Close(context.Background())
+	garbage := []byte("not a zstd frame")
+	appendToFile(t, activeSegmentPath(t, dir), rawRecord(garbage, crc32.Checksum(garbage, crcTable)))
+
+	repo = newTestFileRepo(t, dir)
+	if err := repo.Save(context.Background(), []spoolRow{{Id: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := rowIds(drainFileRepo(t, repo)); !slices.Equal(ids, []int64{1, 2}) {
+		t.Fatalf("replayed %v, want [1 2]", ids)
+	}
+}
+
+func TestFileRepoMigratesLegacyFiles(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		wantIds       []int64
+		wantCorrupted []string
+	}{
+		{
+			name:    "ordered by save time",
+			files:   map[string]string{"20_1_1": `[{"Id":3}]`, "3_7_7": `[{"Id":1},{"Id":2}]`},
+			wantIds: []int64{1, 2, 3},
+		},
+		{
+			name:          "undecodable file renamed",
+			files:         map[string]string{"1_1_1": `[{"Id":1}]`, "2_1_1": `{broken`},
+			wantIds:       []int64{1},
+			wantCorrupted: []string{"2_1_1" + corruptedFileExt},
+		},
+		{
+			name:    "empty batch dropped",
+			files:   map[string]string{"1_1_1": `[]`, "2_1_1": `[{"Id":2}]`},
+			wantIds: []int64{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, "rows"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, "rows", name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			repo := newTestFileRepo(t, dir)
+			if ids := rowIds(drainFileRepo(t, repo)); !slices.Equal(ids, tt.wantIds) {
+				t.Fatalf("replayed %v, want %v", ids, tt.wantIds)
+			}
+			for name := range tt.files {
+				if _, err := os.Stat(filepath.Join(dir, "rows", name)); !os.IsNotExist(err) {
+					t.Fatalf("legacy file %s left in place", name)
+				}
+			}
+			for _, name := range tt.wantCorrupted {
+				if _, err := os.Stat(filepath.Join(dir, "rows", name)); err != nil {
+					t.Fatalf("corrupted file %s: %v", name, err)
+				}
+			}
+		})
+	}
+}
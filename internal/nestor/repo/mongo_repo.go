@@ -52,7 +52,7 @@ func formDocuments[T any](entries []T) []any {
 }
 
 func (s LocalMongoRepo[T, TM]) GetWithDeleteCallback(ctx context.Context) ([]T, error, func() error) {
-	cur, err := s.collection.Find(ctx, bson.M{}, &options.FindOptions{Limit: &fixBatchSize})
+	cur, err := s.collection.Find(ctx, bson.M{}, options.Find().SetLimit(fixBatchSize).SetSort(bson.D{{Key: "_id", Value: 1}}))
 	if err != nil {
 		s.logger.Error(err.Error())
 		return nil, err, func() error { return nil }
@@ -0,0 +1,73 @@
+package shard
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// hashRing is a consistent hash ring with virtual nodes, so a leaving replica moves
+// only its own symbols and they spread over the remaining replicas.
+type hashRing struct {
+	points   []uint64
+	replicas map[uint64]string
+	size     int
+}
+
+func newHashRing(replicas []string, virtualNodes int) *hashRing {
+	ring := &hashRing{
+		replicas: make(map[uint64]string, len(replicas)*virtualNodes),
+		size:     len(replicas),
+	}
+	for _, replica := range replicas {
+		for i := 0; i < max(virtualNodes, 1); i++ {
+			point := hashKey(fmt.Sprintf("%s#%d", replica, i))
+			if _, ok := ring.replicas[point]; ok {
+				continue
+			}
+			ring.replicas[point] = replica
+			ring.points = append(ring.points, point)
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+	return ring
+}
+
+// owners returns up to replicationFactor distinct replicas met clockwise from the key.
+func (s *hashRing) owners(key string, replicationFactor int) []string {
+	if len(s.points) == 0 {
+		return nil
+	}
+	numOwners := min(max(replicationFactor, 1), s.size)
+	keyPoint := hashKey(key)
+	start := sort.Search(len(s.points), func(i int) bool { return s.points[i] >= keyPoint })
+	owners := make([]string, 0, numOwners)
+	for i := 0; i < len(s.points) && len(owners) < numOwners; i++ {
+		replica := s.replicas[s.points[(start+i)%len(s.points)]]
+		isNew := true
+		for _, owner := range owners {
+			if owner == replica {
+				isNew = false
+				break
+			}
+		}
+		if isNew {
+			owners = append(owners, replica)
+		}
+	}
+	return owners
+}
+
+// hashKey mixes fnv hash with the splitmix64 finalizer, plain fnv puts close keys
+// like virtual nodes of one replica close on the ring.
+func hashKey(key string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	point := hash.Sum64()
+	point ^= point >> 30
+	point *= 0xbf58476d1ce4e5b9
+	point ^= point >> 27
+	point *= 0x94d049bb133111eb
+	point ^= point >> 31
+	return point
+}
@@ -0,0 +1,255 @@
+package shard
+
+import (
+	"DeltaReceiver/internal/nestor/conf"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"go.uber.org/zap"
+)
+
+const retryPeriod = time.Second
+
+var errReplicaNodeOwned = errors.New("replica node is owned by another session")
+
+type Metrics interface {
+	SetLiveReplicas(int)
+	IncReassignments()
+}
+
+// ZkSharder registers the replica as an ephemeral node in ZooKeeper and assigns symbols
+// to the live replicas with a consistent hash ring. Every symbol is owned by
+// replicationFactor replicas. Until the first list of replicas is read, and when the
+// replica is alone, it owns all symbols.
+type ZkSharder struct {
+	logger            *zap.Logger
+	conn              *zk.Conn
+	groupPath         string
+	replicaId         string
+	replicationFactor int
+	virtualNodes      int
+	sessionTimeout    time.Duration
+	metrics           Metrics
+	mut               sync.RWMutex
+	replicas          []string
+	ring              *hashRing
+	subscribers       []chan struct{}
+	done              chan struct{}
+}
+
+func NewZkSharder(conn *zk.Conn, cfg *conf.ShardingCfg, metrics Metrics) *ZkSharder {
+	return &ZkSharder{
+		logger:            log.GetLogger(fmt.Sprintf("ZkSharder[%s]", cfg.Group)),
+		conn:              conn,
+		groupPath:         fmt.Sprintf("/nestor/replicas/%s", cfg.Group),
+		replicaId:         cfg.ReplicaId,
+		replicationFactor: cfg.ReplicationFactor,
+		virtualNodes:      cfg.VirtualNodes,
+		sessionTimeout:    time.Duration(cfg.ZkCfg.SessionTimeoutS) * time.Second,
+		metrics:           metrics,
+		done:              make(chan struct{}),
+	}
+}
+
+// Start registers the replica and reads the first assignment, so workers created right
+// after it already get their share of symbols.
+func (s *ZkSharder) Start(ctx context.Context) error {
+	if err := s.createGroupPath(); err != nil {
+		return err
+	}
+	if err := s.awaitRegistration(ctx); err != nil {
+		return err
+	}
+	eventCh, err := s.readReplicas()
+	if err != nil {
+		return err
+	}
+	go s.watchReplicas(ctx, eventCh)
+	return nil
+}
+
+// Stop removes the replica node so other replicas take its symbols without waiting
+// for the session timeout.
+func (s *ZkSharder) Stop() {
+	close(s.done)
+	_, stat, err := s.conn.Get(s.replicaPath())
+	if err == nil && stat.EphemeralOwner == s.conn.SessionID() {
+		err = s.conn.Delete(s.replicaPath(), stat.Version)
+	}
+	if err != nil && !errors.Is(err, zk.ErrNoNode) {
+		s.logger.Error(err.Error())
+	}
+}
+
+func (s *ZkSharder) Owns(symbol string) bool {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	if s.ring == nil {
+		return true
+	}
+	return slices.Contains(s.ring.owners(strings.ToUpper(symbol), s.replicationFactor), s.replicaId)
+}
+
+func (s *ZkSharder) Filter(symbols []string) []string {
+	var owned []string
+	for _, symbol := range symbols {
+		if s.Owns(symbol) {
+			owned = append(owned, symbol)
+		}
+	}
+	return owned
+}
+
+// Subscribe returns a channel notified when the assignment changes. Notifications are
+// coalesced.
+func (s *ZkSharder) Subscribe() <-chan struct{} {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	notifyCh := make(chan struct{}, 1)
+	s.subscribers = append(s.subscribers, notifyCh)
+	return notifyCh
+}
+
+func (s *ZkSharder) watchReplicas(ctx context.Context, eventCh <-chan zk.Event) {
+	for {
+		select {
+		case <-eventCh:
+		case <-ctx.Done():
+			return
+		case <-s.done:
+			return
+		}
+		for {
+			var err error
+			if eventCh, err = s.readReplicas(); err == nil {
+				break
+			}
+			s.logger.Error(err.Error())
+			select {
+			case <-time.After(retryPeriod):
+			case <-ctx.Done():
+				return
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// readReplicas sets a watch on the replicas list and updates the ring. The own node is
+// recreated if it has gone with an expired session.
+func (s *ZkSharder) readReplicas() (<-chan zk.Event, error) {
+	replicas, _, eventCh, err := s.conn.ChildrenW(s.groupPath)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(replicas, s.replicaId) {
+		s.logger.Warn("replica node is missing, register again")
+		if err := s.register(); err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, s.replicaId)
+	}
+	slices.Sort(replicas)
+	s.updateRing(replicas)
+	return eventCh, nil
+}
+
+func (s *ZkSharder) updateRing(replicas []string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.metrics.SetLiveReplicas(len(replicas))
+	if slices.Equal(s.replicas, replicas) {
+		return
+	}
+	s.logger.Info(fmt.Sprintf("live replicas changed to %v", replicas))
+	isFirstAssignment := s.replicas == nil
+	s.replicas = replicas
+	s.ring = newHashRing(replicas, s.virtualNodes)
+	if isFirstAssignment {
+		// workers are not created yet and will use this assignment
+		return
+	}
+	s.metrics.IncReassignments()
+	for _, notifyCh := range s.subscribers {
+		select {
+		case notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// awaitRegistration waits while the replica node is owned by another session. It is
+// the previous session of a restarted replica until the session expires, if the node
+// outlives the session timeout another live replica uses the same id.
+func (s *ZkSharder) awaitRegistration(ctx context.Context) error {
+	deadline := time.After(s.sessionTimeout + retryPeriod)
+	for {
+		err := s.register()
+		if !errors.Is(err, errReplicaNodeOwned) {
+			return err
+		}
+		s.logger.Warn(fmt.Sprintf("%s, waiting until the node expires", err.Error()))
+		exists, _, eventCh, err := s.conn.ExistsW(s.replicaPath())
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		select {
+		case <-eventCh:
+		case <-deadline:
+			return fmt.Errorf("replica id %s is used by another live replica", s.replicaId)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// register creates the replica node. The node of another session is never deleted as
+// it may belong to a live replica, errReplicaNodeOwned is returned instead.
+func (s *ZkSharder) register() error {
+	_, err := s.conn.Create(s.replicaPath(), []byte{}, zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
+	if errors.Is(err, zk.ErrNodeExists) {
+		_, stat, err := s.conn.Get(s.replicaPath())
+		if errors.Is(err, zk.ErrNoNode) {
+			return s.register()
+		}
+		if err != nil {
+			return err
+		}
+		if stat.EphemeralOwner == s.conn.SessionID() {
+			return nil
+		}
+		return fmt.Errorf("%w: node %s, session %x", errReplicaNodeOwned, s.replicaPath(), stat.EphemeralOwner)
+	}
+	if err != nil {
+		return err
+	}
+	s.logger.Info(fmt.Sprintf("replica registered at %s", s.replicaPath()))
+	return nil
+}
+
+func (s *ZkSharder) createGroupPath() error {
+	nodePath := ""
+	for _, subPath := range strings.Split(strings.TrimPrefix(s.groupPath, "/"), "/") {
+		nodePath += "/" + subPath
+		_, err := s.conn.Create(nodePath, []byte{}, zk.FlagPersistent, zk.WorldACL(zk.PermAll))
+		if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ZkSharder) replicaPath() string {
+	return s.groupPath + "/" + s.replicaId
+}
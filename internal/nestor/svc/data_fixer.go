@@ -1,8 +1,10 @@
 package svc
 
 import (
+	"DeltaReceiver/internal/nestor/conf"
 	"DeltaReceiver/pkg/log"
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,48 +13,114 @@ import (
 
 type DataFixer[T any] struct {
 	logger      *zap.Logger
-	mainStorage BatchedDataStorage[T]
+	mainStorage *StorageCircuit[T]
 	auxStorages []AuxBatchedDataStorage[T]
+	metrics     DataFixerMetrics
+	pollPeriod  time.Duration
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	done        chan struct{}
 }
 
-func NewDataFixer[T any](dataType string, mainStorage BatchedDataStorage[T], auxStorages []AuxBatchedDataStorage[T]) *DataFixer[T] {
+func NewDataFixer[T any](
+	dataType string,
+	mainStorage *StorageCircuit[T],
+	auxStorages []AuxBatchedDataStorage[T],
+	metrics DataFixerMetrics,
+	cfg *conf.DataFixerCfg,
+) *DataFixer[T] {
 	return &DataFixer[T]{
 		logger:      log.GetLogger(fmt.Sprintf("DataFixer[%s]", dataType)),
 		mainStorage: mainStorage,
 		auxStorages: auxStorages,
+		metrics:     metrics,
+		pollPeriod:  time.Duration(cfg.PollPeriodS) * time.Second,
+		minBackoff:  time.Duration(cfg.MinBackoffMs) * time.Millisecond,
+		maxBackoff:  time.Duration(cfg.MaxBackoffMs) * time.Millisecond,
+		done:        make(chan struct{}),
 	}
 }
 
-const SleepTimeMin = 5
-
-func (s *DataFixer[T]) Fix() {
-	ctx := context.Background()
+// Fix replays data from the auxiliary storages into the main storage until ctx is done.
+// While replay fails, it is retried with exponential backoff; once the main storage
+// accepts data again, replay starts immediately.
+func (s *DataFixer[T]) Fix(ctx context.Context) {
+	defer close(s.done)
+	backoff := s.minBackoff
 	for {
-		for i, storage := range s.auxStorages {
-			for {
-				data, err, callback := storage.GetWithDeleteCallback(ctx)
-				if err != nil {
-					s.logger.Error(fmt.Errorf("error while getting data: %w", err).Error())
-					break
-				}
-				if len(data) == 0 {
-					s.logger.Info(fmt.Sprintf("no data in storage %d", i))
-					break
-				}
-				err = s.mainStorage.Save(ctx, data)
-				if err != nil {
-					s.logger.Error(fmt.Errorf("error while sending data, %w", err).Error())
-					break
-				}
-				err = callback()
-				if err != nil {
-					s.logger.Error(fmt.Errorf("error while deleting data: %w", err).Error())
-					break
-				}
-				s.logger.Info(fmt.Sprintf("successfully fixed %d data rows", len(data)))
-
-			}
+		wait := s.pollPeriod
+		if err := s.fixOnce(ctx); err != nil {
+			wait = backoff
+			backoff = min(2*backoff, s.maxBackoff)
+			s.logger.Warn(fmt.Sprintf("replay failed, next replay in %s", wait))
+		} else {
+			backoff = s.minBackoff
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			s.logger.Info("stopped")
+			return
+		case <-s.mainStorage.Recovered():
+			timer.Stop()
+			s.logger.Info("main storage recovered, start replay")
+		case <-timer.C:
+		}
+	}
+}
+
+// fixOnce drains every auxiliary storage, a failure of one does not stop draining the
+// others. It returns errors of all failed storages.
+func (s *DataFixer[T]) fixOnce(ctx context.Context) error {
+	var errs []error
+	for i, storage := range s.auxStorages {
+		if err := s.drain(ctx, i, storage); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *DataFixer[T]) drain(ctx context.Context, storageNo int, storage AuxBatchedDataStorage[T]) error {
+	defer s.updateBacklog(storageNo, storage)
+	for ctx.Err() == nil {
+		data, err, callback := storage.GetWithDeleteCallback(ctx)
+		if err != nil {
+			s.metrics.IncReplayErr()
+			err = fmt.Errorf("error while getting data from storage %d: %w", storageNo, err)
+			s.logger.Error(err.Error())
+			return err
+		}
+		if len(data) == 0 {
+			s.logger.Debug(fmt.Sprintf("no data in storage %d", storageNo))
+			return nil
+		}
+		if err = s.mainStorage.Save(ctx, data); err != nil {
+			s.metrics.IncReplayErr()
+			s.logger.Error(fmt.Errorf("error while sending data, %w", err).Error())
+			return err
 		}
-		time.Sleep(SleepTimeMin*time.Minute)
+		if err = callback(); err != nil {
+			s.metrics.IncReplayErr()
+			err = fmt.Errorf("error while deleting data from storage %d: %w", storageNo, err)
+			s.logger.Error(err.Error())
+			return err
+		}
+		s.metrics.AddReplayedRows(len(data))
+		s.updateBacklog(storageNo, storage)
+		s.logger.Info(fmt.Sprintf("successfully fixed %d data rows", len(data)))
 	}
+	return nil
+}
+
+func (s *DataFixer[T]) updateBacklog(storageNo int, storage AuxBatchedDataStorage[T]) {
+	if sizer, ok := storage.(BacklogSizer); ok {
+		s.metrics.SetBacklogBytes(storageNo, sizer.BacklogBytes())
+	}
+}
+
+// Done is closed once Fix returns.
+func (s *DataFixer[T]) Done() <-chan struct{} {
+	return s.done
 }
@@ -55,12 +55,10 @@ func (s *ExchangeInfoSvc) StartReceiveExInfo(ctx context.Context) {
 			s.logger.Warn("error when getting exchange info, sleep")
 			continue
 		}
-		ctxWithTimeout, cancel = context.WithTimeout(context.Background(), 20*time.Second)
-		lastSavedExInfo := s.dataStorages[0].(ExchangeInfoStorage).GetLastExchangeInfo(ctxWithTimeout)
-		cancel()
 		newExInfo := model.NewExchangeInfo(exInfo)
+		lastSavedExInfo := s.getLastSavedExInfo()
 		if lastSavedExInfo == nil {
-			s.logger.Error("error while receiving last saved exchange info from clickhouse")
+			s.logger.Error("error while receiving last saved exchange info from main storage")
 		} else if !model.EqualsExchangeInfos(lastSavedExInfo, newExInfo) {
 			s.logger.Info("exchange info changed, attempt to send")
 			if err = s.saveExInfo(ctx, []model.ExchangeInfo{*model.NewExchangeInfo(exInfo)}); err != nil {
@@ -72,6 +70,26 @@ func (s *ExchangeInfoSvc) StartReceiveExInfo(ctx context.Context) {
 	}
 }
 
+func (s *ExchangeInfoSvc) getLastSavedExInfo() *model.ExchangeInfo {
+	mainStorage := s.dataStorages[0]
+	if circuit, ok := mainStorage.(*StorageCircuit[model.ExchangeInfo]); ok {
+		mainStorage = circuit.Unwrap()
+	}
+	getter, ok := mainStorage.(LastExchangeInfoGetter)
+	if !ok {
+		// main storage can not be queried, so every received exchange info is saved
+		return &model.ExchangeInfo{}
+	}
+	ctxWithTimeout, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	return getter.GetLastExchangeInfo(ctxWithTimeout)
+}
+
+// SaveExInfo saves the exchange info to the first storage of the chain that accepts it.
+func (s *ExchangeInfoSvc) SaveExInfo(ctx context.Context, exInfo bmodel.ExInfo) error {
+	return s.saveExInfo(ctx, []model.ExchangeInfo{*model.NewExchangeInfo(exInfo)})
+}
+
 func (s *ExchangeInfoSvc) saveExInfo(ctx context.Context, exInfo []model.ExchangeInfo) error {
 	for i, storage := range s.dataStorages {
 		for j := 0; j < 3; j++ {
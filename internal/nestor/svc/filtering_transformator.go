@@ -0,0 +1,33 @@
+package svc
+
+import (
+	cmodel "DeltaReceiver/internal/common/model"
+)
+
+// FilteringTransformator drops rows of symbols not assigned to this replica. It is used
+// for streams which can not be subscribed per symbol.
+type FilteringTransformator[TFrom any, TTo cmodel.BinanceDataRow] struct {
+	transformator DataTransformator[TFrom, TTo]
+	symbolsFilter SymbolsFilter
+}
+
+func NewFilteringTransformator[TFrom any, TTo cmodel.BinanceDataRow](transformator DataTransformator[TFrom, TTo], symbolsFilter SymbolsFilter) *FilteringTransformator[TFrom, TTo] {
+	return &FilteringTransformator[TFrom, TTo]{
+		transformator: transformator,
+		symbolsFilter: symbolsFilter,
+	}
+}
+
+func (s *FilteringTransformator[TFrom, TTo]) Transform(msg TFrom) ([]TTo, error) {
+	data, err := s.transformator.Transform(msg)
+	if err != nil {
+		return data, err
+	}
+	owned := make([]TTo, 0, len(data))
+	for _, row := range data {
+		if s.symbolsFilter.Owns(row.GetSymbol()) {
+			owned = append(owned, row)
+		}
+	}
+	return owned, nil
+}
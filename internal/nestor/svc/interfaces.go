@@ -4,6 +4,7 @@ import (
 	"DeltaReceiver/internal/common/model"
 	bmodel "DeltaReceiver/pkg/binance/model"
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -21,6 +22,12 @@ type TradingSymbolsWorkerProvider[T any] interface {
 	GetNewWorkers(context.Context, []string) *T
 }
 
+// SymbolsFilter leaves the symbols assigned to this replica.
+type SymbolsFilter interface {
+	Filter([]string) []string
+	Owns(string) bool
+}
+
 type DataReceiver[T any] interface {
 	ConnectWs(context.Context) error
 	Recv(context.Context) (T, error)
@@ -35,6 +42,11 @@ type BatchedDataStorage[T any] interface {
 	Save(context.Context, []T) error
 }
 
+// DataPublisher hands rows to in-process consumers. Publish must not block.
+type DataPublisher[T any] interface {
+	Publish([]T)
+}
+
 type AuxBatchedDataStorage[T any] interface {
 	GetWithDeleteCallback(context.Context) ([]T, error, func() error)
 }
@@ -44,6 +56,10 @@ type WsDataPipelineMetrics[T any] interface {
 	IncEndedSaveGoroutines()
 	ProcessDataMetrics([]T, TypeOfEvent)
 	IncRecvErr()
+	IncQueueDepth()
+	DecQueueDepth()
+	IncQueueOverflow()
+	ObserveQueueBlockedTime(time.Duration)
 }
 
 type DeltaStorage interface {
@@ -59,6 +75,10 @@ type ExchangeInfoStorage interface {
 	Disconnect(ctx context.Context)
 }
 
+type LastExchangeInfoGetter interface {
+	GetLastExchangeInfo(context.Context) *model.ExchangeInfo
+}
+
 type LocalRepo interface {
 	SaveDeltas(context.Context, []model.Delta) error
 	SaveSnapshot(context.Context, []model.DepthSnapshotPart) error
@@ -75,6 +95,8 @@ const (
 	Receive TypeOfEvent = iota
 	Send
 	Save
+	Spool
+	Fail
 )
 
 type MetricsHolder interface {
@@ -84,7 +106,33 @@ type MetricsHolder interface {
 }
 
 type Fixer interface {
-	Fix()
+	Fix(context.Context)
+	Done() <-chan struct{}
+}
+
+type DataFixerMetrics interface {
+	SetBacklogBytes(storageNo int, backlogBytes int64)
+	AddReplayedRows(int)
+	IncReplayErr()
+}
+
+type ServerTimeClient interface {
+	GetServerTime(context.Context) (int64, error)
+}
+
+type ServerClockMetrics interface {
+	SetOffsetMs(int64)
+	SetRttMs(int64)
+	SetSkewAlarm(bool)
+	IncSyncErr()
+}
+
+type ReceiveTimeCorrector interface {
+	CorrectMs(localTimestampMs int64) int64
+}
+
+type BacklogSizer interface {
+	BacklogBytes() int64
 }
 
 type DeltaHolesStorage interface {
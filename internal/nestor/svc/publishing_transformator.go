@@ -0,0 +1,23 @@
+package svc
+
+// PublishingTransformator passes transformed rows to the publisher before they are
+// batched for the storages, so live consumers do not wait for the batch flush.
+type PublishingTransformator[TFrom, TTo any] struct {
+	transformator DataTransformator[TFrom, TTo]
+	publisher     DataPublisher[TTo]
+}
+
+func NewPublishingTransformator[TFrom, TTo any](transformator DataTransformator[TFrom, TTo], publisher DataPublisher[TTo]) *PublishingTransformator[TFrom, TTo] {
+	return &PublishingTransformator[TFrom, TTo]{
+		transformator: transformator,
+		publisher:     publisher,
+	}
+}
+
+func (s *PublishingTransformator[TFrom, TTo]) Transform(msg TFrom) ([]TTo, error) {
+	data, err := s.transformator.Transform(msg)
+	if err == nil && len(data) > 0 {
+		s.publisher.Publish(data)
+	}
+	return data, err
+}
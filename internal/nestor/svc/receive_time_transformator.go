@@ -0,0 +1,27 @@
+package svc
+
+import (
+	bmodel "DeltaReceiver/pkg/binance/model"
+)
+
+// ReceiveTimeTransformator moves the local receive timestamp of book ticks to the exchange
+// clock, so ticks are comparable with the event times of other streams.
+type ReceiveTimeTransformator[TFrom any] struct {
+	transformator DataTransformator[TFrom, bmodel.SymbolTick]
+	clock         ReceiveTimeCorrector
+}
+
+func NewReceiveTimeTransformator[TFrom any](transformator DataTransformator[TFrom, bmodel.SymbolTick], clock ReceiveTimeCorrector) *ReceiveTimeTransformator[TFrom] {
+	return &ReceiveTimeTransformator[TFrom]{
+		transformator: transformator,
+		clock:         clock,
+	}
+}
+
+func (s *ReceiveTimeTransformator[TFrom]) Transform(msg TFrom) ([]bmodel.SymbolTick, error) {
+	data, err := s.transformator.Transform(msg)
+	for i := range data {
+		data[i].Timestamp = s.clock.CorrectMs(data[i].Timestamp)
+	}
+	return data, err
+}
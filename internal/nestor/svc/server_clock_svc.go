@@ -0,0 +1,116 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/nestor/conf"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ServerClockSvc periodically estimates the offset of the exchange clock relative to the
+// local one. Each sync takes several samples and keeps the one with the smallest RTT,
+// assuming the server stamped its time in the middle of the round trip.
+type ServerClockSvc struct {
+	logger         *zap.Logger
+	client         ServerTimeClient
+	metrics        ServerClockMetrics
+	syncPeriod     time.Duration
+	samplesPerSync int
+	skewAlarm      time.Duration
+	offsetMs       atomic.Int64
+	synced         atomic.Bool
+	done           chan struct{}
+}
+
+func NewServerClockSvc(marketType bmodel.DataType, client ServerTimeClient, metrics ServerClockMetrics, cfg *conf.ServerClockCfg) *ServerClockSvc {
+	return &ServerClockSvc{
+		logger:         log.GetLogger(fmt.Sprintf("ServerClockSvc[%s]", marketType)),
+		client:         client,
+		metrics:        metrics,
+		syncPeriod:     time.Duration(cfg.SyncPeriodS) * time.Second,
+		samplesPerSync: cfg.SamplesPerSync,
+		skewAlarm:      time.Duration(cfg.SkewAlarmMs) * time.Millisecond,
+		done:           make(chan struct{}),
+	}
+}
+
+func (s *ServerClockSvc) Start(ctx context.Context) {
+	defer close(s.done)
+	for {
+		s.sync(ctx)
+		timer := time.NewTimer(s.syncPeriod)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *ServerClockSvc) Done() <-chan struct{} {
+	return s.done
+}
+
+// Offset is server time minus local time. It is zero until the first successful sync.
+func (s *ServerClockSvc) Offset() time.Duration {
+	return time.Duration(s.offsetMs.Load()) * time.Millisecond
+}
+
+// Now returns the local time corrected by the last measured offset.
+func (s *ServerClockSvc) Now() time.Time {
+	return time.Now().Add(s.Offset())
+}
+
+// CorrectMs shifts a timestamp taken by the local clock to the exchange clock.
+func (s *ServerClockSvc) CorrectMs(localTimestampMs int64) int64 {
+	return localTimestampMs + s.offsetMs.Load()
+}
+
+func (s *ServerClockSvc) sync(ctx context.Context) {
+	bestRtt := time.Duration(-1)
+	var bestOffset time.Duration
+	for i := 0; i < s.samplesPerSync; i++ {
+		offset, rtt, err := s.sample(ctx)
+		if err != nil {
+			s.metrics.IncSyncErr()
+			s.logger.Warn(err.Error())
+			continue
+		}
+		if bestRtt < 0 || rtt < bestRtt {
+			bestRtt, bestOffset = rtt, offset
+		}
+	}
+	if bestRtt < 0 {
+		return
+	}
+	s.offsetMs.Store(bestOffset.Milliseconds())
+	s.metrics.SetOffsetMs(bestOffset.Milliseconds())
+	s.metrics.SetRttMs(bestRtt.Milliseconds())
+	if !s.synced.Swap(true) {
+		s.logger.Info(fmt.Sprintf("clock synced, offset %s, rtt %s", bestOffset, bestRtt))
+	}
+	skewed := bestOffset > s.skewAlarm || -bestOffset > s.skewAlarm
+	s.metrics.SetSkewAlarm(skewed)
+	if skewed {
+		s.logger.Error(fmt.Sprintf("clock skew %s exceeds %s (rtt %s)", bestOffset, s.skewAlarm, bestRtt))
+	}
+}
+
+func (s *ServerClockSvc) sample(ctx context.Context) (time.Duration, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	sentAt := time.Now()
+	serverTimeMs, err := s.client.GetServerTime(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	rtt := time.Since(sentAt)
+	midpoint := sentAt.Add(rtt / 2)
+	return time.UnixMilli(serverTimeMs).Sub(midpoint), rtt, nil
+}
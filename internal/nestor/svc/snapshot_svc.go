@@ -19,19 +19,34 @@ type SnapshotSvc struct {
 	snapshotQueue     []string
 	snapshotSchedules map[string]time.Time
 	dataStorages      []BatchedDataStorage[model.DepthSnapshotPart]
+	publisher         DataPublisher[model.DepthSnapshotPart]
+	resyncCh          <-chan string
+	symbolsFilter     SymbolsFilter
 	shutdown          *atomic.Bool
 	done              chan struct{}
 	exInfoCache       *cache.ExchangeInfoCache
 	snapshotDepth     int
 }
 
-func NewSnapshotSvc(dataType string, snapshotDepth int, binanceClient BinanceClient, dataStorages []BatchedDataStorage[model.DepthSnapshotPart], infoCache *cache.ExchangeInfoCache) *SnapshotSvc {
+func NewSnapshotSvc(
+	dataType string,
+	snapshotDepth int,
+	binanceClient BinanceClient,
+	dataStorages []BatchedDataStorage[model.DepthSnapshotPart],
+	publisher DataPublisher[model.DepthSnapshotPart],
+	resyncCh <-chan string,
+	symbolsFilter SymbolsFilter,
+	infoCache *cache.ExchangeInfoCache,
+) *SnapshotSvc {
 	var shutdown atomic.Bool
 	shutdown.Store(false)
 	return &SnapshotSvc{
 		logger:            log.GetLogger(fmt.Sprintf("SnapshotSvc[%s]", dataType)),
 		binanceClient:     binanceClient,
 		dataStorages:      dataStorages,
+		publisher:         publisher,
+		resyncCh:          resyncCh,
+		symbolsFilter:     symbolsFilter,
 		snapshotSchedules: make(map[string]time.Time),
 		shutdown:          &shutdown,
 		done:              make(chan struct{}),
@@ -43,7 +58,11 @@ func NewSnapshotSvc(dataType string, snapshotDepth int, binanceClient BinanceCli
 func (s *SnapshotSvc) StartReceiveAndSaveSnapshots(ctx context.Context) {
 	for {
 		s.snapshotQueue = nil
+		s.unscheduleResyncRequests()
 		tradingSymbols := s.exInfoCache.GetTradingSymbols()
+		if s.symbolsFilter != nil {
+			tradingSymbols = s.symbolsFilter.Filter(tradingSymbols)
+		}
 		curTime := time.Now()
 		s.logger.Info(fmt.Sprintf("start updating scheduling map, %d snapshots scheduled now", len(s.snapshotSchedules)))
 		for _, symbol := range tradingSymbols {
@@ -57,7 +76,7 @@ func (s *SnapshotSvc) StartReceiveAndSaveSnapshots(ctx context.Context) {
 		}
 		s.logger.Info(fmt.Sprintf("end updating scheduling map, %d snapshots scheduled now", len(s.snapshotSchedules)))
 		if len(s.snapshotQueue) == 0 {
-			time.Sleep(10 * time.Minute)
+			s.waitResyncRequest(10 * time.Minute)
 			continue
 		}
 		s.logger.Info(fmt.Sprintf("start of getting %d snapshots", len(s.snapshotQueue)))
@@ -78,6 +97,29 @@ func (s *SnapshotSvc) StartReceiveAndSaveSnapshots(ctx context.Context) {
 	}
 }
 
+// unscheduleResyncRequests drops schedules of symbols whose books lost sync, so their
+// snapshots are taken in this round.
+func (s *SnapshotSvc) unscheduleResyncRequests() {
+	for {
+		select {
+		case symbol := <-s.resyncCh:
+			delete(s.snapshotSchedules, symbol)
+		default:
+			return
+		}
+	}
+}
+
+func (s *SnapshotSvc) waitResyncRequest(timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case symbol := <-s.resyncCh:
+		delete(s.snapshotSchedules, symbol)
+	case <-timer.C:
+	}
+}
+
 func (s *SnapshotSvc) ReceiveAndSaveSnapshot(ctx context.Context, symbol string) (string, error) {
 	snapshot, limit, err := s.binanceClient.GetFullSnapshot(ctx, symbol, s.snapshotDepth)
 	defer func(err error) {
@@ -98,6 +140,9 @@ func (s *SnapshotSvc) ReceiveAndSaveSnapshot(ctx context.Context, symbol string)
 		s.logger.Warn("empty snapshot")
 		return limit, nil
 	}
+	if s.publisher != nil {
+		s.publisher.Publish(snapshot)
+	}
 	return limit, s.saveSnapshot(ctx, snapshot)
 }
 
@@ -0,0 +1,51 @@
+package svc
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// StorageCircuit tracks whether the wrapped storage is failing and signals when
+// the first save after a failure succeeds.
+type StorageCircuit[T any] struct {
+	storage     BatchedDataStorage[T]
+	open        *atomic.Bool
+	recoveredCh chan struct{}
+}
+
+func NewStorageCircuit[T any](storage BatchedDataStorage[T]) *StorageCircuit[T] {
+	var open atomic.Bool
+	open.Store(false)
+	return &StorageCircuit[T]{
+		storage:     storage,
+		open:        &open,
+		recoveredCh: make(chan struct{}, 1),
+	}
+}
+
+func (s *StorageCircuit[T]) Save(ctx context.Context, batch []T) error {
+	if err := s.storage.Save(ctx, batch); err != nil {
+		s.open.Store(true)
+		return err
+	}
+	if s.open.Swap(false) {
+		select {
+		case s.recoveredCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *StorageCircuit[T]) IsOpen() bool {
+	return s.open.Load()
+}
+
+func (s *StorageCircuit[T]) Recovered() <-chan struct{} {
+	return s.recoveredCh
+}
+
+// Unwrap returns the wrapped storage for callers that need more than Save.
+func (s *StorageCircuit[T]) Unwrap() BatchedDataStorage[T] {
+	return s.storage
+}
@@ -0,0 +1,173 @@
+package svc
+
+import (
+	"DeltaReceiver/pkg/binance"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// TradesSvc receives aggregate trades and hands them to the publisher only, trades are
+// not stored. Like WsSvc it recreates the workers every reconnect period and when
+// symbols are reassigned between replicas.
+type TradesSvc struct {
+	logger          *zap.Logger
+	workersProvider WsDataWorkersProvider[TradesWorker]
+	workers         []*TradesWorker
+	reconnectPeriod time.Duration
+	reassignCh      <-chan struct{}
+	shutdown        *atomic.Bool
+}
+
+func NewTradesSvc(dataType string, workersProvider WsDataWorkersProvider[TradesWorker], reconnectPeriod time.Duration, reassignCh <-chan struct{}) *TradesSvc {
+	var shutdown atomic.Bool
+	shutdown.Store(false)
+	return &TradesSvc{
+		logger:          log.GetLogger(fmt.Sprintf("TradesSvc[%s]", dataType)),
+		workersProvider: workersProvider,
+		reconnectPeriod: reconnectPeriod,
+		reassignCh:      reassignCh,
+		shutdown:        &shutdown,
+	}
+}
+
+func (s *TradesSvc) Start(ctx context.Context) {
+	s.workers = s.getAndActivateNewWorkers(ctx)
+	for {
+		timer := time.NewTimer(s.reconnectPeriod)
+		select {
+		case <-timer.C:
+		case <-s.reassignCh:
+			timer.Stop()
+			s.logger.Info("symbols reassigned, recreate workers")
+		}
+		newWorkers := s.getAndActivateNewWorkers(ctx)
+		oldWorkers := s.workers
+		s.workers = newWorkers
+		for _, worker := range oldWorkers {
+			worker.Shutdown()
+		}
+	}
+}
+
+func (s *TradesSvc) getAndActivateNewWorkers(ctx context.Context) []*TradesWorker {
+	if s.shutdown.Load() {
+		return nil
+	}
+	newWorkers := s.workersProvider.GetNewWorkers(ctx)
+	for _, worker := range newWorkers {
+		for k := 0; k < 3; k++ {
+			if err := worker.Start(ctx); err == nil {
+				break
+			} else {
+				s.logger.Error(err.Error())
+			}
+		}
+	}
+	return newWorkers
+}
+
+func (s *TradesSvc) Shutdown(ctx context.Context) {
+	s.shutdown.Store(true)
+	var wg sync.WaitGroup
+	wg.Add(len(s.workers))
+	for _, worker := range s.workers {
+		go func() {
+			defer wg.Done()
+			worker.Shutdown()
+		}()
+	}
+	wg.Wait()
+	s.logger.Info("successfully shutdown")
+}
+
+// TradesWorker publishes trades of one websocket connection as they are received.
+type TradesWorker struct {
+	logger    *zap.Logger
+	receiver  DataReceiver[bmodel.AggTrade]
+	publisher DataPublisher[bmodel.AggTrade]
+	started   *atomic.Bool
+	shutdown  *atomic.Bool
+	done      chan struct{}
+}
+
+func NewTradesWorker(dataType string, receiver DataReceiver[bmodel.AggTrade], publisher DataPublisher[bmodel.AggTrade]) *TradesWorker {
+	var started, shutdown atomic.Bool
+	started.Store(false)
+	shutdown.Store(false)
+	return &TradesWorker{
+		logger:    log.GetLogger(fmt.Sprintf("TradesWorker[%s]", dataType)),
+		receiver:  receiver,
+		publisher: publisher,
+		started:   &started,
+		shutdown:  &shutdown,
+		done:      make(chan struct{}),
+	}
+}
+
+func (s *TradesWorker) Start(ctx context.Context) error {
+	ctxWithTimeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.receiver.ConnectWs(ctxWithTimeout); err != nil {
+		return fmt.Errorf("%w", err)
+	}
+	s.started.Store(true)
+	go s.run(ctx)
+	return nil
+}
+
+// run stops on shutdown, after which the receiver returns empty trades.
+func (s *TradesWorker) run(ctx context.Context) {
+	defer close(s.done)
+	for !s.shutdown.Load() {
+		trade, err := s.receiver.Recv(ctx)
+		if err != nil {
+			s.logger.Error(fmt.Errorf("trade receiving error %w", err).Error())
+			continue
+		}
+		if trade.Symbol != "" {
+			s.publisher.Publish([]bmodel.AggTrade{trade})
+		}
+	}
+}
+
+// Shutdown closes the connection and waits for the worker to stop, it is a no-op for a
+// worker which failed to start.
+func (s *TradesWorker) Shutdown() {
+	if s.shutdown.Swap(true) {
+		return
+	}
+	s.receiver.Shutdown(context.Background())
+	if !s.started.Load() {
+		return
+	}
+	select {
+	case <-s.done:
+	case <-time.After(10 * time.Second):
+		s.logger.Warn("worker not stopped in time")
+	}
+}
+
+type TradesWorkerProvider struct {
+	cfg       *binance.BinanceHttpClientConfig
+	dataType  string
+	publisher DataPublisher[bmodel.AggTrade]
+}
+
+func NewTradesWorkerProvider(cfg *binance.BinanceHttpClientConfig, dataType string, publisher DataPublisher[bmodel.AggTrade]) *TradesWorkerProvider {
+	return &TradesWorkerProvider{
+		cfg:       cfg,
+		dataType:  dataType,
+		publisher: publisher,
+	}
+}
+
+func (s TradesWorkerProvider) GetNewWorkers(ctx context.Context, symbols []string) *TradesWorker {
+	return NewTradesWorker(s.dataType, binance.NewAggTradeClient(s.cfg, symbols), s.publisher)
+}
@@ -15,20 +15,32 @@ type TradingSymbolsWorkersProvider[T any] struct {
 	numWorkers     int
 	workerProvider TradingSymbolsWorkerProvider[T]
 	exInfoCache    *cache.ExchangeInfoCache
+	symbolsFilter  SymbolsFilter
 }
 
-func NewTradingSymbolsWorkersProvider[T any](workersProviderType string, numWorkers int, workerProvider TradingSymbolsWorkerProvider[T], exInfoCache *cache.ExchangeInfoCache) *TradingSymbolsWorkersProvider[T] {
+func NewTradingSymbolsWorkersProvider[T any](
+	workersProviderType string,
+	numWorkers int,
+	workerProvider TradingSymbolsWorkerProvider[T],
+	exInfoCache *cache.ExchangeInfoCache,
+	symbolsFilter SymbolsFilter,
+) *TradingSymbolsWorkersProvider[T] {
 	return &TradingSymbolsWorkersProvider[T]{
 		logger:         log.GetLogger(fmt.Sprintf("TradingSymbolsWorkersProvider[%s]", workersProviderType)),
 		numWorkers:     numWorkers,
 		workerProvider: workerProvider,
 		exInfoCache:    exInfoCache,
+		symbolsFilter:  symbolsFilter,
 	}
 }
 
 func (s *TradingSymbolsWorkersProvider[T]) GetNewWorkers(ctx context.Context) []*T {
 	var symbols []string
-	for _, symbolInfo := range s.exInfoCache.GetTradingSymbols() {
+	tradingSymbols := s.exInfoCache.GetTradingSymbols()
+	if s.symbolsFilter != nil {
+		tradingSymbols = s.symbolsFilter.Filter(tradingSymbols)
+	}
+	for _, symbolInfo := range tradingSymbols {
 		symbols = append(symbols, strings.ToLower(symbolInfo))
 	}
 	s.logger.Info(fmt.Sprintf("start construct workers of %d different symbols", len(symbols)))
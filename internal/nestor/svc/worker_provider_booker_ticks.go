@@ -1,6 +1,7 @@
 package svc
 
 import (
+	"DeltaReceiver/internal/nestor/conf"
 	"DeltaReceiver/pkg/binance"
 	bmodel "DeltaReceiver/pkg/binance/model"
 	"context"
@@ -11,8 +12,9 @@ type BookTicksWorkerProvider struct {
 	dataType         string
 	marketType       bmodel.DataType
 	dataTrasformator DataTransformator[bmodel.SymbolTick, bmodel.SymbolTick]
-	batchSize        int
+	pipelineCfg      *conf.WsPipelineCfg
 	dataStorages     []BatchedDataStorage[bmodel.SymbolTick]
+	spoolStorage     BatchedDataStorage[bmodel.SymbolTick]
 	metrics          WsDataPipelineMetrics[bmodel.SymbolTick]
 }
 
@@ -21,8 +23,9 @@ func NewBookTicksWorkerProvider(
 	dataType string,
 	marketType bmodel.DataType,
 	dataTrasformator DataTransformator[bmodel.SymbolTick, bmodel.SymbolTick],
-	batchSize int,
+	pipelineCfg *conf.WsPipelineCfg,
 	dataStorages []BatchedDataStorage[bmodel.SymbolTick],
+	spoolStorage BatchedDataStorage[bmodel.SymbolTick],
 	metrics WsDataPipelineMetrics[bmodel.SymbolTick],
 ) *BookTicksWorkerProvider {
 	return &BookTicksWorkerProvider{
@@ -30,13 +33,14 @@ func NewBookTicksWorkerProvider(
 		dataType:         dataType,
 		marketType:       marketType,
 		dataTrasformator: dataTrasformator,
-		batchSize:        batchSize,
+		pipelineCfg:      pipelineCfg,
 		dataStorages:     dataStorages,
+		spoolStorage:     spoolStorage,
 		metrics:          metrics,
 	}
 }
 
 func (s BookTicksWorkerProvider) GetNewWorkers(ctx context.Context, symbols []string) *WsDataProcessWorker[bmodel.SymbolTick, bmodel.SymbolTick] {
 	ticksReceiver := binance.NewBookTickerClient(s.cfg, symbols)
-	return NewWsDataProcessWorker(s.dataType, ticksReceiver, s.dataTrasformator, s.batchSize, s.dataStorages, s.metrics)
+	return NewWsDataProcessWorker(s.dataType, ticksReceiver, s.dataTrasformator, s.pipelineCfg, s.dataStorages, s.spoolStorage, s.metrics)
 }
@@ -2,6 +2,7 @@ package svc
 
 import (
 	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/nestor/conf"
 	"DeltaReceiver/pkg/binance"
 	bmodel "DeltaReceiver/pkg/binance/model"
 	"context"
@@ -12,8 +13,9 @@ type DeltaWorkerProvider struct {
 	dataType         string
 	marketType       bmodel.DataType
 	dataTrasformator DataTransformator[bmodel.DeltaMessage, model.Delta]
-	batchSize        int
+	pipelineCfg      *conf.WsPipelineCfg
 	dataStorages     []BatchedDataStorage[model.Delta]
+	spoolStorage     BatchedDataStorage[model.Delta]
 	metrics          WsDataPipelineMetrics[model.Delta]
 }
 
@@ -22,8 +24,9 @@ func NewDeltaWorkerProvider(
 	dataType string,
 	marketType bmodel.DataType,
 	dataTrasformator DataTransformator[bmodel.DeltaMessage, model.Delta],
-	batchSize int,
+	pipelineCfg *conf.WsPipelineCfg,
 	dataStorages []BatchedDataStorage[model.Delta],
+	spoolStorage BatchedDataStorage[model.Delta],
 	metrics WsDataPipelineMetrics[model.Delta],
 ) *DeltaWorkerProvider {
 	return &DeltaWorkerProvider{
@@ -31,13 +34,14 @@ func NewDeltaWorkerProvider(
 		dataType:         dataType,
 		marketType:       marketType,
 		dataTrasformator: dataTrasformator,
-		batchSize:        batchSize,
+		pipelineCfg:      pipelineCfg,
 		dataStorages:     dataStorages,
+		spoolStorage:     spoolStorage,
 		metrics:          metrics,
 	}
 }
 
 func (s DeltaWorkerProvider) GetNewWorkers(ctx context.Context, symbols []string) *WsDataProcessWorker[bmodel.DeltaMessage, model.Delta] {
 	deltaReceiver := binance.NewDeltaReceiveClient(s.cfg, symbols)
-	return NewWsDataProcessWorker[bmodel.DeltaMessage, model.Delta](s.dataType, deltaReceiver, s.dataTrasformator, s.batchSize, s.dataStorages, s.metrics)
+	return NewWsDataProcessWorker[bmodel.DeltaMessage, model.Delta](s.dataType, deltaReceiver, s.dataTrasformator, s.pipelineCfg, s.dataStorages, s.spoolStorage, s.metrics)
 }
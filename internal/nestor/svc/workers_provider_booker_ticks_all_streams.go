@@ -1,6 +1,7 @@
 package svc
 
 import (
+	"DeltaReceiver/internal/nestor/conf"
 	"DeltaReceiver/pkg/binance"
 	bmodel "DeltaReceiver/pkg/binance/model"
 	"context"
@@ -10,8 +11,9 @@ type BookTicksAllStreamsWorkerProvider struct {
 	cfg              *binance.BinanceHttpClientConfig
 	dataType         string
 	dataTrasformator DataTransformator[bmodel.SymbolTick, bmodel.SymbolTick]
-	batchSize        int
+	pipelineCfg      *conf.WsPipelineCfg
 	dataStorages     []BatchedDataStorage[bmodel.SymbolTick]
+	spoolStorage     BatchedDataStorage[bmodel.SymbolTick]
 	metrics          WsDataPipelineMetrics[bmodel.SymbolTick]
 }
 
@@ -19,16 +21,18 @@ func NewBookTicksAllStreamsWorkerProvider(
 	cfg *binance.BinanceHttpClientConfig,
 	dataType string,
 	dataTrasformator DataTransformator[bmodel.SymbolTick, bmodel.SymbolTick],
-	batchSize int,
+	pipelineCfg *conf.WsPipelineCfg,
 	dataStorages []BatchedDataStorage[bmodel.SymbolTick],
+	spoolStorage BatchedDataStorage[bmodel.SymbolTick],
 	metrics WsDataPipelineMetrics[bmodel.SymbolTick],
 ) *BookTicksAllStreamsWorkerProvider {
 	return &BookTicksAllStreamsWorkerProvider{
 		cfg:              cfg,
 		dataType:         dataType,
 		dataTrasformator: dataTrasformator,
-		batchSize:        batchSize,
+		pipelineCfg:      pipelineCfg,
 		dataStorages:     dataStorages,
+		spoolStorage:     spoolStorage,
 		metrics:          metrics,
 	}
 }
@@ -38,8 +42,9 @@ func (s *BookTicksAllStreamsWorkerProvider) GetNewWorkers(ctx context.Context) [
 		s.dataType,
 		binance.NewBookTickerClient(s.cfg, []string{}),
 		s.dataTrasformator,
-		s.batchSize,
+		s.pipelineCfg,
 		s.dataStorages,
+		s.spoolStorage,
 		s.metrics,
 	)}
 }
@@ -1,11 +1,13 @@
 package svc
 
 import (
+	"DeltaReceiver/internal/nestor/conf"
 	"DeltaReceiver/pkg/log"
 	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -16,35 +18,59 @@ var (
 )
 
 type WsDataProcessWorker[TRecv any, TResp any] struct {
-	logger              *zap.Logger
-	dataReceiver        DataReceiver[TRecv]
-	dataTrasformator    DataTransformator[TRecv, TResp]
-	batchSize           int
-	dataStorages        []BatchedDataStorage[TResp]
-	metrics             WsDataPipelineMetrics[TResp]
-	saveDataWg          sync.WaitGroup
-	shutdownCh          chan struct{}
-	shutdownCompletedCh chan struct{}
+	logger           *zap.Logger
+	dataReceiver     DataReceiver[TRecv]
+	dataTrasformator DataTransformator[TRecv, TResp]
+	batchSize        int
+	maxLinger        time.Duration
+	numWriters       int
+	queueMaxBlock    time.Duration
+	saveQueue        chan []TResp
+	dataStorages     []BatchedDataStorage[TResp]
+	spoolStorage     BatchedDataStorage[TResp]
+	metrics          WsDataPipelineMetrics[TResp]
+	saveDataWg       sync.WaitGroup
+	started          *atomic.Bool
+	shutdown         *atomic.Bool
+	shutdownOnce     sync.Once
+	shutdownCh       chan struct{}
+	runCompletedCh   chan struct{}
+}
+
+type recvResult[TRecv any] struct {
+	msg TRecv
+	err error
 }
 
 func NewWsDataProcessWorker[TRecv, TResp any](
 	dataType string,
 	dataReceiver DataReceiver[TRecv],
 	dataTrasformator DataTransformator[TRecv, TResp],
-	batchSize int,
+	pipelineCfg *conf.WsPipelineCfg,
 	dataStorages []BatchedDataStorage[TResp],
+	spoolStorage BatchedDataStorage[TResp],
 	metrics WsDataPipelineMetrics[TResp],
 ) *WsDataProcessWorker[TRecv, TResp] {
+	var started, shutdown atomic.Bool
+	started.Store(false)
+	shutdown.Store(false)
 	return &WsDataProcessWorker[TRecv, TResp]{
-		logger:              log.GetLogger(fmt.Sprintf("WsDataProcessWorker[%s]", dataType)),
-		dataReceiver:        dataReceiver,
-		dataTrasformator:    dataTrasformator,
-		dataStorages:        dataStorages,
-		batchSize:           batchSize,
-		metrics:             metrics,
-		saveDataWg:          sync.WaitGroup{},
-		shutdownCh:          make(chan struct{}),
-		shutdownCompletedCh: make(chan struct{}),
+		logger:           log.GetLogger(fmt.Sprintf("WsDataProcessWorker[%s]", dataType)),
+		dataReceiver:     dataReceiver,
+		dataTrasformator: dataTrasformator,
+		dataStorages:     dataStorages,
+		spoolStorage:     spoolStorage,
+		batchSize:        pipelineCfg.BatchSize,
+		maxLinger:        time.Duration(pipelineCfg.MaxLingerMs) * time.Millisecond,
+		numWriters:       max(pipelineCfg.NumWriters, 1),
+		queueMaxBlock:    time.Duration(pipelineCfg.QueueMaxBlockMs) * time.Millisecond,
+		saveQueue:        make(chan []TResp, max(pipelineCfg.QueueSize, 0)),
+		metrics:          metrics,
+		saveDataWg:       sync.WaitGroup{},
+		started:          &started,
+		shutdown:         &shutdown,
+		shutdownCh:       make(chan struct{}),
+		runCompletedCh:   make(chan struct{}),
 	}
 }
 
@@ -54,55 +80,167 @@ func (s *WsDataProcessWorker[TRecv, TResp]) Start(ctx context.Context) error {
 	if err := s.dataReceiver.ConnectWs(ctxWithTimeout); err != nil {
 		return fmt.Errorf("%w", err)
 	}
+	s.startWriters(ctx)
+	s.started.Store(true)
 	go s.Run(ctx)
 	return nil
 }
 
+func (s *WsDataProcessWorker[TRecv, TResp]) startWriters(ctx context.Context) {
+	s.saveDataWg.Add(s.numWriters)
+	for i := 0; i < s.numWriters; i++ {
+		go func() {
+			defer s.saveDataWg.Done()
+			for batch := range s.saveQueue {
+				s.metrics.DecQueueDepth()
+				if err := s.Save(ctx, batch); err != nil {
+					s.logger.Error(err.Error())
+				}
+				s.metrics.IncEndedSaveGoroutines()
+			}
+		}()
+	}
+}
+
+// Run collects received rows into a batch and hands it to the writers once the
+// batch is full or older than maxLinger. A partial batch survives receive and
+// transform errors and is flushed on shutdown.
 func (s *WsDataProcessWorker[TRecv, TResp]) Run(ctx context.Context) {
+	defer close(s.runCompletedCh)
+	recvCh := make(chan recvResult[TRecv])
+	go s.recvLoop(ctx, recvCh)
+	batch := make([]TResp, 0, s.batchSize)
+	var batchStartTs time.Time
+	lingerTicker := time.NewTicker(s.lingerCheckPeriod())
+	defer lingerTicker.Stop()
 	for {
 		select {
+		case res, ok := <-recvCh:
+			if !ok {
+				s.flush(ctx, batch)
+				s.stopWriters()
+				return
+			}
+			if res.err != nil {
+				s.metrics.IncRecvErr()
+				s.logger.Error(fmt.Errorf("data receiving error %w", res.err).Error())
+				continue
+			}
+			transformedData, err := s.dataTrasformator.Transform(res.msg)
+			if err != nil {
+				s.metrics.IncRecvErr()
+				s.logger.Error(fmt.Errorf("data transformation error %w", err).Error())
+				continue
+			}
+			if transformedData == nil {
+				s.logger.Warn("nil data batch")
+				continue
+			}
+			s.metrics.ProcessDataMetrics(transformedData, Receive)
+			if len(batch) == 0 {
+				batchStartTs = time.Now()
+			}
+			batch = append(batch, transformedData...)
+			if len(batch) >= s.batchSize {
+				s.flush(ctx, batch)
+				batch = make([]TResp, 0, s.batchSize)
+			}
+		case <-lingerTicker.C:
+			if s.maxLinger > 0 && len(batch) > 0 && time.Since(batchStartTs) >= s.maxLinger {
+				s.flush(ctx, batch)
+				batch = make([]TResp, 0, s.batchSize)
+			}
 		case <-s.shutdownCh:
-			s.saveDataWg.Wait()
+			s.shutdown.Store(true)
+			s.dataReceiver.Shutdown(ctx)
+			for res := range recvCh {
+				if res.err != nil {
+					continue
+				}
+				if transformedData, err := s.dataTrasformator.Transform(res.msg); err == nil {
+					s.metrics.ProcessDataMetrics(transformedData, Receive)
+					batch = append(batch, transformedData...)
+				}
+			}
+			s.flush(ctx, batch)
+			s.stopWriters()
 			return
-		default:
-			s.RecvAndSaveBatch(ctx)
 		}
 	}
 }
 
-func (s *WsDataProcessWorker[TRecv, TResp]) RecvAndSaveBatch(ctx context.Context) {
-	batch, err := s.Recv(ctx)
-	if err != nil {
-		s.metrics.IncRecvErr()
-		s.logger.Error(err.Error())
-		return
-	}
-	s.metrics.ProcessDataMetrics(batch, Receive)
-	s.saveDataWg.Add(1)
-	s.metrics.IncStartedSaveGoroutines()
-	err = s.Save(ctx, batch)
-	if err != nil {
-		s.logger.Error(err.Error())
+func (s *WsDataProcessWorker[TRecv, TResp]) lingerCheckPeriod() time.Duration {
+	if s.maxLinger <= 0 {
+		return time.Second
 	}
+	return max(s.maxLinger/10, 10*time.Millisecond)
 }
 
-func (s *WsDataProcessWorker[TRecv, TResp]) Recv(ctx context.Context) ([]TResp, error) {
-	batch := make([]TResp, 0, s.batchSize)
-	for len(batch) < s.batchSize {
+// recvLoop reads messages from the socket until shutdown. Messages returned by the
+// receiver after shutdown was requested are empty placeholders and are dropped.
+func (s *WsDataProcessWorker[TRecv, TResp]) recvLoop(ctx context.Context, recvCh chan<- recvResult[TRecv]) {
+	defer close(recvCh)
+	for !s.shutdown.Load() {
 		msg, err := s.dataReceiver.Recv(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("data receiving error %w", err)
-		}
-		transformedData, err := s.dataTrasformator.Transform(msg)
-		if transformedData == nil {
-			s.logger.Warn("nil data batch")
+		if s.shutdown.Load() {
+			return
 		}
-		if err != nil {
-			return nil, fmt.Errorf("data transformation error %w", err)
+		recvCh <- recvResult[TRecv]{msg: msg, err: err}
+	}
+}
+
+func (s *WsDataProcessWorker[TRecv, TResp]) flush(ctx context.Context, batch []TResp) {
+	if len(batch) == 0 {
+		return
+	}
+	s.metrics.IncStartedSaveGoroutines()
+	s.enqueue(ctx, batch)
+}
+
+func (s *WsDataProcessWorker[TRecv, TResp]) stopWriters() {
+	close(s.saveQueue)
+	s.saveDataWg.Wait()
+}
+
+// enqueue hands the batch to the writers. If the queue stays full longer than
+// queueMaxBlock, the batch is written to the spool so the read loop is not stalled
+// by a slow main storage.
+func (s *WsDataProcessWorker[TRecv, TResp]) enqueue(ctx context.Context, batch []TResp) {
+	s.metrics.IncQueueDepth()
+	select {
+	case s.saveQueue <- batch:
+		return
+	default:
+	}
+	blockStart := time.Now()
+	timer := time.NewTimer(s.queueMaxBlock)
+	defer timer.Stop()
+	select {
+	case s.saveQueue <- batch:
+		s.metrics.ObserveQueueBlockedTime(time.Since(blockStart))
+	case <-timer.C:
+		s.metrics.ObserveQueueBlockedTime(time.Since(blockStart))
+		s.metrics.DecQueueDepth()
+		s.metrics.IncQueueOverflow()
+		s.logger.Warn(fmt.Sprintf("save queue is full, spool batch of %d rows", len(batch)))
+		if err := s.spool(ctx, batch); err != nil {
+			s.logger.Error(err.Error())
 		}
-		batch = append(batch, transformedData...)
+		s.metrics.IncEndedSaveGoroutines()
 	}
-	return batch, nil
+}
+
+func (s *WsDataProcessWorker[TRecv, TResp]) spool(ctx context.Context, batch []TResp) error {
+	if s.spoolStorage == nil {
+		s.metrics.ProcessDataMetrics(batch, Fail)
+		return ErrNotSaved
+	}
+	if err := s.spoolStorage.Save(ctx, batch); err != nil {
+		s.metrics.ProcessDataMetrics(batch, Fail)
+		return fmt.Errorf("%w: %w", ErrNotSaved, err)
+	}
+	s.metrics.ProcessDataMetrics(batch, Spool)
+	return nil
 }
 
 func (s *WsDataProcessWorker[TRecv, TResp]) Save(ctx context.Context, batch []TResp) error {
@@ -112,6 +250,9 @@ func (s *WsDataProcessWorker[TRecv, TResp]) Save(ctx context.Context, batch []TR
 			if err == nil {
 				if i > 0 {
 					s.logger.Warn(fmt.Sprintf("data saved to additional storage with no = %d", i))
+					s.metrics.ProcessDataMetrics(batch, Spool)
+				} else {
+					s.metrics.ProcessDataMetrics(batch, Save)
 				}
 				return nil
 			} else {
@@ -119,15 +260,23 @@ func (s *WsDataProcessWorker[TRecv, TResp]) Save(ctx context.Context, batch []TR
 			}
 		}
 	}
+	s.metrics.ProcessDataMetrics(batch, Fail)
 	return ErrNotSaved
 }
 
+// Shutdown stops receiving and waits until the collected data is flushed to the storages.
 func (s *WsDataProcessWorker[TRecv, TResp]) Shutdown(ctx context.Context) {
-	go func(ctx context.Context) {
-		s.shutdownCh <- struct{}{}
+	s.shutdownOnce.Do(func() {
+		close(s.shutdownCh)
+	})
+	if !s.started.Load() {
 		s.dataReceiver.Shutdown(ctx)
-		s.shutdownCompletedCh <- struct{}{}
-	}(ctx)
-	<-s.shutdownCompletedCh
-	s.logger.Debug("successfully shutdown")
+		return
+	}
+	select {
+	case <-s.runCompletedCh:
+		s.logger.Debug("successfully shutdown")
+	case <-ctx.Done():
+		s.logger.Warn("shutdown timeout exceeded before all data was flushed")
+	}
 }
@@ -19,6 +19,7 @@ type WsSvc[TRecv, TResp any] struct {
 	dataStorages    []BatchedDataStorage[TResp]
 	metrics         WsDataPipelineMetrics[TResp]
 	reconnectPeriod time.Duration
+	reassignCh      <-chan struct{}
 	exInfoCache     *cache.ExchangeInfoCache
 	shutdown        *atomic.Bool
 }
@@ -29,6 +30,7 @@ func NewWsSvc[TRecv, TResp any](
 	dataStorages []BatchedDataStorage[TResp],
 	metrics WsDataPipelineMetrics[TResp],
 	reconnectPeriod time.Duration,
+	reassignCh <-chan struct{},
 	exInfoCache *cache.ExchangeInfoCache,
 ) *WsSvc[TRecv, TResp] {
 	var shutdown atomic.Bool
@@ -39,15 +41,24 @@ func NewWsSvc[TRecv, TResp any](
 		dataStorages:    dataStorages,
 		metrics:         metrics,
 		reconnectPeriod: reconnectPeriod,
+		reassignCh:      reassignCh,
 		exInfoCache:     exInfoCache,
 		shutdown:        &shutdown,
 	}
 }
 
+// Start recreates the workers every reconnect period and when symbols are reassigned
+// between replicas.
 func (s *WsSvc[TRecv, TResp]) Start(ctx context.Context) {
 	s.workers = s.getAndActivateNewWorkers(ctx)
 	for {
-		time.Sleep(s.reconnectPeriod)
+		timer := time.NewTimer(s.reconnectPeriod)
+		select {
+		case <-timer.C:
+		case <-s.reassignCh:
+			timer.Stop()
+			s.logger.Info("symbols reassigned, recreate workers")
+		}
 		s.updateWorkers(ctx)
 	}
 }
@@ -91,6 +102,6 @@ func (s *WsSvc[TRecv, TResp]) Shutdown(ctx context.Context) {
 			worker.Shutdown(ctx)
 		}(ctx)
 	}
-	wg.Done()
+	wg.Wait()
 	s.logger.Info("successfully shutdown")
 }
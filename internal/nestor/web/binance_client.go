@@ -60,3 +60,11 @@ func (s BinanceClient) GetFullExchangeInfo(ctx context.Context, dataType bmodel.
 	}
 	return exInfo, err
 }
+
+func (s BinanceClient) GetServerTime(ctx context.Context) (int64, error) {
+	serverTime, err := s.client.GetServerTime(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return serverTime.ServerTime, nil
+}
@@ -3,8 +3,11 @@ package app
 import (
 	"DeltaReceiver/internal/common/web"
 	"DeltaReceiver/internal/sizif/conf"
+	"DeltaReceiver/internal/sizif/objstore"
+	"DeltaReceiver/internal/sizif/svc"
 	bmodel "DeltaReceiver/pkg/binance/model"
 	"DeltaReceiver/pkg/log"
+	"DeltaReceiver/pkg/s3"
 	"context"
 	"fmt"
 	"net/http"
@@ -36,11 +39,11 @@ func NewApp(cfg *conf.AppConfig) *App {
 	}
 	fmt.Println(string(rawCfg))
 	dwarfClient := web.NewDwarfHttpClient(cfg.DwarfURIConfig)
-	zkConn, b2Bucket, csSession := initConnections(cfg)
+	zkConn, objStore, csSession := initConnections(cfg)
 
-	binanceSpotCtx := NewBinanceMarketCtx(bmodel.Spot, cfg.BinanceSpotCfg, cfg.SocratesCfg.BinanceSpotCfg, zkConn, b2Bucket, csSession, dwarfClient)
-	binanceUSDCtx := NewBinanceMarketCtx(bmodel.FuturesUSD, cfg.BinanceUSDCfg, cfg.SocratesCfg.BinanceUSDCfg, zkConn, b2Bucket, csSession, dwarfClient)
-	binanceCoinCtx := NewBinanceMarketCtx(bmodel.FuturesCoin, cfg.BinanceCoinCfg, cfg.SocratesCfg.BinanceCoinCfg, zkConn, b2Bucket, csSession, dwarfClient)
+	binanceSpotCtx := NewBinanceMarketCtx(bmodel.Spot, cfg.BinanceSpotCfg, cfg.SocratesCfg.BinanceSpotCfg, cfg.KeyScanCfg, cfg.LedgerCfg, cfg.KeyLockCfg, zkConn, objStore, cfg.ParquetCfg, cfg.CompactionCfg, cfg.ExInfoArchiveCfg, cfg.BookCfg, cfg.MidBarsCfg, cfg.SchedulingCfg, csSession, dwarfClient)
+	binanceUSDCtx := NewBinanceMarketCtx(bmodel.FuturesUSD, cfg.BinanceUSDCfg, cfg.SocratesCfg.BinanceUSDCfg, cfg.KeyScanCfg, cfg.LedgerCfg, cfg.KeyLockCfg, zkConn, objStore, cfg.ParquetCfg, cfg.CompactionCfg, cfg.ExInfoArchiveCfg, cfg.BookCfg, cfg.MidBarsCfg, cfg.SchedulingCfg, csSession, dwarfClient)
+	binanceCoinCtx := NewBinanceMarketCtx(bmodel.FuturesCoin, cfg.BinanceCoinCfg, cfg.SocratesCfg.BinanceCoinCfg, cfg.KeyScanCfg, cfg.LedgerCfg, cfg.KeyLockCfg, zkConn, objStore, cfg.ParquetCfg, cfg.CompactionCfg, cfg.ExInfoArchiveCfg, cfg.BookCfg, cfg.MidBarsCfg, cfg.SchedulingCfg, csSession, dwarfClient)
 
 	return &App{
 		logger:         logger,
@@ -51,26 +54,44 @@ func NewApp(cfg *conf.AppConfig) *App {
 	}
 }
 
-func initConnections(cfg *conf.AppConfig) (*zk.Conn, *b2.Bucket, *gocql.Session) {
-	zkConn, _, err := zk.Connect(cfg.ZkCfg.Servers, time.Second*time.Duration(cfg.ZkCfg.SessionTimeoutS))
-	if err != nil {
-		panic(err)
-	}
-	b2Client, err := b2.NewClient(context.TODO(), cfg.B2Cfg.Account, cfg.B2Cfg.Key)
-	if err != nil {
-		panic(err)
-	}
-	b2Bucket, err := b2Client.Bucket(context.TODO(), cfg.B2Cfg.Bucket)
-	if err != nil {
-		panic(err)
+// initConnections dials ZooKeeper only if keys are locked in it, the connection is nil otherwise.
+func initConnections(cfg *conf.AppConfig) (*zk.Conn, objstore.ObjectStore, *gocql.Session) {
+	var zkConn *zk.Conn
+	if cfg.KeyLockCfg.Type == conf.ZkKeyLocker {
+		var err error
+		zkConn, _, err = zk.Connect(cfg.ZkCfg.Servers, time.Second*time.Duration(cfg.ZkCfg.SessionTimeoutS))
+		if err != nil {
+			panic(err)
+		}
 	}
+	objStore := initObjectStore(cfg.ObjectStoreCfg)
 	csCluster := gocql.NewCluster(cfg.SocratesCfg.Hosts...)
 	csCluster.Keyspace = cfg.SocratesCfg.KeySpace
 	session, err := csCluster.CreateSession()
 	if err != nil {
 		panic(err)
 	}
-	return zkConn, b2Bucket, session
+	return zkConn, objStore, session
+}
+
+func initObjectStore(cfg *conf.ObjectStoreCfg) objstore.ObjectStore {
+	switch cfg.Type {
+	case conf.S3ObjectStore:
+		s3Cfg := cfg.S3Cfg
+		return objstore.NewS3Store(s3.NewS3Client(s3Cfg.URICfg.GetBaseUri(), s3Cfg.Region, s3Cfg.Id, s3Cfg.Secret, s3Cfg.Bucket))
+	case conf.FsObjectStore:
+		return objstore.NewFsStore(cfg.FsCfg.RootDir)
+	default:
+		b2Client, err := b2.NewClient(context.TODO(), cfg.B2Cfg.Account, cfg.B2Cfg.Key)
+		if err != nil {
+			panic(err)
+		}
+		b2Bucket, err := b2Client.Bucket(context.TODO(), cfg.B2Cfg.Bucket)
+		if err != nil {
+			panic(err)
+		}
+		return objstore.NewB2Store(b2Bucket)
+	}
 }
 
 func (s *App) Start() {
@@ -89,6 +110,19 @@ func (s *App) Start() {
 	s.logger.Info("App started")
 }
 
+func (s *App) Backfill(ctx context.Context, market bmodel.DataType, dataType string, req *svc.BackfillRequest) ([]svc.KeyResult, error) {
+	switch market {
+	case bmodel.Spot:
+		return s.binanceSpotCtx.Backfill(ctx, dataType, req)
+	case bmodel.FuturesUSD:
+		return s.binanceUSDCtx.Backfill(ctx, dataType, req)
+	case bmodel.FuturesCoin:
+		return s.binanceCoinCtx.Backfill(ctx, dataType, req)
+	default:
+		return nil, fmt.Errorf("unknown market %s", market)
+	}
+}
+
 func (s *App) Stop(ctx context.Context) {
 	s.logger.Info("Begin of graceful shutdown")
 	var wg sync.WaitGroup
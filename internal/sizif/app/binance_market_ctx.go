@@ -5,18 +5,20 @@ import (
 	"DeltaReceiver/internal/common/model"
 	"DeltaReceiver/internal/common/repo/cs"
 	"DeltaReceiver/internal/common/web"
-	b2pqt "DeltaReceiver/internal/sizif/b2"
+	"DeltaReceiver/internal/sizif/catalog"
 	"DeltaReceiver/internal/sizif/conf"
 	"DeltaReceiver/internal/sizif/lock"
 	"DeltaReceiver/internal/sizif/metrics"
+	"DeltaReceiver/internal/sizif/objstore"
+	"DeltaReceiver/internal/sizif/repo"
 	"DeltaReceiver/internal/sizif/svc"
 	bmodel "DeltaReceiver/pkg/binance/model"
 	"context"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/Backblaze/blazer/b2"
 	"github.com/go-zookeeper/zk"
 	"github.com/gocql/gocql"
 )
@@ -25,52 +27,169 @@ type BinanceMarketCtx struct {
 	deltasSvc    *svc.SizifSvc[model.Delta]
 	bookTicksSvc *svc.SizifSvc[bmodel.SymbolTick]
 	snapshotsSvc *svc.SizifSvc[model.DepthSnapshotPart]
+	compactions  []*svc.CompactionSvc
+	exInfoSvc    *svc.ExchangeInfoArchiveSvc
+	bookSvc      *svc.BookReconstructionSvc
+	midBarsSvc   *svc.MidBarsSvc
 }
 
 func NewBinanceMarketCtx(
 	marketType bmodel.DataType,
 	marketCfg *conf.BinanceMarketCfg,
 	csRepoCfg *cconf.BinanceMarketCsRepoCfg,
+	keyScanCfg *cconf.CsKeyScanCfg,
+	ledgerCfg *conf.ArchiveLedgerCfg,
+	lockCfg *conf.KeyLockCfg,
 	zkConn *zk.Conn,
-	b2Bucket *b2.Bucket,
+	objStore objstore.ObjectStore,
+	parquetCfg *conf.ParquetLayoutCfg,
+	compactionCfg *conf.CompactionCfg,
+	exInfoArchiveCfg *conf.ExchangeInfoArchiveCfg,
+	bookCfg *conf.BookReconstructionCfg,
+	midBarsCfg *conf.MidBarsCfg,
+	schedulingCfg *conf.SchedulingCfg,
 	csSession *gocql.Session,
 	dwarfClient *web.DwarfHttpClient,
 ) *BinanceMarketCtx {
-	var marketSubpath string
-	if marketType == bmodel.Spot {
-		marketSubpath = "binance/"
-	} else {
-		marketSubpath = fmt.Sprintf("binance/%s/", marketType)
-	}
-
-	deltaSubpath := marketSubpath + "deltas"
-	deltaSocratesStorage := cs.NewCsDeltaStorageRO(csSession, csRepoCfg.DeltaTableName, csRepoCfg.DeltaKeyTableName)
-	deltaParquetStorage := b2pqt.NewB2ParquetStorage[model.Delta](b2Bucket, deltaSubpath, b2pqt.FromKey)
+	deltaSubpath := catalog.StorageName(marketType, "deltas")
+	deltaManifests := newManifestWriter(objStore, deltaSubpath, lockCfg, zkConn, csSession)
+	deltaSocratesStorage := cs.NewCsDeltaStorageRO(csSession, csRepoCfg.DeltaTableName, csRepoCfg.DeltaKeyTableName, keyScanCfg)
+	deltaParquetStorage := repo.NewParquetStorage[model.Delta](objStore, deltaSubpath, repo.FromKey, parquetCfg.DeltasCfg, deltaManifests)
+	deltaQuarantineSubpath := catalog.QuarantineStorageName(deltaSubpath)
+	deltaQuarantineStorage := repo.NewParquetStorage[model.Delta](objStore, deltaQuarantineSubpath, repo.FromKey, parquetCfg.DeltasCfg, newManifestWriter(objStore, deltaQuarantineSubpath, lockCfg, zkConn, csSession))
+	deltaReportStorages := []svc.ValidationReportStorage{repo.NewValidationReportStorage(objStore, deltaQuarantineSubpath), dwarfClient}
 	deltaTransformator := svc.NewDeltaTransformator(dwarfClient, marketType)
-	deltaLocker := lock.NewZkLocker(deltaSubpath, zkConn)
+	deltaLocker := newKeyLocker(deltaSubpath, lockCfg, zkConn, csSession)
 	deltaMetrics := metrics.NewSizifWorkerMetrics(b2zkPathToMetric(deltaSubpath))
-	deltaSvc := svc.NewSizifSvc(deltaSubpath, deltaSocratesStorage, deltaParquetStorage, deltaTransformator, deltaLocker, marketCfg.DeltaWorkers, deltaMetrics)
+	deltaLedger := repo.NewCsArchiveLedger(csSession, deltaSubpath, ledgerCfg)
+	deltaSvc := svc.NewSizifSvc(deltaSubpath, deltaSocratesStorage, deltaParquetStorage, deltaQuarantineStorage, deltaReportStorages, deltaTransformator, deltaLocker, deltaLedger, newKeyWatermark(deltaSubpath, lockCfg, zkConn, csSession), schedulingCfg.DeltasCfg, marketCfg.DeltaWorkers, deltaMetrics)
 
-	bookTicksSubpath := marketSubpath + "book_ticks"
-	bookTicksSocratesStorage := cs.NewCsBookTicksStorageRO(csSession, csRepoCfg.BookTicksTableName, csRepoCfg.BookTicksKeyTableName)
-	bookTicksParquetStorage := b2pqt.NewB2ParquetStorage[bmodel.SymbolTick](b2Bucket, bookTicksSubpath, b2pqt.FromKey)
+	bookTicksSubpath := catalog.StorageName(marketType, "book_ticks")
+	bookTicksManifests := newManifestWriter(objStore, bookTicksSubpath, lockCfg, zkConn, csSession)
+	bookTicksSocratesStorage := cs.NewCsBookTicksStorageRO(csSession, csRepoCfg.BookTicksTableName, csRepoCfg.BookTicksKeyTableName, keyScanCfg)
+	bookTicksParquetStorage := repo.NewParquetStorage[bmodel.SymbolTick](objStore, bookTicksSubpath, repo.FromKey, parquetCfg.BookTicksCfg, bookTicksManifests)
+	bookTicksQuarantineSubpath := catalog.QuarantineStorageName(bookTicksSubpath)
+	bookTicksQuarantineStorage := repo.NewParquetStorage[bmodel.SymbolTick](objStore, bookTicksQuarantineSubpath, repo.FromKey, parquetCfg.BookTicksCfg, newManifestWriter(objStore, bookTicksQuarantineSubpath, lockCfg, zkConn, csSession))
+	bookTicksReportStorages := []svc.ValidationReportStorage{repo.NewValidationReportStorage(objStore, bookTicksQuarantineSubpath), dwarfClient}
 	bookTicksTransformator := svc.NewBookTicksTransformator()
-	bookTicksLocker := lock.NewZkLocker(bookTicksSubpath, zkConn)
+	bookTicksLocker := newKeyLocker(bookTicksSubpath, lockCfg, zkConn, csSession)
 	bookTicksMetrics := metrics.NewSizifWorkerMetrics(b2zkPathToMetric(bookTicksSubpath))
-	bookTicksSvc := svc.NewSizifSvc(bookTicksSubpath, bookTicksSocratesStorage, bookTicksParquetStorage, bookTicksTransformator, bookTicksLocker, marketCfg.BookTicksWorker, bookTicksMetrics)
+	bookTicksLedger := repo.NewCsArchiveLedger(csSession, bookTicksSubpath, ledgerCfg)
+	bookTicksSvc := svc.NewSizifSvc(bookTicksSubpath, bookTicksSocratesStorage, bookTicksParquetStorage, bookTicksQuarantineStorage, bookTicksReportStorages, bookTicksTransformator, bookTicksLocker, bookTicksLedger, newKeyWatermark(bookTicksSubpath, lockCfg, zkConn, csSession), schedulingCfg.BookTicksCfg, marketCfg.BookTicksWorker, bookTicksMetrics)
 
-	snapshotsSubpath := marketSubpath + "snapshots"
-	snapshotsSocratesStorage := cs.NewCsSnapshotStorageRO(csSession, csRepoCfg.SnapshotTableName, csRepoCfg.SnapshotKeyTableName)
-	snapshotsParquetStorage := b2pqt.NewB2ParquetStorage[model.DepthSnapshotPart](b2Bucket, snapshotsSubpath, b2pqt.FromData)
+	snapshotsSubpath := catalog.StorageName(marketType, "snapshots")
+	snapshotsManifests := newManifestWriter(objStore, snapshotsSubpath, lockCfg, zkConn, csSession)
+	snapshotsSocratesStorage := cs.NewCsSnapshotStorageRO(csSession, csRepoCfg.SnapshotTableName, csRepoCfg.SnapshotKeyTableName, keyScanCfg)
+	snapshotsParquetStorage := repo.NewParquetStorage[model.DepthSnapshotPart](objStore, snapshotsSubpath, repo.FromData, parquetCfg.SnapshotsCfg, snapshotsManifests)
+	snapshotsQuarantineSubpath := catalog.QuarantineStorageName(snapshotsSubpath)
+	snapshotsQuarantineStorage := repo.NewParquetStorage[model.DepthSnapshotPart](objStore, snapshotsQuarantineSubpath, repo.FromData, parquetCfg.SnapshotsCfg, newManifestWriter(objStore, snapshotsQuarantineSubpath, lockCfg, zkConn, csSession))
+	snapshotsReportStorages := []svc.ValidationReportStorage{repo.NewValidationReportStorage(objStore, snapshotsQuarantineSubpath), dwarfClient}
 	snapshotsTransformator := svc.NewDepthSnapshotTransformator()
-	snapshotsLocker := lock.NewZkLocker(snapshotsSubpath, zkConn)
+	snapshotsLocker := newKeyLocker(snapshotsSubpath, lockCfg, zkConn, csSession)
 	snapshotsMetrics := metrics.NewSizifWorkerMetrics(b2zkPathToMetric(snapshotsSubpath))
-	snapshotsSvc := svc.NewSizifSvc(snapshotsSubpath, snapshotsSocratesStorage, snapshotsParquetStorage, snapshotsTransformator, snapshotsLocker, marketCfg.SnapshotsWorker, snapshotsMetrics)
+	snapshotsLedger := repo.NewCsArchiveLedger(csSession, snapshotsSubpath, ledgerCfg)
+	snapshotsSvc := svc.NewSizifSvc(snapshotsSubpath, snapshotsSocratesStorage, snapshotsParquetStorage, snapshotsQuarantineStorage, snapshotsReportStorages, snapshotsTransformator, snapshotsLocker, snapshotsLedger, newKeyWatermark(snapshotsSubpath, lockCfg, zkConn, csSession), schedulingCfg.SnapshotsCfg, marketCfg.SnapshotsWorker, snapshotsMetrics)
+
+	var compactions []*svc.CompactionSvc
+	if compactionCfg.Enabled {
+		compactions = []*svc.CompactionSvc{
+			newCompactionSvc(deltaSubpath, repo.NewParquetCompactor[model.Delta](objStore, deltaSubpath, parquetCfg.DeltasCfg, compactionCfg.MaxPartBytes, deltaManifests), lockCfg, zkConn, csSession, compactionCfg),
+			newCompactionSvc(bookTicksSubpath, repo.NewParquetCompactor[bmodel.SymbolTick](objStore, bookTicksSubpath, parquetCfg.BookTicksCfg, compactionCfg.MaxPartBytes, bookTicksManifests), lockCfg, zkConn, csSession, compactionCfg),
+			newCompactionSvc(snapshotsSubpath, repo.NewParquetCompactor[model.DepthSnapshotPart](objStore, snapshotsSubpath, parquetCfg.SnapshotsCfg, compactionCfg.MaxPartBytes, snapshotsManifests), lockCfg, zkConn, csSession, compactionCfg),
+		}
+	}
+
+	var exInfoSvc *svc.ExchangeInfoArchiveSvc
+	if exInfoArchiveCfg.Enabled {
+		exInfoSubpath := catalog.StorageName(marketType, "exchange_info")
+		exInfoManifests := newManifestWriter(objStore, exInfoSubpath, lockCfg, zkConn, csSession)
+		exInfoSource := cs.NewExchangeInfoStorage(string("exchange_info_"+marketType), csSession, csRepoCfg.ExchangeInfoTableName)
+		exInfoSvc = svc.NewExchangeInfoArchiveSvc(
+			exInfoSubpath,
+			exInfoSource,
+			repo.NewParquetStorage[model.ExchangeInfo](objStore, exInfoSubpath, repo.FromKey, parquetCfg.ExchangeInfoCfg, exInfoManifests),
+			repo.NewParquetStorage[model.InstrumentInfo](objStore, exInfoSubpath, repo.FromKey, parquetCfg.InstrumentsCfg, exInfoManifests),
+			repo.NewCsArchiveLedger(csSession, exInfoSubpath, ledgerCfg),
+			newKeyLocker(exInfoSubpath, lockCfg, zkConn, csSession),
+			metrics.NewExchangeInfoArchiveMetrics(b2zkPathToMetric(exInfoSubpath)),
+			exInfoArchiveCfg,
+		)
+	}
+
+	var bookSvc *svc.BookReconstructionSvc
+	if bookCfg.Enabled {
+		bookSubpath := catalog.StorageName(marketType, "book_states")
+		bookSvc = svc.NewBookReconstructionSvc(
+			bookSubpath,
+			marketType,
+			repo.NewArchiveReader[model.Delta](objStore, marketType, "deltas"),
+			deltaLedger,
+			repo.NewArchiveReader[model.DepthSnapshotPart](objStore, marketType, "snapshots"),
+			snapshotsLedger,
+			repo.NewParquetStorage[model.BookState](objStore, bookSubpath, repo.FromKey, parquetCfg.BookStatesCfg, newManifestWriter(objStore, bookSubpath, lockCfg, zkConn, csSession)),
+			repo.NewCsArchiveLedger(csSession, bookSubpath, ledgerCfg),
+			newKeyLocker(bookSubpath, lockCfg, zkConn, csSession),
+			metrics.NewBookReconstructionMetrics(b2zkPathToMetric(bookSubpath)),
+			bookCfg,
+		)
+	}
+
+	var midBarsSvc *svc.MidBarsSvc
+	if midBarsCfg.Enabled {
+		midBarsSubpath := catalog.StorageName(marketType, "mid_bars")
+		midBarsSvc = svc.NewMidBarsSvc(
+			midBarsSubpath,
+			repo.NewArchiveReader[bmodel.SymbolTick](objStore, marketType, "book_ticks"),
+			bookTicksLedger,
+			repo.NewParquetStorage[model.MidBar](objStore, midBarsSubpath, repo.FromKey, parquetCfg.MidBarsCfg, newManifestWriter(objStore, midBarsSubpath, lockCfg, zkConn, csSession)),
+			repo.NewCsArchiveLedger(csSession, midBarsSubpath, ledgerCfg),
+			newKeyLocker(midBarsSubpath, lockCfg, zkConn, csSession),
+			metrics.NewMidBarsMetrics(b2zkPathToMetric(midBarsSubpath)),
+			midBarsCfg,
+		)
+	}
 
 	return &BinanceMarketCtx{
 		deltasSvc:    deltaSvc,
 		bookTicksSvc: bookTicksSvc,
 		snapshotsSvc: snapshotsSvc,
+		compactions:  compactions,
+		exInfoSvc:    exInfoSvc,
+		bookSvc:      bookSvc,
+		midBarsSvc:   midBarsSvc,
+	}
+}
+
+func newCompactionSvc(subpath string, compactor svc.ParquetCompactor, lockCfg *conf.KeyLockCfg, zkConn *zk.Conn, csSession *gocql.Session, cfg *conf.CompactionCfg) *svc.CompactionSvc {
+	compactionSubpath := "compaction/" + subpath
+	return svc.NewCompactionSvc(compactionSubpath, compactor, newKeyLocker(compactionSubpath, lockCfg, zkConn, csSession), metrics.NewCompactionMetrics(b2zkPathToMetric(subpath)), cfg)
+}
+
+func newKeyLocker(prefix string, cfg *conf.KeyLockCfg, zkConn *zk.Conn, csSession *gocql.Session) svc.KeyLocker {
+	switch cfg.Type {
+	case conf.CsKeyLocker:
+		return lock.NewCsLocker(prefix, csSession, cfg.TableName, time.Duration(cfg.LeaseS)*time.Second)
+	default:
+		return lock.NewZkLocker(prefix, zkConn)
+	}
+}
+
+func newManifestWriter(objStore objstore.ObjectStore, subpath string, cfg *conf.KeyLockCfg, zkConn *zk.Conn, csSession *gocql.Session) *repo.ManifestWriter {
+	prefix := "manifest/" + subpath
+	switch cfg.Type {
+	case conf.CsKeyLocker:
+		return repo.NewManifestWriter(objStore, subpath, lock.NewCsMutex(lock.NewCsLocker(prefix, csSession, cfg.TableName, time.Duration(cfg.LeaseS)*time.Second)))
+	default:
+		return repo.NewManifestWriter(objStore, subpath, lock.NewZkMutex(prefix, zkConn))
+	}
+}
+
+func newKeyWatermark(name string, cfg *conf.KeyLockCfg, zkConn *zk.Conn, csSession *gocql.Session) svc.KeyWatermark {
+	switch cfg.Type {
+	case conf.CsKeyLocker:
+		return lock.NewCsWatermark(name, csSession, cfg.WatermarkTableName)
+	default:
+		return lock.NewZkWatermark(name, zkConn)
 	}
 }
 
@@ -82,11 +201,70 @@ func (s *BinanceMarketCtx) Start(ctx context.Context) {
 	go s.deltasSvc.Start(ctx)
 	go s.bookTicksSvc.Start(ctx)
 	go s.snapshotsSvc.Start(ctx)
+	for _, compaction := range s.compactions {
+		go compaction.Start(ctx)
+	}
+	if s.exInfoSvc != nil {
+		go s.exInfoSvc.Start(ctx)
+	}
+	if s.bookSvc != nil {
+		go s.bookSvc.Start(ctx)
+	}
+	if s.midBarsSvc != nil {
+		go s.midBarsSvc.Start(ctx)
+	}
+}
+
+// Backfill archives the requested keys of the data type again. It is used instead of Start.
+func (s *BinanceMarketCtx) Backfill(ctx context.Context, dataType string, req *svc.BackfillRequest) ([]svc.KeyResult, error) {
+	switch dataType {
+	case "deltas":
+		return backfill(ctx, s.deltasSvc, req)
+	case "book_ticks":
+		return backfill(ctx, s.bookTicksSvc, req)
+	case "snapshots":
+		return backfill(ctx, s.snapshotsSvc, req)
+	default:
+		return nil, fmt.Errorf("unknown data type %s", dataType)
+	}
+}
+
+func backfill[T model.WithTimestampMs](ctx context.Context, sizifSvc *svc.SizifSvc[T], req *svc.BackfillRequest) ([]svc.KeyResult, error) {
+	sizifSvc.StartWorkers(ctx)
+	defer sizifSvc.Shutdown(ctx)
+	return sizifSvc.Backfill(ctx, req)
 }
 
 func (s *BinanceMarketCtx) Shutdown(ctx context.Context) {
 	var wg sync.WaitGroup
-	wg.Add(3)
+	wg.Add(3 + len(s.compactions))
+	for _, compaction := range s.compactions {
+		go func() {
+			compaction.Shutdown(ctx)
+			wg.Done()
+		}()
+	}
+	if s.exInfoSvc != nil {
+		wg.Add(1)
+		go func() {
+			s.exInfoSvc.Shutdown(ctx)
+			wg.Done()
+		}()
+	}
+	if s.bookSvc != nil {
+		wg.Add(1)
+		go func() {
+			s.bookSvc.Shutdown(ctx)
+			wg.Done()
+		}()
+	}
+	if s.midBarsSvc != nil {
+		wg.Add(1)
+		go func() {
+			s.midBarsSvc.Shutdown(ctx)
+			wg.Done()
+		}()
+	}
 	go func() {
 		s.deltasSvc.Shutdown(ctx)
 		wg.Done()
@@ -0,0 +1,89 @@
+package catalog
+
+import (
+	"DeltaReceiver/internal/sizif/objstore"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Catalog reads day manifests, so archived files can be found without listing the store.
+type Catalog struct {
+	objStore objstore.ObjectStore
+}
+
+func NewCatalog(objStore objstore.ObjectStore) *Catalog {
+	return &Catalog{
+		objStore: objStore,
+	}
+}
+
+// Day returns the manifest of the day merged from manifests of its symbols or nil if
+// nothing was archived for it.
+func (s *Catalog) Day(ctx context.Context, market bmodel.DataType, dataType string, date time.Time) (*DayManifest, error) {
+	storageName := StorageName(market, dataType)
+	keys, err := s.objStore.List(ctx, manifestsPrefix(storageName, date))
+	if err != nil {
+		return nil, err
+	}
+	var day *DayManifest
+	for _, key := range keys {
+		symbol, ok := strings.CutSuffix(strings.TrimPrefix(key, manifestsPrefix(storageName, date)), ".json")
+		if !ok || strings.Contains(symbol, "/") {
+			continue
+		}
+		manifest, err := ReadDayManifest(ctx, s.objStore, storageName, symbol, date)
+		if err != nil {
+			return nil, err
+		}
+		if manifest == nil {
+			continue
+		}
+		if day == nil {
+			day = &DayManifest{StorageName: storageName, Date: manifest.Date}
+		}
+		day.UpdatedAtMs = max(day.UpdatedAtMs, manifest.UpdatedAtMs)
+		day.Files = append(day.Files, manifest.Files...)
+	}
+	return day, nil
+}
+
+// Files returns files of the symbol which may hold rows in [from, to), ordered by day.
+func (s *Catalog) Files(ctx context.Context, market bmodel.DataType, dataType string, symbol string, from, to time.Time) ([]ManifestFile, error) {
+	var files []ManifestFile
+	fromMs, toMs := from.UnixMilli(), to.UnixMilli()
+	for date := from.UTC().Truncate(24 * time.Hour); date.Before(to); date = date.AddDate(0, 0, 1) {
+		manifest, err := ReadDayManifest(ctx, s.objStore, StorageName(market, dataType), symbol, date)
+		if err != nil {
+			return nil, err
+		}
+		if manifest == nil {
+			continue
+		}
+		for _, file := range manifest.Files {
+			if file.Covers(fromMs, toMs) {
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// ReadDayManifest returns the manifest of the symbol and day or nil if there is none.
+func ReadDayManifest(ctx context.Context, objStore objstore.ObjectStore, storageName, symbol string, date time.Time) (*DayManifest, error) {
+	data, err := objStore.Get(ctx, ManifestKey(storageName, symbol, date))
+	if errors.Is(err, objstore.ObjectNotFoundErr) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest DayManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
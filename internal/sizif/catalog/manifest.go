@@ -0,0 +1,67 @@
+package catalog
+
+import (
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"fmt"
+	"time"
+)
+
+// DayManifest lists archived files of one market and data type for a UTC day. A manifest
+// is kept per symbol, so writers of different symbols do not contend; a manifest of the
+// whole day is merged from them. sizif updates it whenever files are verified, compacted
+// or removed, so it always matches the objects readers should use.
+type DayManifest struct {
+	StorageName string `json:"storageName"`
+	Date        string `json:"date"`
+	// Symbol is empty in a merged manifest of the whole day.
+	Symbol      string         `json:"symbol,omitempty"`
+	UpdatedAtMs int64          `json:"updatedAtMs"`
+	Files       []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	Key    string `json:"key"`
+	Symbol string `json:"symbol"`
+	// Hour is the UTC hour of day of hourly files and CompactedHour for compacted day parts.
+	Hour           int    `json:"hour"`
+	Rows           int64  `json:"rows"`
+	MinTimestampMs int64  `json:"minTimestampMs"`
+	MaxTimestampMs int64  `json:"maxTimestampMs"`
+	MinUpdateId    int64  `json:"minUpdateId"`
+	MaxUpdateId    int64  `json:"maxUpdateId"`
+	HoleCount      int    `json:"holeCount"`
+	Checksum       string `json:"checksum"`
+	WriterVersion  int    `json:"writerVersion"`
+}
+
+const (
+	CompactedHour = -1
+	manifestsDir  = "_manifests"
+)
+
+// StorageName is the key prefix of archived objects of the market and data type,
+// e.g. binance/deltas for spot and binance/usd/deltas for futures.
+func StorageName(market bmodel.DataType, dataType string) string {
+	if market == bmodel.Spot {
+		return "binance/" + dataType
+	}
+	return fmt.Sprintf("binance/%s/%s", market, dataType)
+}
+
+// QuarantineStorageName is the key prefix of invalid hours of the storage.
+func QuarantineStorageName(storageName string) string {
+	return "quarantine/" + storageName
+}
+
+func ManifestKey(storageName, symbol string, date time.Time) string {
+	return manifestsPrefix(storageName, date) + symbol + ".json"
+}
+
+func manifestsPrefix(storageName string, date time.Time) string {
+	return fmt.Sprintf("%s/%s/%s/", storageName, manifestsDir, date.UTC().Format(time.DateOnly))
+}
+
+// Covers reports whether the file may hold rows in [fromMs, toMs).
+func (s *ManifestFile) Covers(fromMs, toMs int64) bool {
+	return s.Rows > 0 && s.MinTimestampMs < toMs && s.MaxTimestampMs >= fromMs
+}
@@ -0,0 +1,30 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+
+	"github.com/gocql/gocql"
+)
+
+// ArchiveLedgerCfg sets the Cassandra table recording archived keys. Reads and writes of
+// the ledger decide whether hot data is archived again, so they should be at least QUORUM.
+type ArchiveLedgerCfg struct {
+	TableName   string `yaml:"table"`
+	Consistency string `yaml:"consistency"`
+}
+
+func NewArchiveLedgerCfgFromEnv(envPrefix string) *ArchiveLedgerCfg {
+	cfg := &ArchiveLedgerCfg{
+		TableName:   env.GetStringOrDefault(envPrefix+".table", "archived_keys"),
+		Consistency: env.GetStringOrDefault(envPrefix+".consistency", "QUORUM"),
+	}
+	if _, err := gocql.ParseConsistencyWrapper(cfg.Consistency); err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+func (s *ArchiveLedgerCfg) GetConsistency() gocql.Consistency {
+	consistency, _ := gocql.ParseConsistencyWrapper(s.Consistency)
+	return consistency
+}
@@ -3,8 +3,8 @@ package conf
 import "os"
 
 type B2Config struct {
-	Account string
-	Key     string
+	Account string `yaml:"-"`
+	Key     string `yaml:"-"`
 	Bucket  string `yaml:"bucket"`
 }
 
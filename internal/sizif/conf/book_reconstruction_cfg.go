@@ -0,0 +1,36 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+	"fmt"
+)
+
+// BookReconstructionCfg sets the derived job replaying archived deltas into book states.
+// Hours are reconstructed DelayH hours after their end, once their deltas and snapshots
+// are archived, and only within the last LookbackDays days. Replay starts from the last
+// snapshot at most SnapshotLookbackH hours before the hour.
+type BookReconstructionCfg struct {
+	Enabled           bool  `yaml:"enabled"`
+	PeriodM           int   `yaml:"period.m"`
+	DelayH            int   `yaml:"delay.h"`
+	LookbackDays      int   `yaml:"lookback.days"`
+	SnapshotLookbackH int   `yaml:"snapshot.lookback.h"`
+	Depth             int   `yaml:"depth"`
+	IntervalMs        int64 `yaml:"interval.ms"`
+}
+
+func NewBookReconstructionCfgFromEnv(envPrefix string) *BookReconstructionCfg {
+	cfg := &BookReconstructionCfg{
+		Enabled:           env.GetBoolOrDefault(envPrefix+".enabled", false),
+		PeriodM:           env.GetIntOrDefault(envPrefix+".period.m", 30),
+		DelayH:            env.GetIntOrDefault(envPrefix+".delay.h", 6),
+		LookbackDays:      env.GetIntOrDefault(envPrefix+".lookback.days", 2),
+		SnapshotLookbackH: env.GetIntOrDefault(envPrefix+".snapshot.lookback.h", 2),
+		Depth:             env.GetIntOrDefault(envPrefix+".depth", 20),
+		IntervalMs:        env.GetInt64OrDefault(envPrefix+".interval.ms", 1000),
+	}
+	if cfg.Depth <= 0 || cfg.IntervalMs <= 0 || 3600_000%cfg.IntervalMs != 0 {
+		panic(fmt.Sprintf("invalid book depth %d or interval %dms, interval must divide an hour", cfg.Depth, cfg.IntervalMs))
+	}
+	return cfg
+}
@@ -0,0 +1,21 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+)
+
+type CompactionCfg struct {
+	Enabled      bool  `yaml:"enabled"`
+	PeriodM      int   `yaml:"period.m"`
+	DayDelayH    int   `yaml:"day.delay.h"`
+	MaxPartBytes int64 `yaml:"max.part.bytes"`
+}
+
+func NewCompactionCfgFromEnv(envPrefix string) *CompactionCfg {
+	return &CompactionCfg{
+		Enabled:      env.GetBoolOrDefault(envPrefix+".enabled", false),
+		PeriodM:      env.GetIntOrDefault(envPrefix+".period.m", 60),
+		DayDelayH:    env.GetIntOrDefault(envPrefix+".day.delay.h", 24),
+		MaxPartBytes: env.GetInt64OrDefault(envPrefix+".max.part.bytes", 256<<20),
+	}
+}
@@ -6,23 +6,46 @@ import (
 )
 
 type AppConfig struct {
-	ZkCfg          *ZkConfig            `yaml:"zk"`
-	B2Cfg          *B2Config            `yaml:"b2"`
-	DwarfURIConfig *cconf.BaseUriConfig `yaml:"dwarf.uri"`
-	SocratesCfg    *conf.CsRepoConfig   `yaml:"socrates"`
-	BinanceSpotCfg *BinanceMarketCfg    `yaml:"binance.spot"`
-	BinanceUSDCfg  *BinanceMarketCfg    `yaml:"binance.usd"`
-	BinanceCoinCfg *BinanceMarketCfg    `yaml:"binance.coin"`
+	ZkCfg            *conf.ZkConfig          `yaml:"zk,omitempty"`
+	ObjectStoreCfg   *ObjectStoreCfg         `yaml:"object.store"`
+	ParquetCfg       *ParquetLayoutCfg       `yaml:"parquet"`
+	CompactionCfg    *CompactionCfg          `yaml:"compaction"`
+	ExInfoArchiveCfg *ExchangeInfoArchiveCfg `yaml:"exchange.info.archive"`
+	BookCfg          *BookReconstructionCfg  `yaml:"book.reconstruction"`
+	MidBarsCfg       *MidBarsCfg             `yaml:"mid.bars"`
+	SchedulingCfg    *SchedulingCfg          `yaml:"scheduling"`
+	DwarfURIConfig   *cconf.BaseUriConfig    `yaml:"dwarf.uri"`
+	SocratesCfg      *conf.CsRepoConfig      `yaml:"socrates"`
+	KeyScanCfg       *conf.CsKeyScanCfg      `yaml:"socrates.key.scan"`
+	LedgerCfg        *ArchiveLedgerCfg       `yaml:"archive.ledger"`
+	KeyLockCfg       *KeyLockCfg             `yaml:"key.lock"`
+	BinanceSpotCfg   *BinanceMarketCfg       `yaml:"binance.spot"`
+	BinanceUSDCfg    *BinanceMarketCfg       `yaml:"binance.usd"`
+	BinanceCoinCfg   *BinanceMarketCfg       `yaml:"binance.coin"`
 }
 
 func AppConfigFromEnv(prefix string) *AppConfig {
+	keyLockCfg := NewKeyLockCfgFromEnv("key.lock")
+	var zkCfg *conf.ZkConfig
+	if keyLockCfg.Type == ZkKeyLocker {
+		zkCfg = conf.ZkConfigFromEnv("zk")
+	}
 	return &AppConfig{
-		ZkCfg:          ZkConfigFromEnv("zk"),
-		B2Cfg:          B2ConfigFromEnv("b2"),
-		SocratesCfg:    conf.NewCsRepoConfigFromEnv("socrates"),
-		DwarfURIConfig: cconf.NewBaseUriConfigFromEnv("dwarf.uri"),
-		BinanceSpotCfg: NewBinanceMarketCfg("binance.spot"),
-		BinanceUSDCfg:  NewBinanceMarketCfg("binance.usd"),
-		BinanceCoinCfg: NewBinanceMarketCfg("binance.coin"),
+		ZkCfg:            zkCfg,
+		ObjectStoreCfg:   NewObjectStoreCfgFromEnv("object.store"),
+		ParquetCfg:       NewParquetLayoutCfgFromEnv("parquet"),
+		CompactionCfg:    NewCompactionCfgFromEnv("compaction"),
+		ExInfoArchiveCfg: NewExchangeInfoArchiveCfgFromEnv("exchange.info.archive"),
+		BookCfg:          NewBookReconstructionCfgFromEnv("book.reconstruction"),
+		MidBarsCfg:       NewMidBarsCfgFromEnv("mid.bars"),
+		SchedulingCfg:    NewSchedulingCfgFromEnv("scheduling"),
+		SocratesCfg:      conf.NewCsRepoConfigFromEnv("socrates"),
+		KeyScanCfg:       conf.NewCsKeyScanCfgFromEnv("socrates.key.scan"),
+		LedgerCfg:        NewArchiveLedgerCfgFromEnv("archive.ledger"),
+		KeyLockCfg:       keyLockCfg,
+		DwarfURIConfig:   cconf.NewBaseUriConfigFromEnv("dwarf.uri"),
+		BinanceSpotCfg:   NewBinanceMarketCfg("binance.spot"),
+		BinanceUSDCfg:    NewBinanceMarketCfg("binance.usd"),
+		BinanceCoinCfg:   NewBinanceMarketCfg("binance.coin"),
 	}
 }
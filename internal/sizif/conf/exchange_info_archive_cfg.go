@@ -0,0 +1,21 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+)
+
+// ExchangeInfoArchiveCfg sets how often days of exchange info are archived. A day is
+// archived DayDelayH hours after its end.
+type ExchangeInfoArchiveCfg struct {
+	Enabled   bool `yaml:"enabled"`
+	PeriodM   int  `yaml:"period.m"`
+	DayDelayH int  `yaml:"day.delay.h"`
+}
+
+func NewExchangeInfoArchiveCfgFromEnv(envPrefix string) *ExchangeInfoArchiveCfg {
+	return &ExchangeInfoArchiveCfg{
+		Enabled:   env.GetBoolOrDefault(envPrefix+".enabled", false),
+		PeriodM:   env.GetIntOrDefault(envPrefix+".period.m", 60),
+		DayDelayH: env.GetIntOrDefault(envPrefix+".day.delay.h", 1),
+	}
+}
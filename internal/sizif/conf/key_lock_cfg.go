@@ -0,0 +1,42 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+	"fmt"
+)
+
+type KeyLockerType string
+
+const (
+	ZkKeyLocker KeyLockerType = "zk"
+	CsKeyLocker KeyLockerType = "cassandra"
+)
+
+// KeyLockCfg selects the backend keys and manifests are locked in and key watermarks are
+// kept in. Cassandra locks are leases renewed by their holder, a lock of a crashed holder
+// is taken over after LeaseS seconds. ZooKeeper is not used with the Cassandra backend.
+type KeyLockCfg struct {
+	Type               KeyLockerType `yaml:"type"`
+	TableName          string        `yaml:"table,omitempty"`
+	WatermarkTableName string        `yaml:"watermark.table,omitempty"`
+	LeaseS             int           `yaml:"lease.s,omitempty"`
+}
+
+func NewKeyLockCfgFromEnv(envPrefix string) *KeyLockCfg {
+	cfg := &KeyLockCfg{
+		Type: KeyLockerType(env.GetStringOrDefault(envPrefix+".type", string(ZkKeyLocker))),
+	}
+	switch cfg.Type {
+	case ZkKeyLocker:
+	case CsKeyLocker:
+		cfg.TableName = env.GetStringOrDefault(envPrefix+".table", "key_locks")
+		cfg.WatermarkTableName = env.GetStringOrDefault(envPrefix+".watermark.table", "key_watermarks")
+		cfg.LeaseS = env.GetIntOrDefault(envPrefix+".lease.s", 300)
+		if cfg.LeaseS < 3 {
+			panic(fmt.Sprintf("invalid key lock lease %ds", cfg.LeaseS))
+		}
+	default:
+		panic("unknown key locker type " + cfg.Type)
+	}
+	return cfg
+}
@@ -0,0 +1,35 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+	"fmt"
+	"strconv"
+)
+
+// MidBarsCfg sets the derived job aggregating archived book ticks into mid price bars of
+// every interval of IntervalsMs. Hours are aggregated once their book ticks are recorded in
+// the ledger and DelayH hours passed after their end, only within the last LookbackDays days.
+type MidBarsCfg struct {
+	Enabled      bool    `yaml:"enabled"`
+	PeriodM      int     `yaml:"period.m"`
+	DelayH       int     `yaml:"delay.h"`
+	LookbackDays int     `yaml:"lookback.days"`
+	IntervalsMs  []int64 `yaml:"intervals.ms"`
+}
+
+func NewMidBarsCfgFromEnv(envPrefix string) *MidBarsCfg {
+	cfg := &MidBarsCfg{
+		Enabled:      env.GetBoolOrDefault(envPrefix+".enabled", false),
+		PeriodM:      env.GetIntOrDefault(envPrefix+".period.m", 30),
+		DelayH:       env.GetIntOrDefault(envPrefix+".delay.h", 6),
+		LookbackDays: env.GetIntOrDefault(envPrefix+".lookback.days", 2),
+	}
+	for _, interval := range env.GetListOrDefault(envPrefix+".intervals.ms", []string{"1000", "60000"}) {
+		intervalMs, err := strconv.ParseInt(interval, 10, 64)
+		if err != nil || intervalMs <= 0 || 3600_000%intervalMs != 0 {
+			panic(fmt.Sprintf("invalid bar interval %s, interval must divide an hour", interval))
+		}
+		cfg.IntervalsMs = append(cfg.IntervalsMs, intervalMs)
+	}
+	return cfg
+}
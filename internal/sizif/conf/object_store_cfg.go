@@ -0,0 +1,46 @@
+package conf
+
+import (
+	"DeltaReceiver/internal/common/conf"
+	"DeltaReceiver/pkg/env"
+)
+
+type ObjectStoreType string
+
+const (
+	B2ObjectStore ObjectStoreType = "b2"
+	S3ObjectStore ObjectStoreType = "s3"
+	FsObjectStore ObjectStoreType = "fs"
+)
+
+// ObjectStoreCfg selects the backend parquet files are uploaded to. Only the settings of
+// the selected backend are read, B2 settings keep their historical "b2" prefix.
+type ObjectStoreCfg struct {
+	Type  ObjectStoreType `yaml:"type"`
+	B2Cfg *B2Config       `yaml:"b2,omitempty"`
+	S3Cfg *conf.S3Cfg     `yaml:"s3,omitempty"`
+	FsCfg *FsStoreCfg     `yaml:"fs,omitempty"`
+}
+
+type FsStoreCfg struct {
+	RootDir string `yaml:"root.dir"`
+}
+
+func NewObjectStoreCfgFromEnv(envPrefix string) *ObjectStoreCfg {
+	cfg := &ObjectStoreCfg{
+		Type: ObjectStoreType(env.GetStringOrDefault(envPrefix+".type", string(B2ObjectStore))),
+	}
+	switch cfg.Type {
+	case B2ObjectStore:
+		cfg.B2Cfg = B2ConfigFromEnv("b2")
+	case S3ObjectStore:
+		cfg.S3Cfg = conf.NewS3CfgFromEnv(envPrefix + ".s3")
+	case FsObjectStore:
+		cfg.FsCfg = &FsStoreCfg{
+			RootDir: env.GetStringOrDefault(envPrefix+".fs.root.dir", "/var/lib/sizif/objects"),
+		}
+	default:
+		panic("unknown object store type " + cfg.Type)
+	}
+	return cfg
+}
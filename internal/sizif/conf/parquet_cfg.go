@@ -0,0 +1,93 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+)
+
+type ParquetCompression string
+
+const (
+	ZstdCompression         ParquetCompression = "zstd"
+	Lz4Compression          ParquetCompression = "lz4"
+	SnappyCompression       ParquetCompression = "snappy"
+	UncompressedCompression ParquetCompression = "none"
+)
+
+// ParquetCfg is a layout of parquet files of one data type. Column names are the names from
+// the parquet struct tags of the stored model.
+type ParquetCfg struct {
+	Compression       ParquetCompression `yaml:"compression"`
+	ZstdLevel         int                `yaml:"zstd.level"`
+	RowGroupRows      int64              `yaml:"row.group.rows"`
+	PageBufferSize    int                `yaml:"page.buffer.size"`
+	SortingColumns    []string           `yaml:"sorting.columns"`
+	DictionaryColumns []string           `yaml:"dictionary.columns"`
+	BloomFilterCols   []string           `yaml:"bloom.filter.columns"`
+	BloomFilterBits   int                `yaml:"bloom.filter.bits"`
+}
+
+type ParquetLayoutCfg struct {
+	DeltasCfg       *ParquetCfg `yaml:"deltas"`
+	BookTicksCfg    *ParquetCfg `yaml:"book.ticks"`
+	SnapshotsCfg    *ParquetCfg `yaml:"snapshots"`
+	ExchangeInfoCfg *ParquetCfg `yaml:"exchange.info"`
+	InstrumentsCfg  *ParquetCfg `yaml:"instruments"`
+	BookStatesCfg   *ParquetCfg `yaml:"book.states"`
+	MidBarsCfg      *ParquetCfg `yaml:"mid.bars"`
+}
+
+func NewParquetLayoutCfgFromEnv(envPrefix string) *ParquetLayoutCfg {
+	return &ParquetLayoutCfg{
+		DeltasCfg: NewParquetCfgFromEnv(envPrefix+".deltas", &ParquetCfg{
+			SortingColumns:    []string{"timestampMs", "updateId"},
+			DictionaryColumns: []string{"symbol", "price"},
+			BloomFilterCols:   []string{"symbol", "price"},
+		}),
+		BookTicksCfg: NewParquetCfgFromEnv(envPrefix+".book.ticks", &ParquetCfg{
+			SortingColumns:    []string{"timestampMs", "updateId"},
+			DictionaryColumns: []string{"symbol", "bidPrice", "askPrice"},
+			BloomFilterCols:   []string{"symbol"},
+		}),
+		SnapshotsCfg: NewParquetCfgFromEnv(envPrefix+".snapshots", &ParquetCfg{
+			SortingColumns:    []string{"timestampMs", "lastUpdateId"},
+			DictionaryColumns: []string{"symbol"},
+			BloomFilterCols:   []string{"symbol", "price"},
+		}),
+		ExchangeInfoCfg: NewParquetCfgFromEnv(envPrefix+".exchange.info", &ParquetCfg{
+			SortingColumns: []string{"timestampMs"},
+		}),
+		InstrumentsCfg: NewParquetCfgFromEnv(envPrefix+".instruments", &ParquetCfg{
+			SortingColumns:    []string{"timestampMs", "symbol"},
+			DictionaryColumns: []string{"symbol", "status", "baseAsset", "quoteAsset"},
+			BloomFilterCols:   []string{"symbol"},
+		}),
+		BookStatesCfg: NewParquetCfgFromEnv(envPrefix+".book.states", &ParquetCfg{
+			SortingColumns:    []string{"timestampMs"},
+			DictionaryColumns: []string{"symbol"},
+		}),
+		MidBarsCfg: NewParquetCfgFromEnv(envPrefix+".mid.bars", &ParquetCfg{
+			SortingColumns:    []string{"intervalMs", "timestampMs"},
+			DictionaryColumns: []string{"symbol", "intervalMs"},
+		}),
+	}
+}
+
+// NewParquetCfgFromEnv takes column defaults from defaults, which differ between data types.
+func NewParquetCfgFromEnv(envPrefix string, defaults *ParquetCfg) *ParquetCfg {
+	compression := ParquetCompression(env.GetStringOrDefault(envPrefix+".compression", string(ZstdCompression)))
+	switch compression {
+	case ZstdCompression, Lz4Compression, SnappyCompression, UncompressedCompression:
+	default:
+		panic("unknown parquet compression " + compression)
+	}
+	return &ParquetCfg{
+		Compression:       compression,
+		ZstdLevel:         env.GetIntOrDefault(envPrefix+".zstd.level", 3),
+		RowGroupRows:      env.GetInt64OrDefault(envPrefix+".row.group.rows", 1_000_000),
+		PageBufferSize:    env.GetIntOrDefault(envPrefix+".page.buffer.size", 1<<20),
+		SortingColumns:    env.GetListOrDefault(envPrefix+".sorting.columns", defaults.SortingColumns),
+		DictionaryColumns: env.GetListOrDefault(envPrefix+".dictionary.columns", defaults.DictionaryColumns),
+		BloomFilterCols:   env.GetListOrDefault(envPrefix+".bloom.filter.columns", defaults.BloomFilterCols),
+		BloomFilterBits:   env.GetIntOrDefault(envPrefix+".bloom.filter.bits", 10),
+	}
+}
@@ -0,0 +1,58 @@
+package conf
+
+import (
+	"DeltaReceiver/pkg/env"
+	"fmt"
+	"time"
+)
+
+type SchedulingCfg struct {
+	DeltasCfg    *KeySchedulingCfg `yaml:"deltas"`
+	BookTicksCfg *KeySchedulingCfg `yaml:"book.ticks"`
+	SnapshotsCfg *KeySchedulingCfg `yaml:"snapshots"`
+}
+
+// KeySchedulingCfg sets how often keys of a data type are scanned and bounds hours of
+// scheduled keys. Keys of the last CutoffH hours may still get data, keys before
+// ProcessFrom are never archived.
+type KeySchedulingCfg struct {
+	PeriodS     int    `yaml:"period.s"`
+	CutoffH     int    `yaml:"cutoff.h"`
+	ProcessFrom string `yaml:"process.from"`
+	fromHourNo  int64
+}
+
+const processFromLayout = "2006-01-02T15:04:05"
+
+// NewSchedulingCfgFromEnv reads lower bounds from process.<data type>.from, UTC.
+func NewSchedulingCfgFromEnv(envPrefix string) *SchedulingCfg {
+	return &SchedulingCfg{
+		DeltasCfg:    newKeySchedulingCfgFromEnv(envPrefix+".deltas", "process.deltas.from"),
+		BookTicksCfg: newKeySchedulingCfgFromEnv(envPrefix+".book.ticks", "process.book.ticks.from"),
+		SnapshotsCfg: newKeySchedulingCfgFromEnv(envPrefix+".snapshots", "process.snapshots.from"),
+	}
+}
+
+func newKeySchedulingCfgFromEnv(envPrefix string, processFromKey string) *KeySchedulingCfg {
+	cfg := &KeySchedulingCfg{
+		PeriodS:     env.GetIntOrDefault(envPrefix+".period.s", 300),
+		CutoffH:     env.GetIntOrDefault(envPrefix+".cutoff.h", 3),
+		ProcessFrom: env.GetStringOrDefault(processFromKey, ""),
+	}
+	if cfg.PeriodS < 1 || cfg.CutoffH < 1 {
+		panic(fmt.Sprintf("invalid %s period %d s or cutoff %d h", envPrefix, cfg.PeriodS, cfg.CutoffH))
+	}
+	if cfg.ProcessFrom != "" {
+		processFrom, err := time.Parse(processFromLayout, cfg.ProcessFrom)
+		if err != nil {
+			panic(err)
+		}
+		cfg.fromHourNo = processFrom.Unix() / 3600
+	}
+	return cfg
+}
+
+// FromHourNo returns the first hour to archive, 0 if all hours are archived.
+func (s *KeySchedulingCfg) FromHourNo() int64 {
+	return s.fromHourNo
+}
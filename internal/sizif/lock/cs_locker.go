@@ -0,0 +1,164 @@
+package lock
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/svc"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocql/gocql"
+	"go.uber.org/zap"
+)
+
+// CsLocker locks keys with Cassandra lightweight transactions. A lock is a lease renewed
+// by its holder until Unlock, so a lock of a crashed holder is taken over once its lease
+// expires. Every acquisition increments the token of the key, renewals, releases and
+// CheckLocked are conditional on it, so a holder whose lease was taken over notices it at
+// its next renewal or check. Writes made after CheckLocked are not conditional on the
+// token, a holder stalled for longer than the lease right after the check may still write
+// once, the same as with ZooKeeper session expiry.
+type CsLocker struct {
+	logger           *zap.Logger
+	session          *gocql.Session
+	prefix           string
+	owner            string
+	lease            time.Duration
+	mu               sync.Mutex
+	leases           map[model.ProcessingKey]*csLease
+	insertStatement  string
+	acquireStatement string
+	renewStatement   string
+	releaseStatement string
+	selectStatement  string
+}
+
+type csLease struct {
+	token int64
+	lost  atomic.Bool
+	stop  chan struct{}
+}
+
+func NewCsLocker(prefix string, session *gocql.Session, tableName string, lease time.Duration) *CsLocker {
+	return &CsLocker{
+		logger:           log.GetLogger("CsLocker_" + prefix),
+		session:          session,
+		prefix:           prefix,
+		owner:            gocql.TimeUUID().String(),
+		lease:            lease,
+		leases:           make(map[model.ProcessingKey]*csLease),
+		insertStatement:  fmt.Sprintf("INSERT INTO %s (prefix, symbol, hour, owner, token, expires_at_ms) VALUES (?, ?, ?, ?, ?, ?) IF NOT EXISTS", tableName),
+		acquireStatement: fmt.Sprintf("UPDATE %s SET owner = ?, token = ?, expires_at_ms = ? WHERE prefix = ? AND symbol = ? AND hour = ? IF token = ?", tableName),
+		renewStatement:   fmt.Sprintf("UPDATE %s SET expires_at_ms = ? WHERE prefix = ? AND symbol = ? AND hour = ? IF owner = ? AND token = ?", tableName),
+		releaseStatement: fmt.Sprintf("UPDATE %s SET owner = null, expires_at_ms = 0 WHERE prefix = ? AND symbol = ? AND hour = ? IF owner = ? AND token = ?", tableName),
+		selectStatement:  fmt.Sprintf("SELECT owner, token, expires_at_ms FROM %s WHERE prefix = ? AND symbol = ? AND hour = ?", tableName),
+	}
+}
+
+func (s *CsLocker) Lock(ctx context.Context, key *model.ProcessingKey) (svc.LockOpStatus, error) {
+	expiresAtMs := time.Now().Add(s.lease).UnixMilli()
+	prev := make(map[string]interface{})
+	applied, err := s.session.Query(s.insertStatement, s.prefix, key.Symbol, key.HourNo, s.owner, int64(1), expiresAtMs).WithContext(ctx).MapScanCAS(prev)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return svc.AlreadyLocked, err
+	}
+	token := int64(1)
+	if !applied {
+		prevToken, _ := prev["token"].(int64)
+		prevExpiresAtMs, _ := prev["expires_at_ms"].(int64)
+		if prevExpiresAtMs > time.Now().UnixMilli() {
+			return svc.AlreadyLocked, nil
+		}
+		token = prevToken + 1
+		applied, err = s.session.Query(s.acquireStatement, s.owner, token, expiresAtMs, s.prefix, key.Symbol, key.HourNo, prevToken).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+		if err != nil {
+			s.logger.Error(err.Error())
+			return svc.AlreadyLocked, err
+		}
+		if !applied {
+			return svc.AlreadyLocked, nil
+		}
+	}
+	lease := &csLease{token: token, stop: make(chan struct{})}
+	s.mu.Lock()
+	s.leases[*key] = lease
+	s.mu.Unlock()
+	go s.renew(*key, lease)
+	s.logger.Info(fmt.Sprintf("key %s locked with token %d", key, token))
+	return svc.LockedSuccessfully, nil
+}
+
+// renew extends the lease every third of its duration until it is released or lost.
+func (s *CsLocker) renew(key model.ProcessingKey, lease *csLease) {
+	ticker := time.NewTicker(s.lease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lease.stop:
+			return
+		case <-ticker.C:
+		}
+		expiresAtMs := time.Now().Add(s.lease).UnixMilli()
+		applied, err := s.session.Query(s.renewStatement, expiresAtMs, s.prefix, key.Symbol, key.HourNo, s.owner, lease.token).MapScanCAS(make(map[string]interface{}))
+		if err != nil {
+			s.logger.Error(err.Error())
+			continue
+		}
+		if !applied {
+			s.logger.Error(fmt.Sprintf("lease of key %s with token %d lost", &key, lease.token))
+			lease.lost.Store(true)
+			return
+		}
+	}
+}
+
+func (s *CsLocker) Unlock(ctx context.Context, key *model.ProcessingKey) error {
+	s.mu.Lock()
+	lease, ok := s.leases[*key]
+	delete(s.leases, *key)
+	s.mu.Unlock()
+	if !ok {
+		s.logger.Warn(fmt.Sprintf("no lease for key %s", key))
+		return nil
+	}
+	close(lease.stop)
+	var err error
+	for i := 0; i < 3; i++ {
+		var applied bool
+		applied, err = s.session.Query(s.releaseStatement, s.prefix, key.Symbol, key.HourNo, s.owner, lease.token).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+		if err == nil {
+			if !applied {
+				s.logger.Warn(fmt.Sprintf("lease of key %s with token %d already taken over", key, lease.token))
+			}
+			return nil
+		}
+		s.logger.Error(err.Error())
+	}
+	return err
+}
+
+// CheckLocked reads the lock with SERIAL consistency, so a lease taken over by another
+// holder is seen even if its renewal has not failed yet.
+func (s *CsLocker) CheckLocked(ctx context.Context, key *model.ProcessingKey) error {
+	s.mu.Lock()
+	lease, ok := s.leases[*key]
+	s.mu.Unlock()
+	if !ok || lease.lost.Load() {
+		return fmt.Errorf("%w: %s", svc.LockLostErr, key)
+	}
+	var owner string
+	var token, expiresAtMs int64
+	err := s.session.Query(s.selectStatement, s.prefix, key.Symbol, key.HourNo).WithContext(ctx).Consistency(gocql.Consistency(gocql.Serial)).Scan(&owner, &token, &expiresAtMs)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return err
+	}
+	if owner != s.owner || token != lease.token || expiresAtMs <= time.Now().UnixMilli() {
+		return fmt.Errorf("%w: %s has token %d", svc.LockLostErr, key, token)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package lock
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/svc"
+	"context"
+	"time"
+)
+
+// CsMutex is a blocking mutex on leases of a CsLocker, a mutex of a crashed holder is
+// taken over once its lease expires. Names are kept as symbols of hour 0.
+type CsMutex struct {
+	locker *CsLocker
+}
+
+func NewCsMutex(locker *CsLocker) *CsMutex {
+	return &CsMutex{locker: locker}
+}
+
+const csMutexRetryPeriod = time.Second
+
+// Lock waits until the mutex with the name is acquired or ctx is done.
+func (s *CsMutex) Lock(ctx context.Context, name string) (func(), error) {
+	key := model.ProcessingKey{Symbol: name}
+	for {
+		lockStatus, err := s.locker.Lock(ctx, &key)
+		if err != nil {
+			return nil, err
+		}
+		if lockStatus == svc.LockedSuccessfully {
+			return func() {
+				// failures are logged by the locker and the lease expires anyway
+				_ = s.locker.Unlock(context.Background(), &key)
+			}, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(csMutexRetryPeriod):
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gocql/gocql"
+)
+
+// CsWatermark persists an hour number in a Cassandra row updated with lightweight
+// transactions, so it survives restarts and is shared by all sizif replicas.
+type CsWatermark struct {
+	session          *gocql.Session
+	name             string
+	selectStatement  string
+	insertStatement  string
+	advanceStatement string
+}
+
+func NewCsWatermark(name string, session *gocql.Session, tableName string) *CsWatermark {
+	return &CsWatermark{
+		session:          session,
+		name:             name,
+		selectStatement:  fmt.Sprintf("SELECT hour FROM %s WHERE name = ?", tableName),
+		insertStatement:  fmt.Sprintf("INSERT INTO %s (name, hour) VALUES (?, ?) IF NOT EXISTS", tableName),
+		advanceStatement: fmt.Sprintf("UPDATE %s SET hour = ? WHERE name = ? IF hour < ?", tableName),
+	}
+}
+
+// Get returns 0 if the watermark was never set.
+func (s *CsWatermark) Get(ctx context.Context) (int64, error) {
+	var hourNo int64
+	err := s.session.Query(s.selectStatement, s.name).WithContext(ctx).Consistency(gocql.Consistency(gocql.Serial)).Scan(&hourNo)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return 0, nil
+	}
+	return hourNo, err
+}
+
+// Advance sets the watermark to hourNo unless it is already further.
+func (s *CsWatermark) Advance(ctx context.Context, hourNo int64) error {
+	applied, err := s.session.Query(s.insertStatement, s.name, hourNo).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+	if err != nil || applied {
+		return err
+	}
+	_, err = s.session.Query(s.advanceStatement, hourNo, s.name, hourNo).WithContext(ctx).MapScanCAS(make(map[string]interface{}))
+	return err
+}
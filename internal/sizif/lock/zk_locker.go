@@ -7,12 +7,13 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/go-zookeeper/zk"
 	"go.uber.org/zap"
 )
 
+// ZkLocker locks keys with ephemeral nodes, so locks of a worker which lost its session
+// are released by ZooKeeper.
 type ZkLocker struct {
 	logger *zap.Logger
 	prefix string
@@ -27,17 +28,11 @@ func NewZkLocker(prefix string, conn *zk.Conn) *ZkLocker {
 	}
 }
 
-type LockType []byte
-
-var (
-	locked    LockType      = []byte("1")
-	processed LockType      = []byte("2")
-	ttl       time.Duration = time.Hour * 24
-)
+var locked = []byte("1")
 
 func (s ZkLocker) Lock(ctx context.Context, key *model.ProcessingKey) (svc.LockOpStatus, error) {
 	lockPath := s.createZkPath(key)
-	_, err := s.conn.CreateTTL(lockPath, locked, zk.FlagTTL, zk.WorldACL(zk.PermAll), ttl)
+	_, err := s.conn.Create(lockPath, locked, zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
 	if err != nil {
 		if err == zk.ErrNoNode {
 			lockSubPaths := strings.Split(lockPath, "/")
@@ -61,12 +56,6 @@ func (s ZkLocker) Lock(ctx context.Context, key *model.ProcessingKey) (svc.LockO
 			}
 			return s.Lock(ctx, key)
 		} else if err == zk.ErrNodeExists {
-			if status, _, err := s.conn.Get(lockPath); err != nil {
-				s.logger.Error(err.Error())
-				return svc.AlreadyLocked, err
-			} else if status[0] == processed[0] {
-				return svc.AlreadyProcessed, nil
-			}
 			return svc.AlreadyLocked, nil
 		} else {
 			s.logger.Error(err.Error())
@@ -94,16 +83,17 @@ func (s ZkLocker) Unlock(ctx context.Context, key *model.ProcessingKey) error {
 	return err
 }
 
-func (s ZkLocker) MarkProcessed(ctx context.Context, key *model.ProcessingKey) error {
+// CheckLocked checks that the lock node is owned by the session of the locker.
+func (s ZkLocker) CheckLocked(ctx context.Context, key *model.ProcessingKey) error {
 	lockPath := s.createZkPath(key)
-	_, err := s.conn.Set(lockPath, processed, 0)
+	exists, stat, err := s.conn.Exists(lockPath)
 	if err != nil {
-		if err == zk.ErrBadVersion {
-			s.logger.Error("unexpected lock version")
-		}
 		s.logger.Error(err.Error())
 		return err
 	}
+	if !exists || stat.EphemeralOwner != s.conn.SessionID() {
+		return fmt.Errorf("%w: %s", svc.LockLostErr, lockPath)
+	}
 	return nil
 }
 
@@ -0,0 +1,81 @@
+package lock
+
+import (
+	"DeltaReceiver/pkg/log"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-zookeeper/zk"
+	"go.uber.org/zap"
+)
+
+// ZkMutex is a blocking mutex on ephemeral nodes, so a lock of a crashed holder is released
+// together with its session.
+type ZkMutex struct {
+	logger *zap.Logger
+	prefix string
+	conn   *zk.Conn
+}
+
+func NewZkMutex(prefix string, conn *zk.Conn) *ZkMutex {
+	return &ZkMutex{
+		logger: log.GetLogger("ZkMutex_" + prefix),
+		prefix: prefix,
+		conn:   conn,
+	}
+}
+
+const mutexRecheckPeriod = 5 * time.Second
+
+// Lock waits until the mutex with the name is acquired or ctx is done.
+func (s *ZkMutex) Lock(ctx context.Context, name string) (func(), error) {
+	path := fmt.Sprintf("/_mutex/%s/%s", s.prefix, name)
+	for {
+		_, err := s.conn.Create(path, []byte{}, zk.FlagEphemeral, zk.WorldACL(zk.PermAll))
+		if err == nil {
+			return func() {
+				if err := s.conn.Delete(path, -1); err != nil && !errors.Is(err, zk.ErrNoNode) {
+					s.logger.Error(err.Error())
+				}
+			}, nil
+		}
+		if errors.Is(err, zk.ErrNoNode) {
+			if err = s.createParents(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !errors.Is(err, zk.ErrNodeExists) {
+			return nil, err
+		}
+		exists, _, events, err := s.conn.ExistsW(path)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-events:
+		case <-time.After(mutexRecheckPeriod):
+		}
+	}
+}
+
+func (s *ZkMutex) createParents(path string) error {
+	nodes := strings.Split(path, "/")
+	nodePath := ""
+	for _, node := range nodes[1 : len(nodes)-1] {
+		nodePath += "/" + node
+		_, err := s.conn.Create(nodePath, []byte{}, 0, zk.WorldACL(zk.PermAll))
+		if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-zookeeper/zk"
+)
+
+// ZkWatermark persists an hour number in a persistent node, so it survives restarts and is
+// shared by all sizif replicas.
+type ZkWatermark struct {
+	path string
+	conn *zk.Conn
+}
+
+func NewZkWatermark(prefix string, conn *zk.Conn) *ZkWatermark {
+	return &ZkWatermark{
+		path: fmt.Sprintf("/_watermark/%s", prefix),
+		conn: conn,
+	}
+}
+
+// Get returns 0 if the watermark was never set.
+func (s *ZkWatermark) Get(ctx context.Context) (int64, error) {
+	data, _, err := s.conn.Get(s.path)
+	if errors.Is(err, zk.ErrNoNode) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+// Advance sets the watermark to hourNo unless it is already further.
+func (s *ZkWatermark) Advance(ctx context.Context, hourNo int64) error {
+	for {
+		data, stat, err := s.conn.Get(s.path)
+		if errors.Is(err, zk.ErrNoNode) {
+			err = s.create(hourNo)
+			if errors.Is(err, zk.ErrNodeExists) {
+				continue
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if current, err := strconv.ParseInt(string(data), 10, 64); err == nil && current >= hourNo {
+			return nil
+		}
+		_, err = s.conn.Set(s.path, []byte(strconv.FormatInt(hourNo, 10)), stat.Version)
+		if errors.Is(err, zk.ErrBadVersion) {
+			continue
+		}
+		return err
+	}
+}
+
+func (s *ZkWatermark) create(hourNo int64) error {
+	nodes := strings.Split(s.path, "/")
+	nodePath := ""
+	for _, node := range nodes[1 : len(nodes)-1] {
+		nodePath += "/" + node
+		_, err := s.conn.Create(nodePath, []byte{}, 0, zk.WorldACL(zk.PermAll))
+		if err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return err
+		}
+	}
+	_, err := s.conn.Create(s.path, []byte(strconv.FormatInt(hourNo, 10)), 0, zk.WorldACL(zk.PermAll))
+	return err
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type BookReconstructionMetrics struct {
+	reconstructedHours prometheus.Counter
+	unsyncedStates     prometheus.Counter
+	failedHours        prometheus.Counter
+}
+
+func NewBookReconstructionMetrics(dataType string) *BookReconstructionMetrics {
+	return &BookReconstructionMetrics{
+		reconstructedHours: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_reconstructed_hours", dataType),
+		}),
+		unsyncedStates: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_unsynced_states", dataType),
+		}),
+		failedHours: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_reconstruction_failed_hours", dataType),
+		}),
+	}
+}
+
+func (s BookReconstructionMetrics) IncReconstructedHours(numUnsyncedStates int) {
+	s.reconstructedHours.Inc()
+	s.unsyncedStates.Add(float64(numUnsyncedStates))
+}
+
+func (s BookReconstructionMetrics) IncFailedHours() {
+	s.failedHours.Inc()
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type CompactionMetrics struct {
+	compactedDays        prometheus.Counter
+	compactedSourceFiles prometheus.Counter
+	failedDays           prometheus.Counter
+}
+
+func NewCompactionMetrics(dataType string) *CompactionMetrics {
+	return &CompactionMetrics{
+		compactedDays: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_compacted_days", dataType),
+		}),
+		compactedSourceFiles: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_compacted_source_files", dataType),
+		}),
+		failedDays: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_compaction_failed_days", dataType),
+		}),
+	}
+}
+
+func (s CompactionMetrics) IncCompactedDays(numSourceFiles int) {
+	s.compactedDays.Inc()
+	s.compactedSourceFiles.Add(float64(numSourceFiles))
+}
+
+func (s CompactionMetrics) IncFailedDays() {
+	s.failedDays.Inc()
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type ExchangeInfoArchiveMetrics struct {
+	archivedDays     prometheus.Counter
+	archivedVersions prometheus.Counter
+	failedDays       prometheus.Counter
+}
+
+func NewExchangeInfoArchiveMetrics(dataType string) *ExchangeInfoArchiveMetrics {
+	return &ExchangeInfoArchiveMetrics{
+		archivedDays: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_archived_days", dataType),
+		}),
+		archivedVersions: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_archived_versions", dataType),
+		}),
+		failedDays: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_archive_failed_days", dataType),
+		}),
+	}
+}
+
+func (s ExchangeInfoArchiveMetrics) IncArchivedDays(numVersions int) {
+	s.archivedDays.Inc()
+	s.archivedVersions.Add(float64(numVersions))
+}
+
+func (s ExchangeInfoArchiveMetrics) IncFailedDays() {
+	s.failedDays.Inc()
+}
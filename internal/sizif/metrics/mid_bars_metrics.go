@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type MidBarsMetrics struct {
+	aggregatedHours prometheus.Counter
+	bars            prometheus.Counter
+	failedHours     prometheus.Counter
+}
+
+func NewMidBarsMetrics(dataType string) *MidBarsMetrics {
+	return &MidBarsMetrics{
+		aggregatedHours: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_aggregated_hours", dataType),
+		}),
+		bars: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_bars", dataType),
+		}),
+		failedHours: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_aggregation_failed_hours", dataType),
+		}),
+	}
+}
+
+func (s MidBarsMetrics) IncAggregatedHours(numBars int) {
+	s.aggregatedHours.Inc()
+	s.bars.Add(float64(numBars))
+}
+
+func (s MidBarsMetrics) IncFailedHours() {
+	s.failedHours.Inc()
+}
@@ -10,7 +10,12 @@ import (
 const SizifMetricsNamespace = "sizif"
 
 type SizifWorkerMetrics struct {
-	invalidDataCounter prometheus.Counter
+	invalidDataCounter        prometheus.Counter
+	verificationFailedCounter prometheus.Counter
+	keyScanErrors             prometheus.Counter
+	keyWatermark              prometheus.Gauge
+	queuedKeys                prometheus.Gauge
+	inFlightKeys              prometheus.Gauge
 }
 
 func NewSizifWorkerMetrics(dataType string) *SizifWorkerMetrics {
@@ -19,9 +24,46 @@ func NewSizifWorkerMetrics(dataType string) *SizifWorkerMetrics {
 			Namespace: SizifMetricsNamespace,
 			Name:      fmt.Sprintf("%s_invalid_data_files", dataType),
 		}),
+		verificationFailedCounter: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_verification_failed_files", dataType),
+		}),
+		keyScanErrors: promauto.NewCounter(prometheus.CounterOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_key_scan_errors", dataType),
+		}),
+		keyWatermark: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_key_watermark_hour", dataType),
+		}),
+		queuedKeys: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_queued_keys", dataType),
+		}),
+		inFlightKeys: promauto.NewGauge(prometheus.GaugeOpts{
+			Namespace: SizifMetricsNamespace,
+			Name:      fmt.Sprintf("%s_in_flight_keys", dataType),
+		}),
 	}
 }
 
 func (s SizifWorkerMetrics) IncInvalidDataCounter() {
 	s.invalidDataCounter.Inc()
 }
+
+func (s SizifWorkerMetrics) IncVerificationFailedCounter() {
+	s.verificationFailedCounter.Inc()
+}
+
+func (s SizifWorkerMetrics) IncKeyScanErrors() {
+	s.keyScanErrors.Inc()
+}
+
+func (s SizifWorkerMetrics) SetKeyWatermark(hourNo int64) {
+	s.keyWatermark.Set(float64(hourNo))
+}
+
+func (s SizifWorkerMetrics) SetScheduledKeys(queued, inFlight int) {
+	s.queuedKeys.Set(float64(queued))
+	s.inFlightKeys.Set(float64(inFlight))
+}
@@ -0,0 +1,58 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/Backblaze/blazer/b2"
+)
+
+type B2Store struct {
+	bucket *b2.Bucket
+}
+
+func NewB2Store(bucket *b2.Bucket) *B2Store {
+	return &B2Store{
+		bucket: bucket,
+	}
+}
+
+func (s *B2Store) Put(ctx context.Context, key string, data []byte) error {
+	objWriter := s.bucket.Object(key).NewWriter(ctx)
+	if _, err := io.Copy(objWriter, bytes.NewReader(data)); err != nil {
+		_ = objWriter.Close()
+		return err
+	}
+	return objWriter.Close()
+}
+
+func (s *B2Store) Get(ctx context.Context, key string) ([]byte, error) {
+	objReader := s.bucket.Object(key).NewReader(ctx)
+	data, err := io.ReadAll(objReader)
+	if errClose := objReader.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil && b2.IsNotExist(err) {
+		return nil, errors.Join(ObjectNotFoundErr, err)
+	}
+	return data, err
+}
+
+func (s *B2Store) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	iter := s.bucket.List(ctx, b2.ListPrefix(prefix))
+	for iter.Next() {
+		keys = append(keys, iter.Object().Name())
+	}
+	return keys, iter.Err()
+}
+
+func (s *B2Store) Delete(ctx context.Context, key string) error {
+	err := s.bucket.Object(key).Delete(ctx)
+	if err != nil && b2.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
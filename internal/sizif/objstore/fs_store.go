@@ -0,0 +1,93 @@
+package objstore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FsStore keeps objects as files under a root directory, the key being the relative path.
+type FsStore struct {
+	rootDir string
+}
+
+func NewFsStore(rootDir string) *FsStore {
+	return &FsStore{
+		rootDir: rootDir,
+	}
+}
+
+func (s *FsStore) Put(_ context.Context, key string, data []byte) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// write to a temp file first, so readers never see a partially written object
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(data)
+	if errClose := tmpFile.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+	}
+	return err
+}
+
+func (s *FsStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.Join(ObjectNotFoundErr, err)
+	}
+	return data, err
+}
+
+func (s *FsStore) List(_ context.Context, prefix string) ([]string, error) {
+	walkRoot := s.rootDir
+	if dir := prefix[:strings.LastIndex(prefix, "/")+1]; dir != "" {
+		walkRoot = s.path(dir)
+	}
+	var keys []string
+	err := filepath.WalkDir(walkRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".tmp") {
+			return nil
+		}
+		relPath, err := filepath.Rel(s.rootDir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relPath)
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (s *FsStore) Delete(_ context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *FsStore) path(key string) string {
+	return filepath.Join(s.rootDir, filepath.FromSlash(key))
+}
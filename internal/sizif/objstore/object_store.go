@@ -0,0 +1,20 @@
+package objstore
+
+import (
+	"context"
+	"errors"
+)
+
+// ObjectStore is a flat key-value blob storage. Keys are slash separated paths relative to
+// the root of the store.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Get returns ObjectNotFoundErr if there is no object with the key.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns keys of all objects starting with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete does not fail if there is no object with the key.
+	Delete(ctx context.Context, key string) error
+}
+
+var ObjectNotFoundErr = errors.New("object not found")
@@ -0,0 +1,41 @@
+package objstore
+
+import (
+	"DeltaReceiver/pkg/s3"
+	"context"
+	"errors"
+)
+
+type S3Store struct {
+	client *s3.S3Client
+}
+
+func NewS3Store(client *s3.S3Client) *S3Store {
+	return &S3Store{
+		client: client,
+	}
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	return s.client.PutObject(ctx, key, data)
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := s.client.GetObject(ctx, key)
+	if errors.Is(err, s3.NoSuchKeyErr) {
+		return nil, errors.Join(ObjectNotFoundErr, err)
+	}
+	return data, err
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string) ([]string, error) {
+	return s.client.ListObjects(ctx, prefix)
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	err := s.client.DeleteObject(ctx, key)
+	if errors.Is(err, s3.NoSuchKeyErr) {
+		return nil
+	}
+	return err
+}
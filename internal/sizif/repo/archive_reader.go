@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/catalog"
+	"DeltaReceiver/internal/sizif/objstore"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// ArchiveReader reads archived rows of a market and data type through day manifests.
+type ArchiveReader[T model.WithTimestampMs] struct {
+	objStore    objstore.ObjectStore
+	catalog     *catalog.Catalog
+	market      bmodel.DataType
+	dataType    string
+	storageName string
+}
+
+func NewArchiveReader[T model.WithTimestampMs](objStore objstore.ObjectStore, market bmodel.DataType, dataType string) *ArchiveReader[T] {
+	return &ArchiveReader[T]{
+		objStore:    objStore,
+		catalog:     catalog.NewCatalog(objStore),
+		market:      market,
+		dataType:    dataType,
+		storageName: catalog.StorageName(market, dataType),
+	}
+}
+
+// Read returns rows of the symbol in [from, to) ordered by time.
+func (s *ArchiveReader[T]) Read(ctx context.Context, symbol string, from, to time.Time) ([]T, error) {
+	files, err := s.catalog.Files(ctx, s.market, s.dataType, symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
+	fromMs, toMs := from.UnixMilli(), to.UnixMilli()
+	var rows []T
+	for _, file := range files {
+		fileRows, err := s.readFile(ctx, file.Key)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range fileRows {
+			// compacted parts cover the whole day
+			if ts := row.GetTimestampMs(); ts >= fromMs && ts < toMs {
+				rows = append(rows, row)
+			}
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b T) int {
+		return cmp.Compare(a.GetTimestampMs(), b.GetTimestampMs())
+	})
+	return rows, nil
+}
+
+// HoleCount sums holes of the symbol files in [from, to), holes of a compacted part are
+// counted for every hour of its day.
+func (s *ArchiveReader[T]) HoleCount(ctx context.Context, symbol string, from, to time.Time) (int, error) {
+	files, err := s.catalog.Files(ctx, s.market, s.dataType, symbol, from, to)
+	if err != nil {
+		return 0, err
+	}
+	holeCount := 0
+	for _, file := range files {
+		holeCount += file.HoleCount
+	}
+	return holeCount, nil
+}
+
+func (s *ArchiveReader[T]) readFile(ctx context.Context, key string) ([]T, error) {
+	data, err := s.objStore.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("object %s not read: %w", key, err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("object %s not opened: %w", key, err)
+	}
+	rows := make([]T, file.NumRows())
+	reader := parquet.NewGenericReader[T](file)
+	n, err := reader.Read(rows)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("object %s not read: %w", key, err)
+	}
+	return rows[:n], reader.Close()
+}
+
+// SymbolHours returns archived keys of the day, hours of compacted parts are taken from
+// their time ranges.
+func (s *ArchiveReader[T]) SymbolHours(ctx context.Context, date time.Time) ([]model.ProcessingKey, error) {
+	manifest, err := s.catalog.Day(ctx, s.market, s.dataType, date)
+	if err != nil || manifest == nil {
+		return nil, err
+	}
+	keys := make(map[model.ProcessingKey]struct{})
+	for _, file := range manifest.Files {
+		if file.Rows == 0 {
+			continue
+		}
+		for hourNo := file.MinTimestampMs / millisInHour; hourNo <= file.MaxTimestampMs/millisInHour; hourNo++ {
+			keys[model.ProcessingKey{Symbol: file.Symbol, HourNo: hourNo}] = struct{}{}
+		}
+	}
+	result := make([]model.ProcessingKey, 0, len(keys))
+	for key := range keys {
+		result = append(result, key)
+	}
+	slices.SortFunc(result, func(a, b model.ProcessingKey) int {
+		return cmp.Or(cmp.Compare(a.HourNo, b.HourNo), strings.Compare(a.Symbol, b.Symbol))
+	})
+	return result, nil
+}
+
+const millisInHour = 60 * 60 * 1000
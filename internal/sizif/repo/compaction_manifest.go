@@ -0,0 +1,83 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/sizif/catalog"
+	"DeltaReceiver/internal/sizif/objstore"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CompactionManifest lists the files which make up a compacted day of a symbol and the
+// hourly objects merged into them so far. Once the manifest exists, readers must take the
+// day from its files only. An hourly object still present in the day directory is a not
+// yet deleted source if its key and checksum are in Sources, otherwise it is late data
+// waiting for the next compaction. Compacted parts not in the manifest are leftovers of a
+// failed run.
+type CompactionManifest struct {
+	Symbol        string               `json:"symbol"`
+	Date          string               `json:"date"`
+	CompactedAtMs int64                `json:"compactedAtMs"`
+	Rows          int64                `json:"rows"`
+	Files         []CompactedFileRef   `json:"files"`
+	Sources       []CompactedSourceRef `json:"sources"`
+}
+
+type CompactedFileRef struct {
+	Key   string `json:"key"`
+	Rows  int64  `json:"rows"`
+	Bytes int64  `json:"bytes"`
+	// Entry is the day manifest entry of the part, it is added again if a run was
+	// interrupted before the day manifest was switched to the parts.
+	Entry catalog.ManifestFile `json:"entry"`
+}
+
+// CompactedSourceRef is an hourly object merged into the parts, Checksum is the hex
+// sha256 of its content.
+type CompactedSourceRef struct {
+	Key      string `json:"key"`
+	Checksum string `json:"checksum"`
+}
+
+const (
+	compactionManifestName = "manifest.json"
+	compactedDirName       = "compacted"
+)
+
+func dayPrefix(storageName, symbol string, date time.Time) string {
+	return fmt.Sprintf("%s/%s/%s/", storageName, symbol, date.Format(time.DateOnly))
+}
+
+func CompactionManifestKey(storageName, symbol string, date time.Time) string {
+	return dayPrefix(storageName, symbol, date) + compactionManifestName
+}
+
+// ReadCompactionManifest returns nil if the day was never compacted.
+func ReadCompactionManifest(ctx context.Context, objStore objstore.ObjectStore, storageName, symbol string, date time.Time) (*CompactionManifest, error) {
+	data, err := objStore.Get(ctx, CompactionManifestKey(storageName, symbol, date))
+	if errors.Is(err, objstore.ObjectNotFoundErr) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest CompactionManifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+func writeCompactionManifest(ctx context.Context, objStore objstore.ObjectStore, storageName string, manifest *CompactionManifest) error {
+	date, err := time.Parse(time.DateOnly, manifest.Date)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return objStore.Put(ctx, CompactionManifestKey(storageName, manifest.Symbol, date), data)
+}
@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/conf"
+	"DeltaReceiver/internal/sizif/svc"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"fmt"
+
+	"github.com/gocql/gocql"
+	"go.uber.org/zap"
+)
+
+// CsArchiveLedger keeps objects archived for keys of a storage in a Cassandra table
+// partitioned by storage name and key.
+type CsArchiveLedger struct {
+	logger          *zap.Logger
+	session         *gocql.Session
+	storageName     string
+	consistency     gocql.Consistency
+	selectStatement string
+	insertStatement string
+	deleteStatement string
+}
+
+func NewCsArchiveLedger(session *gocql.Session, storageName string, cfg *conf.ArchiveLedgerCfg) *CsArchiveLedger {
+	return &CsArchiveLedger{
+		logger:          log.GetLogger("CsArchiveLedger_" + storageName),
+		session:         session,
+		storageName:     storageName,
+		consistency:     cfg.GetConsistency(),
+		selectStatement: fmt.Sprintf("SELECT revision, object_key, checksum, row_count, archived_at_ms FROM %s WHERE storage_name = ? AND symbol = ? AND hour = ?", cfg.TableName),
+		insertStatement: fmt.Sprintf("INSERT INTO %s (storage_name, symbol, hour, revision, object_key, checksum, row_count, archived_at_ms) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", cfg.TableName),
+		deleteStatement: fmt.Sprintf("DELETE FROM %s WHERE storage_name = ? AND symbol = ? AND hour = ?", cfg.TableName),
+	}
+}
+
+func (s *CsArchiveLedger) Get(ctx context.Context, key *model.ProcessingKey) ([]svc.ArchivedObject, error) {
+	iter := s.session.Query(s.selectStatement, s.storageName, key.Symbol, key.HourNo).WithContext(ctx).Consistency(s.consistency).Iter()
+	var objects []svc.ArchivedObject
+	var obj svc.ArchivedObject
+	for iter.Scan(&obj.Revision, &obj.ObjectKey, &obj.Checksum, &obj.Rows, &obj.ArchivedAtMs) {
+		objects = append(objects, obj)
+	}
+	if err := iter.Close(); err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	return objects, nil
+}
+
+// Record writes the objects in a logged batch, so a revision is recorded entirely or not at all.
+func (s *CsArchiveLedger) Record(ctx context.Context, key *model.ProcessingKey, objects []svc.ArchivedObject) error {
+	batch := s.session.NewBatch(gocql.LoggedBatch).WithContext(ctx)
+	batch.SetConsistency(s.consistency)
+	for _, obj := range objects {
+		batch.Query(s.insertStatement, s.storageName, key.Symbol, key.HourNo, obj.Revision, obj.ObjectKey, obj.Checksum, obj.Rows, obj.ArchivedAtMs)
+	}
+	if err := s.session.ExecuteBatch(batch); err != nil {
+		s.logger.Error(err.Error())
+		return err
+	}
+	return nil
+}
+
+func (s *CsArchiveLedger) Clear(ctx context.Context, key *model.ProcessingKey) (bool, error) {
+	objects, err := s.Get(ctx, key)
+	if err != nil || len(objects) == 0 {
+		return false, err
+	}
+	err = s.session.Query(s.deleteStatement, s.storageName, key.Symbol, key.HourNo).WithContext(ctx).Consistency(s.consistency).Exec()
+	if err != nil {
+		s.logger.Error(err.Error())
+		return false, err
+	}
+	s.logger.Info(fmt.Sprintf("ledger of key %s cleared, %d objects", key, len(objects)))
+	return true, nil
+}
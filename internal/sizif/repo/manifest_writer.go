@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/catalog"
+	"DeltaReceiver/internal/sizif/objstore"
+	"DeltaReceiver/internal/sizif/svc"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WriterVersion is recorded in manifests and file metadata. It is bumped whenever the layout
+// of written files changes.
+const WriterVersion = 2
+
+// ManifestWriter keeps day manifests of a storage up to date. Updates are read-modify-write
+// of the manifest of a symbol and day, so they are serialized with a mutex of the symbol
+// and day shared by all sizif replicas.
+type ManifestWriter struct {
+	objStore    objstore.ObjectStore
+	storageName string
+	mutex       svc.NamedMutex
+}
+
+func NewManifestWriter(objStore objstore.ObjectStore, storageName string, mutex svc.NamedMutex) *ManifestWriter {
+	return &ManifestWriter{
+		objStore:    objStore,
+		storageName: storageName,
+		mutex:       mutex,
+	}
+}
+
+const manifestLockTimeout = time.Minute
+
+// UpdateDay removes files with the removed keys from the manifest of the symbol and day
+// and adds or replaces the added files.
+func (s *ManifestWriter) UpdateDay(ctx context.Context, date time.Time, symbol string, removedKeys []string, added []catalog.ManifestFile) error {
+	return s.update(ctx, date, symbol, added, func(file catalog.ManifestFile) bool {
+		return slices.Contains(removedKeys, file.Key)
+	})
+}
+
+// ReplaceCompacted removes files with the removed keys and all compacted parts of the
+// symbol, which also drops parts of a compaction run stopped before its update, and adds
+// the parts.
+func (s *ManifestWriter) ReplaceCompacted(ctx context.Context, date time.Time, symbol string, removedKeys []string, parts []catalog.ManifestFile) error {
+	return s.update(ctx, date, symbol, parts, func(file catalog.ManifestFile) bool {
+		return slices.Contains(removedKeys, file.Key) || file.Hour == catalog.CompactedHour
+	})
+}
+
+func (s *ManifestWriter) update(ctx context.Context, date time.Time, symbol string, added []catalog.ManifestFile, removed func(catalog.ManifestFile) bool) error {
+	lockCtx, cancel := context.WithTimeout(ctx, manifestLockTimeout)
+	defer cancel()
+	unlock, err := s.mutex.Lock(lockCtx, symbol+"_"+date.UTC().Format(time.DateOnly))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	manifest, err := catalog.ReadDayManifest(ctx, s.objStore, s.storageName, symbol, date)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		manifest = &catalog.DayManifest{StorageName: s.storageName, Date: date.UTC().Format(time.DateOnly), Symbol: symbol}
+	}
+	manifest.Files = slices.DeleteFunc(manifest.Files, func(file catalog.ManifestFile) bool {
+		if removed(file) {
+			return true
+		}
+		return slices.ContainsFunc(added, func(addedFile catalog.ManifestFile) bool {
+			return addedFile.Key == file.Key
+		})
+	})
+	manifest.Files = append(manifest.Files, added...)
+	slices.SortFunc(manifest.Files, func(a, b catalog.ManifestFile) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	manifest.UpdatedAtMs = time.Now().UnixMilli()
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	return s.objStore.Put(ctx, catalog.ManifestKey(s.storageName, symbol, date), data)
+}
+
+// fileStats accumulates manifest statistics of rows in the order they are written.
+// Holes are counted for rows with update id ranges, i.e. deltas, when the first update
+// id of a row does not continue the previous one.
+type fileStats struct {
+	rows           int64
+	minTimestampMs int64
+	maxTimestampMs int64
+	minUpdateId    int64
+	maxUpdateId    int64
+	lastUpdateId   int64
+	holeCount      int
+}
+
+type withFirstUpdateId interface {
+	GetFirstUpdateId() int64
+}
+
+func (s *fileStats) add(row any) {
+	var timestampMs, updateId int64
+	if withTs, ok := row.(model.WithTimestampMs); ok {
+		timestampMs = withTs.GetTimestampMs()
+	}
+	if withUpdateId, ok := row.(model.WithUpdateId); ok {
+		updateId = withUpdateId.GetUpdateId()
+	}
+	if s.rows == 0 {
+		s.minTimestampMs, s.maxTimestampMs = timestampMs, timestampMs
+		s.minUpdateId, s.maxUpdateId = updateId, updateId
+	} else {
+		s.minTimestampMs, s.maxTimestampMs = min(s.minTimestampMs, timestampMs), max(s.maxTimestampMs, timestampMs)
+		s.minUpdateId, s.maxUpdateId = min(s.minUpdateId, updateId), max(s.maxUpdateId, updateId)
+		if withFirst, ok := row.(withFirstUpdateId); ok && updateId != s.lastUpdateId && withFirst.GetFirstUpdateId()-s.lastUpdateId > 1 {
+			s.holeCount++
+		}
+	}
+	s.lastUpdateId = updateId
+	s.rows++
+}
+
+func (s *fileStats) manifestFile(key, symbol string, hour int, data []byte) catalog.ManifestFile {
+	checksum := sha256.Sum256(data)
+	return catalog.ManifestFile{
+		Key:            key,
+		Symbol:         symbol,
+		Hour:           hour,
+		Rows:           s.rows,
+		MinTimestampMs: s.minTimestampMs,
+		MaxTimestampMs: s.maxTimestampMs,
+		MinUpdateId:    s.minUpdateId,
+		MaxUpdateId:    s.maxUpdateId,
+		HoleCount:      s.holeCount,
+		Checksum:       hex.EncodeToString(checksum[:]),
+		WriterVersion:  WriterVersion,
+	}
+}
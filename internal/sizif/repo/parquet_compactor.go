@@ -0,0 +1,480 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/sizif/catalog"
+	"DeltaReceiver/internal/sizif/conf"
+	"DeltaReceiver/internal/sizif/objstore"
+	"DeltaReceiver/internal/sizif/svc"
+	"DeltaReceiver/pkg/log"
+	"bytes"
+	"container/heap"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"go.uber.org/zap"
+)
+
+// ParquetCompactor merges all parquet objects of a symbol and day into sorted parts of
+// bounded size and switches readers to them with a CompactionManifest.
+type ParquetCompactor[T any] struct {
+	logger       *zap.Logger
+	objStore     objstore.ObjectStore
+	storageName  string
+	layout       *parquetLayout
+	maxPartBytes int64
+	manifests    *ManifestWriter
+}
+
+func NewParquetCompactor[T any](objStore objstore.ObjectStore, storageName string, cfg *conf.ParquetCfg, maxPartBytes int64, manifests *ManifestWriter) *ParquetCompactor[T] {
+	return &ParquetCompactor[T]{
+		logger:       log.GetLogger("ParquetCompactor_" + storageName),
+		objStore:     objStore,
+		storageName:  storageName,
+		layout:       newParquetLayout[T](cfg),
+		maxPartBytes: maxPartBytes,
+		manifests:    manifests,
+	}
+}
+
+// PendingDays lists the storage and returns days which still have hourly objects.
+func (s *ParquetCompactor[T]) PendingDays(ctx context.Context, completedBefore time.Time) ([]svc.ArchiveDay, error) {
+	keys, err := s.objStore.List(ctx, s.storageName+"/")
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[svc.ArchiveDay]struct{})
+	for _, key := range keys {
+		// <storage>/<symbol>/<date>/<time>.parquet
+		parts := strings.Split(strings.TrimPrefix(key, s.storageName+"/"), "/")
+		if len(parts) != 3 || !strings.HasSuffix(parts[2], ".parquet") {
+			continue
+		}
+		date, err := time.Parse(time.DateOnly, parts[1])
+		if err != nil || !date.AddDate(0, 0, 1).Before(completedBefore) {
+			continue
+		}
+		pending[svc.ArchiveDay{Symbol: parts[0], Date: date}] = struct{}{}
+	}
+	days := make([]svc.ArchiveDay, 0, len(pending))
+	for day := range pending {
+		days = append(days, day)
+	}
+	slices.SortFunc(days, func(a, b svc.ArchiveDay) int {
+		return a.Date.Compare(b.Date)
+	})
+	return days, nil
+}
+
+// Compact merges hourly objects of the day together with parts of a previous compaction,
+// so late data is compacted as well. Hourly objects the previous run already merged are
+// only deleted. Sources are deleted only after the written parts are read back with the
+// expected number of rows and the manifest is switched to them. Objects are spooled to
+// temporary files, so a run holds one object in memory at a time.
+func (s *ParquetCompactor[T]) Compact(ctx context.Context, day svc.ArchiveDay) (int, error) {
+	prefix := dayPrefix(s.storageName, day.Symbol, day.Date)
+	prevManifest, err := ReadCompactionManifest(ctx, s.objStore, s.storageName, day.Symbol, day.Date)
+	if err != nil {
+		return 0, err
+	}
+	hourlyKeys, staleKeys, err := s.listDay(ctx, prefix, prevManifest)
+	if err != nil {
+		return 0, err
+	}
+	if len(hourlyKeys) == 0 {
+		return 0, nil
+	}
+	spool, err := newFileSpool()
+	if err != nil {
+		return 0, err
+	}
+	defer spool.Close()
+	compacted := make(map[string]string)
+	if prevManifest != nil {
+		for _, sourceRef := range prevManifest.Sources {
+			compacted[sourceRef.Key] = sourceRef.Checksum
+		}
+	}
+	var sources []*parquet.File
+	var sourceRefs []CompactedSourceRef
+	var leftoverKeys []string
+	for _, key := range hourlyKeys {
+		data, err := s.objStore.Get(ctx, key)
+		if err != nil {
+			return 0, fmt.Errorf("source %s: %w", key, err)
+		}
+		checksum := sha256.Sum256(data)
+		sourceRef := CompactedSourceRef{Key: key, Checksum: hex.EncodeToString(checksum[:])}
+		if compacted[key] == sourceRef.Checksum {
+			leftoverKeys = append(leftoverKeys, key)
+			continue
+		}
+		file, err := spool.open(data)
+		if err != nil {
+			return 0, fmt.Errorf("source %s: %w", key, err)
+		}
+		sources = append(sources, file)
+		sourceRefs = append(sourceRefs, sourceRef)
+	}
+	if len(sources) == 0 {
+		return 0, s.dropLeftovers(ctx, day, prevManifest, slices.Concat(leftoverKeys, staleKeys))
+	}
+	var partKeys []string
+	if prevManifest != nil {
+		partKeys = manifestKeys(prevManifest)
+		parts := make([]*parquet.File, 0, len(partKeys))
+		for _, key := range partKeys {
+			file, err := s.openFile(ctx, spool, key)
+			if err != nil {
+				return 0, fmt.Errorf("source %s: %w", key, err)
+			}
+			parts = append(parts, file)
+		}
+		sources = append(parts, sources...)
+	}
+	var sourceRows int64
+	for _, source := range sources {
+		sourceRows += source.NumRows()
+	}
+	// parts of a run must never overwrite parts of the previous one, which are still in use
+	compactedAtMs := time.Now().UnixMilli()
+	if prevManifest != nil {
+		compactedAtMs = max(compactedAtMs, prevManifest.CompactedAtMs+1)
+	}
+	manifest := &CompactionManifest{
+		Symbol:        day.Symbol,
+		Date:          day.Date.Format(time.DateOnly),
+		CompactedAtMs: compactedAtMs,
+		Sources:       mergeSourceRefs(prevManifest, sourceRefs),
+	}
+	var partFiles []catalog.ManifestFile
+	err = s.merge(sources, func(partNo int, data []byte, stats *fileStats) error {
+		key := fmt.Sprintf("%s%s/%d-%04d.parquet", prefix, compactedDirName, compactedAtMs, partNo)
+		if err := s.objStore.Put(ctx, key, data); err != nil {
+			return err
+		}
+		partFile := stats.manifestFile(key, day.Symbol, catalog.CompactedHour, data)
+		manifest.Files = append(manifest.Files, CompactedFileRef{Key: key, Rows: stats.rows, Bytes: int64(len(data)), Entry: partFile})
+		manifest.Rows += stats.rows
+		partFiles = append(partFiles, partFile)
+		return nil
+	})
+	if err == nil {
+		err = s.verify(ctx, spool, manifest, sourceRows)
+	}
+	if err != nil {
+		s.deleteKeys(ctx, manifestKeys(manifest))
+		return 0, err
+	}
+	if err = writeCompactionManifest(ctx, s.objStore, s.storageName, manifest); err != nil {
+		return 0, err
+	}
+	removedKeys := slices.Concat(partKeys, staleKeys, leftoverKeys)
+	for _, sourceRef := range sourceRefs {
+		removedKeys = append(removedKeys, sourceRef.Key)
+	}
+	if err = s.manifests.ReplaceCompacted(ctx, day.Date, day.Symbol, removedKeys, partFiles); err != nil {
+		return 0, err
+	}
+	s.logger.Info(fmt.Sprintf("day %s compacted from %d files into %d parts with %d rows", day, len(sources), len(manifest.Files), manifest.Rows))
+	s.deleteKeys(ctx, removedKeys)
+	return len(sources), nil
+}
+
+// listDay returns hourly objects of the day and compacted parts which are not in the
+// manifest of the previous run.
+func (s *ParquetCompactor[T]) listDay(ctx context.Context, prefix string, prevManifest *CompactionManifest) ([]string, []string, error) {
+	keys, err := s.objStore.List(ctx, prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	var partKeys []string
+	if prevManifest != nil {
+		partKeys = manifestKeys(prevManifest)
+	}
+	var hourlyKeys, staleKeys []string
+	for _, key := range keys {
+		name := strings.TrimPrefix(key, prefix)
+		switch {
+		case !strings.HasSuffix(name, ".parquet"):
+		case !strings.Contains(name, "/"):
+			hourlyKeys = append(hourlyKeys, key)
+		case strings.HasPrefix(name, compactedDirName+"/") && !slices.Contains(partKeys, key):
+			staleKeys = append(staleKeys, key)
+		}
+	}
+	return hourlyKeys, staleKeys, nil
+}
+
+// dropLeftovers deletes objects which the previous run merged but did not delete. The day
+// manifest is switched to the parts of the previous run again, in case the run stopped
+// before it was.
+func (s *ParquetCompactor[T]) dropLeftovers(ctx context.Context, day svc.ArchiveDay, prevManifest *CompactionManifest, leftoverKeys []string) error {
+	var partFiles []catalog.ManifestFile
+	for _, fileRef := range prevManifest.Files {
+		if fileRef.Entry.Key != "" {
+			partFiles = append(partFiles, fileRef.Entry)
+		}
+	}
+	if err := s.manifests.ReplaceCompacted(ctx, day.Date, day.Symbol, leftoverKeys, partFiles); err != nil {
+		return err
+	}
+	s.logger.Info(fmt.Sprintf("day %s already compacted, deleting %d leftover files", day, len(leftoverKeys)))
+	s.deleteKeys(ctx, leftoverKeys)
+	return nil
+}
+
+func (s *ParquetCompactor[T]) openFile(ctx context.Context, spool *fileSpool, key string) (*parquet.File, error) {
+	data, err := s.objStore.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return spool.open(data)
+}
+
+func (s *ParquetCompactor[T]) verify(ctx context.Context, spool *fileSpool, manifest *CompactionManifest, sourceRows int64) error {
+	if manifest.Rows != sourceRows {
+		return fmt.Errorf("%w: merged %d rows of %d", CompactionMismatchErr, manifest.Rows, sourceRows)
+	}
+	var readRows int64
+	for _, fileRef := range manifest.Files {
+		file, err := s.openFile(ctx, spool, fileRef.Key)
+		if err != nil {
+			return fmt.Errorf("compacted %s: %w", fileRef.Key, err)
+		}
+		readRows += file.NumRows()
+	}
+	if readRows != sourceRows {
+		return fmt.Errorf("%w: read back %d rows of %d", CompactionMismatchErr, readRows, sourceRows)
+	}
+	return nil
+}
+
+const mergeBatchSize = 1024
+
+// merge k-way merges the sources, each of them sorted by the sorting columns, and cuts the
+// output into parts once a part reaches maxPartBytes. The bound is checked after row groups
+// are flushed, so a part may exceed it by about a row group. Sources without sorting
+// columns are concatenated.
+func (s *ParquetCompactor[T]) merge(sources []*parquet.File, savePart func(int, []byte, *fileStats) error) error {
+	queue := &mergeQueue[T]{compareRows: s.layout.compareRows}
+	// rows are deconstructed only to be compared
+	var sortingSchema *parquet.Schema
+	if s.layout.compareRows != nil {
+		sortingSchema = s.layout.baseSchema
+	}
+	for sourceNo, source := range sources {
+		cursor := &mergeCursor[T]{sourceNo: sourceNo, reader: parquet.NewGenericReader[T](source), buf: make([]T, mergeBatchSize)}
+		ok, err := cursor.next(sortingSchema)
+		if err != nil {
+			return err
+		}
+		if ok {
+			queue.cursors = append(queue.cursors, cursor)
+		}
+	}
+	heap.Init(queue)
+	partNo := 0
+	var buffer bytes.Buffer
+	var writer *parquet.GenericWriter[T]
+	var partStats *fileStats
+	batch := make([]T, 0, mergeBatchSize)
+	flushPart := func() error {
+		if writer == nil {
+			return nil
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		if err := savePart(partNo, bytes.Clone(buffer.Bytes()), partStats); err != nil {
+			return err
+		}
+		partNo++
+		writer = nil
+		return nil
+	}
+	writeBatch := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if writer == nil {
+			buffer.Reset()
+			writer = parquet.NewGenericWriter[T](&buffer, s.layout.writerOptions...)
+			partStats = &fileStats{}
+		}
+		if _, err := writer.Write(batch); err != nil {
+			return err
+		}
+		for _, row := range batch {
+			partStats.add(row)
+		}
+		batch = batch[:0]
+		if int64(buffer.Len()) >= s.maxPartBytes {
+			return flushPart()
+		}
+		return nil
+	}
+	for queue.Len() > 0 {
+		cursor := queue.cursors[0]
+		batch = append(batch, cursor.value())
+		ok, err := cursor.next(sortingSchema)
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(queue, 0)
+		} else {
+			heap.Pop(queue)
+		}
+		if len(batch) == mergeBatchSize {
+			if err = writeBatch(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writeBatch(); err != nil {
+		return err
+	}
+	return flushPart()
+}
+
+func (s *ParquetCompactor[T]) deleteKeys(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.objStore.Delete(ctx, key); err != nil {
+			s.logger.Warn(fmt.Sprintf("object %s not deleted: %s", key, err.Error()))
+		}
+	}
+}
+
+// mergeSourceRefs adds sources of a run to the ones of the previous run, a source with the
+// key of a previous one replaces it.
+func mergeSourceRefs(prevManifest *CompactionManifest, sourceRefs []CompactedSourceRef) []CompactedSourceRef {
+	if prevManifest == nil {
+		return sourceRefs
+	}
+	merged := slices.DeleteFunc(slices.Clone(prevManifest.Sources), func(prevRef CompactedSourceRef) bool {
+		return slices.ContainsFunc(sourceRefs, func(sourceRef CompactedSourceRef) bool {
+			return sourceRef.Key == prevRef.Key
+		})
+	})
+	return append(merged, sourceRefs...)
+}
+
+// fileSpool keeps objects of a run in temporary files, which are removed on Close.
+type fileSpool struct {
+	dir   string
+	files []*os.File
+}
+
+func newFileSpool() (*fileSpool, error) {
+	dir, err := os.MkdirTemp("", "sizif-compaction-")
+	if err != nil {
+		return nil, err
+	}
+	return &fileSpool{dir: dir}, nil
+}
+
+func (s *fileSpool) open(data []byte) (*parquet.File, error) {
+	file, err := os.CreateTemp(s.dir, "*.parquet")
+	if err != nil {
+		return nil, err
+	}
+	s.files = append(s.files, file)
+	if _, err = file.Write(data); err != nil {
+		return nil, err
+	}
+	return parquet.OpenFile(file, int64(len(data)))
+}
+
+func (s *fileSpool) Close() {
+	for _, file := range s.files {
+		_ = file.Close()
+	}
+	_ = os.RemoveAll(s.dir)
+}
+
+func manifestKeys(manifest *CompactionManifest) []string {
+	keys := make([]string, len(manifest.Files))
+	for i, fileRef := range manifest.Files {
+		keys[i] = fileRef.Key
+	}
+	return keys
+}
+
+type mergeCursor[T any] struct {
+	sourceNo int
+	reader   *parquet.GenericReader[T]
+	buf      []T
+	pos      int
+	size     int
+	row      parquet.Row
+}
+
+func (s *mergeCursor[T]) value() T {
+	return s.buf[s.pos]
+}
+
+// next moves the cursor to the next value and returns false once the source is exhausted.
+func (s *mergeCursor[T]) next(schema *parquet.Schema) (bool, error) {
+	if s.size > 0 {
+		s.pos++
+	}
+	if s.pos >= s.size {
+		n, err := s.reader.Read(s.buf)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, err
+		}
+		if n == 0 {
+			return false, s.reader.Close()
+		}
+		s.pos, s.size = 0, n
+	}
+	if schema != nil {
+		s.row = schema.Deconstruct(s.row[:0], &s.buf[s.pos])
+	}
+	return true, nil
+}
+
+type mergeQueue[T any] struct {
+	cursors     []*mergeCursor[T]
+	compareRows func(parquet.Row, parquet.Row) int
+}
+
+func (s *mergeQueue[T]) Len() int {
+	return len(s.cursors)
+}
+
+// Less keeps the source order for equal rows, and without sorting columns
+// drains the sources one by one.
+func (s *mergeQueue[T]) Less(i, j int) bool {
+	if s.compareRows != nil {
+		if cmp := s.compareRows(s.cursors[i].row, s.cursors[j].row); cmp != 0 {
+			return cmp < 0
+		}
+	}
+	return s.cursors[i].sourceNo < s.cursors[j].sourceNo
+}
+
+func (s *mergeQueue[T]) Swap(i, j int) {
+	s.cursors[i], s.cursors[j] = s.cursors[j], s.cursors[i]
+}
+
+func (s *mergeQueue[T]) Push(x any) {
+	s.cursors = append(s.cursors, x.(*mergeCursor[T]))
+}
+
+func (s *mergeQueue[T]) Pop() any {
+	last := s.cursors[len(s.cursors)-1]
+	s.cursors = s.cursors[:len(s.cursors)-1]
+	return last
+}
+
+var CompactionMismatchErr = errors.New("compacted rows do not match source rows")
@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/sizif/conf"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+)
+
+// parquetLayout holds the writer options derived from ParquetCfg. The written schema is the
+// schema of T with dictionary encoding on the configured columns, so columns keep their order.
+type parquetLayout struct {
+	baseSchema    *parquet.Schema
+	writerOptions []parquet.WriterOption
+	compareRows   func(parquet.Row, parquet.Row) int
+}
+
+const parquetMetadataPrefix = "sizif."
+
+func newParquetLayout[T any](cfg *conf.ParquetCfg) *parquetLayout {
+	baseSchema := parquet.SchemaOf(new(T))
+	for _, columns := range [][]string{cfg.SortingColumns, cfg.DictionaryColumns, cfg.BloomFilterCols} {
+		for _, column := range columns {
+			if _, ok := baseSchema.Lookup(column); !ok {
+				panic(fmt.Sprintf("no column %s in parquet schema %s", column, baseSchema.Name()))
+			}
+		}
+	}
+	schema := baseSchema
+	if len(cfg.DictionaryColumns) > 0 {
+		schema = parquet.SchemaOf(reflect.New(withDictionaryTags(reflect.TypeFor[T](), cfg.DictionaryColumns)).Interface())
+	}
+
+	var sortingColumns []parquet.SortingColumn
+	for _, column := range cfg.SortingColumns {
+		sortingColumns = append(sortingColumns, parquet.Ascending(column))
+	}
+	var bloomFilters []parquet.BloomFilterColumn
+	for _, column := range cfg.BloomFilterCols {
+		bloomFilters = append(bloomFilters, parquet.SplitBlockFilter(uint(cfg.BloomFilterBits), column))
+	}
+	writerOptions := []parquet.WriterOption{
+		schema,
+		parquet.Compression(compressionCodec(cfg)),
+		parquet.MaxRowsPerRowGroup(cfg.RowGroupRows),
+		parquet.PageBufferSize(cfg.PageBufferSize),
+		parquet.SortingWriterConfig(parquet.SortingColumns(sortingColumns...)),
+		parquet.BloomFilters(bloomFilters...),
+	}
+	// settings are kept in the file, so readers and compaction can tell how it was written
+	for key, value := range map[string]string{
+		"writer.version":       strconv.Itoa(WriterVersion),
+		"compression":          string(cfg.Compression),
+		"zstd.level":           strconv.Itoa(cfg.ZstdLevel),
+		"row.group.rows":       strconv.FormatInt(cfg.RowGroupRows, 10),
+		"page.buffer.size":     strconv.Itoa(cfg.PageBufferSize),
+		"sorting.columns":      strings.Join(cfg.SortingColumns, ","),
+		"dictionary.columns":   strings.Join(cfg.DictionaryColumns, ","),
+		"bloom.filter.columns": strings.Join(cfg.BloomFilterCols, ","),
+		"bloom.filter.bits":    strconv.Itoa(cfg.BloomFilterBits),
+	} {
+		writerOptions = append(writerOptions, parquet.KeyValueMetadata(parquetMetadataPrefix+key, value))
+	}
+	var compareRows func(parquet.Row, parquet.Row) int
+	if len(sortingColumns) > 0 {
+		compareRows = baseSchema.Comparator(sortingColumns...)
+	}
+	return &parquetLayout{
+		baseSchema:    baseSchema,
+		writerOptions: writerOptions,
+		compareRows:   compareRows,
+	}
+}
+
+// sortEntries orders entries by the sorting columns, keeping the order of equal entries.
+func sortEntries[T any](layout *parquetLayout, entries []T) []T {
+	if layout.compareRows == nil {
+		return entries
+	}
+	rows := make([]parquet.Row, len(entries))
+	order := make([]int, len(entries))
+	for i := range entries {
+		rows[i] = layout.baseSchema.Deconstruct(nil, &entries[i])
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return layout.compareRows(rows[a], rows[b])
+	})
+	sorted := make([]T, len(entries))
+	for i, idx := range order {
+		sorted[i] = entries[idx]
+	}
+	return sorted
+}
+
+// withDictionaryTags returns a copy of the struct type t with the dict option added to
+// parquet tags of the given columns.
+func withDictionaryTags(t reflect.Type, columns []string) reflect.Type {
+	fields := make([]reflect.StructField, t.NumField())
+	for i := range fields {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("parquet")
+		if ok && slices.Contains(columns, strings.Split(tag, ",")[0]) {
+			field.Tag = reflect.StructTag(strings.Replace(string(field.Tag), `parquet:"`+tag+`"`, `parquet:"`+tag+`,dict"`, 1))
+		}
+		fields[i] = field
+	}
+	return reflect.StructOf(fields)
+}
+
+// zstd levels 1-4 map to the encoder presets from fastest to best compression.
+func compressionCodec(cfg *conf.ParquetCfg) compress.Codec {
+	switch cfg.Compression {
+	case conf.ZstdCompression:
+		levels := []zstd.Level{zstd.SpeedFastest, zstd.SpeedDefault, zstd.SpeedBetterCompression, zstd.SpeedBestCompression}
+		return &zstd.Codec{Level: levels[min(max(cfg.ZstdLevel, 1), len(levels))-1]}
+	case conf.Lz4Compression:
+		return &parquet.Lz4Raw
+	case conf.SnappyCompression:
+		return &parquet.Snappy
+	default:
+		return &parquet.Uncompressed
+	}
+}
@@ -0,0 +1,112 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/conf"
+	"DeltaReceiver/internal/sizif/objstore"
+	"DeltaReceiver/internal/sizif/svc"
+	"DeltaReceiver/pkg/log"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"go.uber.org/zap"
+)
+
+type KeyTsFormType int
+
+const (
+	FromData = KeyTsFormType(0)
+	FromKey  = KeyTsFormType(1)
+)
+
+type ParquetStorage[T any] struct {
+	logger        *zap.Logger
+	objStore      objstore.ObjectStore
+	storageName   string
+	layout        *parquetLayout
+	keyTsFormType KeyTsFormType
+	manifests     *ManifestWriter
+}
+
+func NewParquetStorage[T any](objStore objstore.ObjectStore, storageName string, keyTsFormType KeyTsFormType, cfg *conf.ParquetCfg, manifests *ManifestWriter) *ParquetStorage[T] {
+	logger := log.GetLogger("ParquetStorage_" + storageName)
+	return &ParquetStorage[T]{
+		logger:        logger,
+		objStore:      objStore,
+		storageName:   storageName,
+		layout:        newParquetLayout[T](cfg),
+		keyTsFormType: keyTsFormType,
+		manifests:     manifests,
+	}
+}
+
+func (s ParquetStorage[T]) Save(ctx context.Context, entries []T, timestampMs int64, key *model.ProcessingKey, revision int) (*svc.ArchivedObject, error) {
+	var buffer bytes.Buffer
+	writer := parquet.NewGenericWriter[T](&buffer, s.layout.writerOptions...)
+	entries = sortEntries(s.layout, entries)
+	_, err := writer.Write(entries)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	err = writer.Close()
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	objKey := s.createObjKey(key, timestampMs, revision)
+	err = s.objStore.Put(ctx, objKey, buffer.Bytes())
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	s.logger.Info(fmt.Sprintf("key %s saved", objKey))
+	return &svc.ArchivedObject{
+		ObjectKey:    objKey,
+		Checksum:     rowsChecksum(s.layout.baseSchema, entries),
+		Rows:         int64(len(entries)),
+		Revision:     revision,
+		ArchivedAtMs: time.Now().UnixMilli(),
+	}, nil
+}
+
+func (s ParquetStorage[T]) Checksum(entries []T) string {
+	return rowsChecksum(s.layout.baseSchema, sortEntries(s.layout, entries))
+}
+
+// createObjKey names objects <storage>/<symbol>/<date>/<time>.parquet, objects of later
+// revisions get a .r<revision> suffix so late data does not overwrite archived data.
+func (s *ParquetStorage[T]) createObjKey(key *model.ProcessingKey, timestampMs int64, revision int) string {
+	var processingTime time.Time
+	if s.keyTsFormType == FromData {
+		processingTime = time.UnixMilli(timestampMs).UTC()
+	} else if s.keyTsFormType == FromKey {
+		processingTime = time.Unix(key.HourNo*60*60, 0).UTC()
+	} else {
+		panic("invalid key form ts type")
+	}
+	keyDate := processingTime.Format("2006-01-02")
+	keyTime := processingTime.Format("15-04-05")
+	if revision > 0 {
+		keyTime = fmt.Sprintf("%s.r%d", keyTime, revision)
+	}
+	return fmt.Sprintf("%s/%s/%s/%s.parquet", s.storageName, key.Symbol, keyDate, keyTime)
+}
+
+// parseObjKey returns the symbol and processing time of an object named by createObjKey.
+func (s *ParquetStorage[T]) parseObjKey(objKey string) (string, time.Time, error) {
+	parts := strings.Split(strings.TrimPrefix(objKey, s.storageName+"/"), "/")
+	if len(parts) != 3 {
+		return "", time.Time{}, fmt.Errorf("object key %s is not of storage %s", objKey, s.storageName)
+	}
+	keyTime, _, _ := strings.Cut(strings.TrimSuffix(parts[2], ".parquet"), ".")
+	processingTime, err := time.Parse("2006-01-02 15-04-05", parts[1]+" "+keyTime)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("object key %s: %w", objKey, err)
+	}
+	return parts[0], processingTime, nil
+}
@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/sizif/catalog"
+	"DeltaReceiver/internal/sizif/svc"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Verify downloads the saved object and checks that it has the layout schema and holds
+// exactly the saved rows. Rows are compared by a checksum of their values, so the check
+// does not depend on encodings and compression of the object. The object is added to the
+// day manifest once it passes.
+func (s ParquetStorage[T]) Verify(ctx context.Context, obj *svc.ArchivedObject) error {
+	objKey := obj.ObjectKey
+	data, err := s.objStore.Get(ctx, objKey)
+	if err != nil {
+		return fmt.Errorf("object %s not read back: %w", objKey, err)
+	}
+	file, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("%w: object %s not opened: %s", VerificationMismatchErr, objKey, err.Error())
+	}
+	if !sameColumns(file.Schema(), s.layout.baseSchema) {
+		return fmt.Errorf("%w: object %s has schema %s", VerificationMismatchErr, objKey, file.Schema())
+	}
+	if file.NumRows() != obj.Rows {
+		return fmt.Errorf("%w: object %s has %d rows of %d", VerificationMismatchErr, objKey, file.NumRows(), obj.Rows)
+	}
+	savedEntries := make([]T, file.NumRows())
+	reader := parquet.NewGenericReader[T](file)
+	n, err := reader.Read(savedEntries)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: object %s not read: %s", VerificationMismatchErr, objKey, err.Error())
+	}
+	if err = reader.Close(); err != nil {
+		return err
+	}
+	savedChecksum := rowsChecksum(s.layout.baseSchema, savedEntries[:n])
+	if savedChecksum != obj.Checksum {
+		return fmt.Errorf("%w: object %s has checksum %s, source data %s", VerificationMismatchErr, objKey, savedChecksum, obj.Checksum)
+	}
+	symbol, processingTime, err := s.parseObjKey(objKey)
+	if err != nil {
+		return err
+	}
+	var stats fileStats
+	for _, entry := range savedEntries[:n] {
+		stats.add(entry)
+	}
+	manifestFile := stats.manifestFile(objKey, symbol, processingTime.Hour(), data)
+	return s.manifests.UpdateDay(ctx, processingTime, symbol, nil, []catalog.ManifestFile{manifestFile})
+}
+
+// sameColumns compares paths and physical types of leaf columns.
+func sameColumns(a, b *parquet.Schema) bool {
+	aColumns, bColumns := a.Columns(), b.Columns()
+	if !slices.EqualFunc(aColumns, bColumns, slices.Equal[[]string]) {
+		return false
+	}
+	for _, path := range aColumns {
+		aLeaf, _ := a.Lookup(path...)
+		bLeaf, _ := b.Lookup(path...)
+		if aLeaf.Node.Type().Kind() != bLeaf.Node.Type().Kind() || aLeaf.Node.Optional() != bLeaf.Node.Optional() {
+			return false
+		}
+	}
+	return true
+}
+
+// rowsChecksum hashes values of the rows in order.
+func rowsChecksum[T any](schema *parquet.Schema, entries []T) string {
+	hash := sha256.New()
+	var row parquet.Row
+	var buf []byte
+	for i := range entries {
+		row = schema.Deconstruct(row[:0], &entries[i])
+		for _, value := range row {
+			buf = append(buf[:0], byte(value.Kind()))
+			buf = binary.AppendUvarint(buf, uint64(len(value.Bytes())))
+			hash.Write(append(buf, value.Bytes()...))
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+var VerificationMismatchErr = errors.New("saved object does not match source data")
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/objstore"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ValidationReportStorage keeps validation reports next to the quarantined objects of the hour.
+type ValidationReportStorage struct {
+	objStore    objstore.ObjectStore
+	storageName string
+}
+
+func NewValidationReportStorage(objStore objstore.ObjectStore, storageName string) *ValidationReportStorage {
+	return &ValidationReportStorage{
+		objStore:    objStore,
+		storageName: storageName,
+	}
+}
+
+func (s *ValidationReportStorage) SaveValidationReport(ctx context.Context, report *model.ValidationReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return s.objStore.Put(ctx, s.reportKey(report), data)
+}
+
+// reportKey is <storage>/<symbol>/<date>/<hour>.report.json
+func (s *ValidationReportStorage) reportKey(report *model.ValidationReport) string {
+	hourTime := time.UnixMilli(report.TimestampMs).UTC()
+	return fmt.Sprintf("%s/%s/%s/%s.report.json", s.storageName, report.Symbol, hourTime.Format(time.DateOnly), hourTime.Format("15-04-05"))
+}
@@ -0,0 +1,106 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// BackfillRequest selects hot keys to archive again, hours are in [FromHourNo, ToHourNo).
+// Replace clears ledger records of the keys, so their objects are archived again from
+// revision 0.
+type BackfillRequest struct {
+	Symbols    *regexp.Regexp
+	FromHourNo int64
+	ToHourNo   int64
+	DryRun     bool
+	Replace    bool
+}
+
+func (s *BackfillRequest) matches(key *model.ProcessingKey) bool {
+	return key.HourNo >= s.FromHourNo && key.HourNo < s.ToHourNo && s.Symbols.MatchString(key.Symbol)
+}
+
+type KeyOutcome string
+
+const (
+	KeyProcessed        KeyOutcome = "processed"
+	KeyAlreadyProcessed KeyOutcome = "already_processed"
+	KeyLocked           KeyOutcome = "locked"
+	KeyFailed           KeyOutcome = "failed"
+	KeyDryRun           KeyOutcome = "dry_run"
+)
+
+type KeyResult struct {
+	Key     model.ProcessingKey
+	Outcome KeyOutcome
+	Err     error
+}
+
+func NewKeyResult(key model.ProcessingKey, outcome KeyOutcome, err error) KeyResult {
+	return KeyResult{Key: key, Outcome: outcome, Err: err}
+}
+
+func (s KeyResult) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s\t%s\t%s", s.Key.Symbol, s.Key.GetStartTime().UTC().Format(ProcessingKeyLayout), s.Outcome))
+	if s.Err != nil {
+		sb.WriteString("\t" + s.Err.Error())
+	}
+	return sb.String()
+}
+
+// Backfill processes the matching hot keys before scheduled keys. Workers must be started.
+// Without Replace archived objects are kept, hot data of an archived key is saved as its
+// next revision unless it matches the last revision, then it is only deleted. With Replace
+// the keys, quarantined ones included, are archived again from revision 0, replacing first
+// revision objects and forgetting later ones, so only hours still fully present in socrates
+// and not yet compacted should be replaced.
+func (s *SizifSvc[T]) Backfill(ctx context.Context, req *BackfillRequest) ([]KeyResult, error) {
+	keys, err := s.socratesStorage.GetKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys = slices.DeleteFunc(keys, func(key model.ProcessingKey) bool {
+		return !req.matches(&key)
+	})
+	slices.SortFunc(keys, func(a, b model.ProcessingKey) int {
+		return cmp.Or(cmp.Compare(a.HourNo, b.HourNo), strings.Compare(a.Symbol, b.Symbol))
+	})
+	s.logger.Info(fmt.Sprintf("found %d keys to backfill", len(keys)))
+	results := make([]KeyResult, 0, len(keys))
+	if req.DryRun {
+		for _, key := range keys {
+			results = append(results, NewKeyResult(key, KeyDryRun, nil))
+		}
+		return results, nil
+	}
+	resultQueue := make(chan KeyResult, len(keys))
+	numTasks := 0
+	for _, key := range keys {
+		if req.Replace {
+			if _, err := s.ledger.Clear(ctx, &key); err != nil {
+				results = append(results, NewKeyResult(key, KeyFailed, err))
+				continue
+			}
+		}
+		if !s.scheduler.Prioritize(key, resultQueue) {
+			return results, errors.New("service is shut down")
+		}
+		numTasks++
+	}
+	for range numTasks {
+		select {
+		case result := <-resultQueue:
+			results = append(results, result)
+		case <-ctx.Done():
+			return results, ctx.Err()
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,214 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/conf"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BookReconstructionSvc periodically replays archived deltas of each symbol hour on top of
+// the nearest prior snapshot and saves book states of the hour. Hours are locked with the
+// KeyLocker and recorded in the ArchiveLedger of the book states storage with the sum of the
+// latest revisions of the hour in deltasLedger and snapshotsLedger. Deltas or snapshots
+// archived late in a new revision raise the sum and make the hour reconstructed again.
+// Futures holes are not seen in update ids, so futures hours are checked for holes only
+// when their deltas manifests report some.
+type BookReconstructionSvc struct {
+	logger          *zap.Logger
+	checkHoles      bool
+	deltas          ArchiveReader[model.Delta]
+	deltasLedger    ArchiveLedger
+	snapshots       ArchiveReader[model.DepthSnapshotPart]
+	snapshotsLedger ArchiveLedger
+	storage         ParquetStorage[model.BookState]
+	ledger          ArchiveLedger
+	keyLocker       KeyLocker
+	metrics         BookReconstructionMetrics
+	cfg             *conf.BookReconstructionCfg
+	stop            chan struct{}
+	done            chan struct{}
+}
+
+func NewBookReconstructionSvc(
+	serviceType string,
+	marketType bmodel.DataType,
+	deltas ArchiveReader[model.Delta],
+	deltasLedger ArchiveLedger,
+	snapshots ArchiveReader[model.DepthSnapshotPart],
+	snapshotsLedger ArchiveLedger,
+	storage ParquetStorage[model.BookState],
+	ledger ArchiveLedger,
+	keyLocker KeyLocker,
+	metrics BookReconstructionMetrics,
+	cfg *conf.BookReconstructionCfg,
+) *BookReconstructionSvc {
+	return &BookReconstructionSvc{
+		logger:          log.GetLogger(fmt.Sprintf("BookReconstructionSvc[%s]", serviceType)),
+		checkHoles:      marketType == bmodel.Spot,
+		deltas:          deltas,
+		deltasLedger:    deltasLedger,
+		snapshots:       snapshots,
+		snapshotsLedger: snapshotsLedger,
+		storage:         storage,
+		ledger:          ledger,
+		keyLocker:       keyLocker,
+		metrics:         metrics,
+		cfg:             cfg,
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+func (s *BookReconstructionSvc) Start(ctx context.Context) {
+	defer close(s.done)
+	s.logger.Info("Service started")
+	for {
+		s.reconstructPendingHours(ctx)
+		timer := time.NewTimer(time.Duration(s.cfg.PeriodM) * time.Minute)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *BookReconstructionSvc) reconstructPendingHours(ctx context.Context) {
+	completedBefore := time.Now().Add(-time.Duration(s.cfg.DelayH) * time.Hour)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for date := today.AddDate(0, 0, -s.cfg.LookbackDays); !date.After(today); date = date.AddDate(0, 0, 1) {
+		keys, err := s.deltas.SymbolHours(ctx, date)
+		if err != nil {
+			s.logger.Error(err.Error())
+			return
+		}
+		for _, key := range keys {
+			select {
+			case <-s.stop:
+				return
+			default:
+			}
+			if key.GetEndTime().After(completedBefore) {
+				continue
+			}
+			sourcesRevision, pending, err := s.isPending(ctx, &key)
+			if err != nil {
+				s.logger.Error(err.Error())
+				return
+			}
+			if pending {
+				s.lockAndReconstructHour(ctx, key, sourcesRevision)
+			}
+		}
+	}
+}
+
+// isPending reports whether book states of the key are not reconstructed from the latest
+// revisions of its deltas and snapshots, the sum of which is returned. Both revisions only
+// grow, so the sum grows whenever either of them does.
+func (s *BookReconstructionSvc) isPending(ctx context.Context, key *model.ProcessingKey) (int, bool, error) {
+	sourcesRevision := 0
+	for _, sourceLedger := range []ArchiveLedger{s.deltasLedger, s.snapshotsLedger} {
+		archived, err := sourceLedger.Get(ctx, key)
+		if err != nil {
+			return 0, false, err
+		}
+		if len(archived) > 0 {
+			sourcesRevision += archived[len(archived)-1].Revision
+		}
+	}
+	archivedStates, err := s.ledger.Get(ctx, key)
+	if err != nil {
+		return 0, false, err
+	}
+	return sourcesRevision, len(archivedStates) == 0 || archivedStates[len(archivedStates)-1].Revision < sourcesRevision, nil
+}
+
+func (s *BookReconstructionSvc) lockAndReconstructHour(ctx context.Context, key model.ProcessingKey, sourcesRevision int) {
+	lockStatus, err := s.keyLocker.Lock(ctx, &key)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	if lockStatus != LockedSuccessfully {
+		s.logger.Debug(fmt.Sprintf("key %s already reconstructing", &key))
+		return
+	}
+	defer func() {
+		if err := s.keyLocker.Unlock(ctx, &key); err != nil {
+			s.logger.Warn(fmt.Sprintf("key %s not unlocked: %s", &key, err.Error()))
+		}
+	}()
+	numUnsynced, err := s.reconstructHour(ctx, &key, sourcesRevision)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("key %s not reconstructed: %s", &key, err.Error()))
+		s.metrics.IncFailedHours()
+		return
+	}
+	s.metrics.IncReconstructedHours(numUnsynced)
+}
+
+// reconstructHour returns the number of saved states which are not synced. States are always
+// saved as the first revision object, so reconstructing again replaces the object.
+func (s *BookReconstructionSvc) reconstructHour(ctx context.Context, key *model.ProcessingKey, sourcesRevision int) (int, error) {
+	startTime := key.GetStartTime()
+	snapshots, err := s.snapshots.Read(ctx, key.Symbol, startTime.Add(-time.Duration(s.cfg.SnapshotLookbackH)*time.Hour), key.GetEndTime())
+	if err != nil {
+		return 0, err
+	}
+	replayFrom := startTime
+	for _, part := range snapshots {
+		if part.Timestamp <= startTime.UnixMilli() {
+			replayFrom = time.UnixMilli(part.Timestamp)
+		}
+	}
+	deltas, err := s.deltas.Read(ctx, key.Symbol, replayFrom, key.GetEndTime())
+	if err != nil {
+		return 0, err
+	}
+	checkHoles := s.checkHoles
+	if !checkHoles {
+		holeCount, err := s.deltas.HoleCount(ctx, key.Symbol, startTime, key.GetEndTime())
+		if err != nil {
+			return 0, err
+		}
+		checkHoles = holeCount > 0
+	}
+	states := reconstructBook(key, deltas, snapshots, s.cfg.Depth, s.cfg.IntervalMs, checkHoles)
+	numUnsynced := 0
+	for _, state := range states {
+		if !state.Synced {
+			numUnsynced++
+		}
+	}
+	obj, err := saveAndVerify(ctx, s.storage, states, key)
+	if err != nil {
+		return 0, err
+	}
+	obj.Revision = sourcesRevision
+	if err = s.keyLocker.CheckLocked(ctx, key); err != nil {
+		return 0, err
+	}
+	if err = s.ledger.Record(ctx, key, []ArchivedObject{*obj}); err != nil {
+		return 0, err
+	}
+	s.logger.Info(fmt.Sprintf("key %s reconstructed from %d deltas and %d snapshot parts of revision %d, %d of %d states not synced", key, len(deltas), len(snapshots), sourcesRevision, numUnsynced, len(states)))
+	return numUnsynced, nil
+}
+
+func (s *BookReconstructionSvc) Shutdown(ctx context.Context) {
+	s.logger.Info("Start shutdown")
+	close(s.stop)
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+	s.logger.Info("End shutdown")
+}
@@ -0,0 +1,187 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"cmp"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+type bookLevel struct {
+	price    float64
+	priceStr string
+	quantity string
+}
+
+// bookSide keeps levels ordered from the best price.
+type bookSide struct {
+	levels []bookLevel
+	isBid  bool
+}
+
+func (s *bookSide) better(a, b float64) bool {
+	if s.isBid {
+		return a > b
+	}
+	return a < b
+}
+
+// set replaces the quantity of the level, zero quantity removes it.
+func (s *bookSide) set(priceStr, quantity string) {
+	price, err := strconv.ParseFloat(priceStr, 64)
+	if err != nil {
+		return
+	}
+	i := sort.Search(len(s.levels), func(i int) bool {
+		return !s.better(s.levels[i].price, price)
+	})
+	found := i < len(s.levels) && s.levels[i].price == price
+	if qty, err := strconv.ParseFloat(quantity, 64); err == nil && qty == 0 {
+		if found {
+			s.levels = slices.Delete(s.levels, i, i+1)
+		}
+		return
+	}
+	if found {
+		s.levels[i].quantity = quantity
+		return
+	}
+	s.levels = slices.Insert(s.levels, i, bookLevel{price: price, priceStr: priceStr, quantity: quantity})
+}
+
+func (s *bookSide) top(depth int) ([]string, []string) {
+	levels := s.levels[:min(depth, len(s.levels))]
+	prices := make([]string, len(levels))
+	quantities := make([]string, len(levels))
+	for i, level := range levels {
+		prices[i], quantities[i] = level.priceStr, level.quantity
+	}
+	return prices, quantities
+}
+
+// bookReplayer applies snapshots and delta events of one symbol in time order. A hole in
+// update ids leaves the book unsynced until a snapshot at least as new as the book resets it.
+// Futures events are chained by the update id of the previous event which deltas do not keep,
+// so holes are checked in futures books only where the archive already reports holes.
+type bookReplayer struct {
+	bids                bookSide
+	asks                bookSide
+	checkHoles          bool
+	hasBook             bool
+	synced              bool
+	crossedHole         bool
+	lastUpdateId        int64
+	snapshotTimestampMs int64
+}
+
+func newBookReplayer(checkHoles bool) *bookReplayer {
+	return &bookReplayer{
+		bids:       bookSide{isBid: true},
+		asks:       bookSide{isBid: false},
+		checkHoles: checkHoles,
+	}
+}
+
+func (s *bookReplayer) side(isBid bool) *bookSide {
+	if isBid {
+		return &s.bids
+	}
+	return &s.asks
+}
+
+// applySnapshot takes parts of one snapshot, older snapshots than the book are skipped.
+func (s *bookReplayer) applySnapshot(parts []model.DepthSnapshotPart) {
+	lastUpdateId := parts[0].LastUpdateId
+	if s.hasBook && lastUpdateId < s.lastUpdateId {
+		return
+	}
+	s.bids.levels, s.asks.levels = s.bids.levels[:0], s.asks.levels[:0]
+	for _, part := range parts {
+		s.side(part.T).set(part.Price, part.Count)
+	}
+	s.hasBook, s.synced = true, true
+	s.lastUpdateId = lastUpdateId
+	s.snapshotTimestampMs = parts[0].Timestamp
+}
+
+// applyEvent takes rows of one depth update.
+func (s *bookReplayer) applyEvent(rows []model.Delta) {
+	if !s.hasBook || rows[0].UpdateId <= s.lastUpdateId {
+		return
+	}
+	if s.checkHoles && rows[0].FirstUpdateId > s.lastUpdateId+1 {
+		s.crossedHole = true
+		s.synced = false
+	}
+	for _, row := range rows {
+		s.side(row.T).set(row.Price, row.Count)
+	}
+	s.lastUpdateId = rows[0].UpdateId
+}
+
+// state returns the book and resets the hole flag for the next state.
+func (s *bookReplayer) state(symbol string, timestampMs int64, depth int) model.BookState {
+	state := model.BookState{
+		Timestamp:           timestampMs,
+		Symbol:              symbol,
+		UpdateId:            s.lastUpdateId,
+		SnapshotTimestampMs: s.snapshotTimestampMs,
+		Synced:              s.synced,
+		CrossedHole:         s.crossedHole,
+	}
+	state.BidPrices, state.BidQuantities = s.bids.top(depth)
+	state.AskPrices, state.AskQuantities = s.asks.top(depth)
+	s.crossedHole = false
+	return state
+}
+
+// groupRows splits rows ordered by time into runs of rows with the same id.
+func groupRows[T any](rows []T, id func(T) int64) [][]T {
+	var groups [][]T
+	for start := 0; start < len(rows); {
+		end := start + 1
+		for end < len(rows) && id(rows[end]) == id(rows[start]) {
+			end++
+		}
+		groups = append(groups, rows[start:end])
+		start = end
+	}
+	return groups
+}
+
+// reconstructBook emits states of the key every intervalMs, a state at t includes snapshots
+// and deltas up to t. Replay starts from the last snapshot not after the start of the hour,
+// deltas must be given from its time.
+func reconstructBook(key *model.ProcessingKey, deltas []model.Delta, snapshots []model.DepthSnapshotPart, depth int, intervalMs int64, checkHoles bool) []model.BookState {
+	slices.SortStableFunc(deltas, func(a, b model.Delta) int {
+		return cmp.Or(cmp.Compare(a.Timestamp, b.Timestamp), cmp.Compare(a.UpdateId, b.UpdateId))
+	})
+	slices.SortStableFunc(snapshots, func(a, b model.DepthSnapshotPart) int {
+		return cmp.Or(cmp.Compare(a.Timestamp, b.Timestamp), cmp.Compare(a.LastUpdateId, b.LastUpdateId))
+	})
+	events := groupRows(deltas, func(delta model.Delta) int64 { return delta.UpdateId })
+	snapshotGroups := groupRows(snapshots, func(part model.DepthSnapshotPart) int64 { return part.LastUpdateId })
+	startMs := key.GetStartTime().UnixMilli()
+	endMs := startMs + millisInHour
+	replayer := newBookReplayer(checkHoles)
+	states := make([]model.BookState, 0, millisInHour/intervalMs)
+	eventNo, snapshotNo := 0, 0
+	for t := startMs; t < endMs; t += intervalMs {
+		for {
+			hasSnapshot := snapshotNo < len(snapshotGroups) && snapshotGroups[snapshotNo][0].Timestamp <= t
+			hasEvent := eventNo < len(events) && events[eventNo][0].Timestamp <= t
+			if hasSnapshot && (!hasEvent || snapshotGroups[snapshotNo][0].Timestamp <= events[eventNo][0].Timestamp) {
+				replayer.applySnapshot(snapshotGroups[snapshotNo])
+				snapshotNo++
+			} else if hasEvent {
+				replayer.applyEvent(events[eventNo])
+				eventNo++
+			} else {
+				break
+			}
+		}
+		states = append(states, replayer.state(key.Symbol, t, depth))
+	}
+	return states
+}
@@ -0,0 +1,145 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"slices"
+	"testing"
+)
+
+const (
+	testHourStartMs   = millisInHour
+	testIntervalMs    = 20 * 60 * 1000
+	testSecondStateMs = testHourStartMs + testIntervalMs
+	testThirdStateMs  = testHourStartMs + 2*testIntervalMs
+)
+
+func testDelta(timestampMs, firstUpdateId, updateId int64, isBid bool, price, qty string) model.Delta {
+	return model.Delta{Symbol: "BTCUSDT", Timestamp: timestampMs, FirstUpdateId: firstUpdateId, UpdateId: updateId, T: isBid, Price: price, Count: qty}
+}
+
+func testSnapshot(timestampMs, lastUpdateId int64, levels ...model.DepthSnapshotPart) []model.DepthSnapshotPart {
+	for i := range levels {
+		levels[i].Symbol, levels[i].Timestamp, levels[i].LastUpdateId = "BTCUSDT", timestampMs, lastUpdateId
+	}
+	return levels
+}
+
+type wantState struct {
+	updateId    int64
+	synced      bool
+	crossedHole bool
+	bids        []string
+	asks        []string
+}
+
+func TestReconstructBook(t *testing.T) {
+	bid := func(price, qty string) model.DepthSnapshotPart {
+		return model.DepthSnapshotPart{T: true, Price: price, Count: qty}
+	}
+	ask := func(price, qty string) model.DepthSnapshotPart {
+		return model.DepthSnapshotPart{T: false, Price: price, Count: qty}
+	}
+	tests := []struct {
+		name       string
+		deltas     []model.Delta
+		snapshots  []model.DepthSnapshotPart
+		checkHoles bool
+		want       []wantState
+	}{
+		{
+			name: "unsynced before first snapshot",
+			deltas: []model.Delta{
+				testDelta(testHourStartMs, 1, 5, true, "10", "1"),
+			},
+			snapshots:  testSnapshot(testSecondStateMs, 8, bid("10", "2"), ask("11", "1")),
+			checkHoles: true,
+			want: []wantState{
+				{},
+				{updateId: 8, synced: true, bids: []string{"10"}, asks: []string{"11"}},
+				{updateId: 8, synced: true, bids: []string{"10"}, asks: []string{"11"}},
+			},
+		},
+		{
+			name: "events in snapshot skipped and zero quantity removes level",
+			deltas: []model.Delta{
+				testDelta(testHourStartMs-1, 6, 8, true, "9", "1"),
+				testDelta(testHourStartMs+1, 9, 10, true, "10.5", "1"),
+				testDelta(testHourStartMs+1, 9, 10, false, "10.8", "1"),
+				testDelta(testSecondStateMs, 11, 12, true, "10.5", "0"),
+				testDelta(testSecondStateMs, 11, 12, false, "12", "1"),
+			},
+			snapshots:  testSnapshot(testHourStartMs-10, 8, bid("10", "2"), ask("11", "1")),
+			checkHoles: true,
+			want: []wantState{
+				{updateId: 8, synced: true, bids: []string{"10"}, asks: []string{"11"}},
+				{updateId: 12, synced: true, bids: []string{"10"}, asks: []string{"10.8", "11"}},
+				{updateId: 12, synced: true, bids: []string{"10"}, asks: []string{"10.8", "11"}},
+			},
+		},
+		{
+			name: "spot hole unsyncs until next snapshot",
+			deltas: []model.Delta{
+				testDelta(testHourStartMs+1, 15, 16, true, "9", "1"),
+				testDelta(testThirdStateMs-1, 17, 20, true, "8", "1"),
+			},
+			snapshots: slices.Concat(
+				testSnapshot(testHourStartMs, 8, bid("10", "2")),
+				testSnapshot(testThirdStateMs-2, 16, bid("10", "3")),
+			),
+			checkHoles: true,
+			want: []wantState{
+				{updateId: 8, synced: true, bids: []string{"10"}},
+				{updateId: 16, crossedHole: true, bids: []string{"10", "9"}},
+				{updateId: 20, synced: true, bids: []string{"10", "8"}},
+			},
+		},
+		{
+			name: "futures holes not checked",
+			deltas: []model.Delta{
+				testDelta(testHourStartMs+1, 15, 16, true, "9", "1"),
+			},
+			snapshots:  testSnapshot(testHourStartMs, 8, bid("10", "2")),
+			checkHoles: false,
+			want: []wantState{
+				{updateId: 8, synced: true, bids: []string{"10"}},
+				{updateId: 16, synced: true, bids: []string{"10", "9"}},
+				{updateId: 16, synced: true, bids: []string{"10", "9"}},
+			},
+		},
+		{
+			name: "older snapshot skipped",
+			deltas: []model.Delta{
+				testDelta(testHourStartMs+1, 9, 12, true, "9", "1"),
+			},
+			snapshots: slices.Concat(
+				testSnapshot(testHourStartMs, 8, bid("10", "2")),
+				testSnapshot(testSecondStateMs, 10, bid("10", "5")),
+			),
+			checkHoles: true,
+			want: []wantState{
+				{updateId: 8, synced: true, bids: []string{"10"}},
+				{updateId: 12, synced: true, bids: []string{"10", "9"}},
+				{updateId: 12, synced: true, bids: []string{"10", "9"}},
+			},
+		},
+	}
+	key := &model.ProcessingKey{Symbol: "BTCUSDT", HourNo: 1}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			states := reconstructBook(key, tt.deltas, tt.snapshots, 2, testIntervalMs, tt.checkHoles)
+			if len(states) != len(tt.want) {
+				t.Fatalf("got %d states, want %d", len(states), len(tt.want))
+			}
+			for i, state := range states {
+				want := tt.want[i]
+				if state.Timestamp != testHourStartMs+int64(i)*testIntervalMs {
+					t.Fatalf("state %d at %d", i, state.Timestamp)
+				}
+				if state.UpdateId != want.updateId || state.Synced != want.synced || state.CrossedHole != want.crossedHole ||
+					!slices.Equal(state.BidPrices, want.bids) || !slices.Equal(state.AskPrices, want.asks) {
+					t.Fatalf("state %d is %+v, want %+v", i, state, want)
+				}
+			}
+		})
+	}
+}
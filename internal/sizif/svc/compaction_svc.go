@@ -0,0 +1,106 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/sizif/conf"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CompactionSvc periodically merges hourly parquet objects of completed days. Days are
+// locked with the KeyLocker, so several sizif replicas can run compaction at once.
+type CompactionSvc struct {
+	logger    *zap.Logger
+	compactor ParquetCompactor
+	keyLocker KeyLocker
+	metrics   CompactionMetrics
+	period    time.Duration
+	dayDelay  time.Duration
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewCompactionSvc(serviceType string, compactor ParquetCompactor, keyLocker KeyLocker, metrics CompactionMetrics, cfg *conf.CompactionCfg) *CompactionSvc {
+	return &CompactionSvc{
+		logger:    log.GetLogger(fmt.Sprintf("CompactionSvc[%s]", serviceType)),
+		compactor: compactor,
+		keyLocker: keyLocker,
+		metrics:   metrics,
+		period:    time.Duration(cfg.PeriodM) * time.Minute,
+		dayDelay:  time.Duration(cfg.DayDelayH) * time.Hour,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (s *CompactionSvc) Start(ctx context.Context) {
+	defer close(s.done)
+	s.logger.Info("Service started")
+	for {
+		s.compactPendingDays(ctx)
+		timer := time.NewTimer(s.period)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *CompactionSvc) compactPendingDays(ctx context.Context) {
+	days, err := s.compactor.PendingDays(ctx, time.Now().Add(-s.dayDelay))
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	s.logger.Info(fmt.Sprintf("found %d days to compact", len(days)))
+	for _, day := range days {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		s.lockAndCompactDay(ctx, day)
+	}
+}
+
+func (s *CompactionSvc) lockAndCompactDay(ctx context.Context, day ArchiveDay) {
+	lockKey := day.LockKey()
+	lockStatus, err := s.keyLocker.Lock(ctx, &lockKey)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	if lockStatus != LockedSuccessfully {
+		s.logger.Debug(fmt.Sprintf("day %s already compacting", day))
+		return
+	}
+	defer func() {
+		if err := s.keyLocker.Unlock(ctx, &lockKey); err != nil {
+			s.logger.Warn(fmt.Sprintf("day %s not unlocked: %s", day, err.Error()))
+		}
+	}()
+	numSourceFiles, err := s.compactor.Compact(ctx, day)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("day %s not compacted: %s", day, err.Error()))
+		s.metrics.IncFailedDays()
+		return
+	}
+	if numSourceFiles > 0 {
+		s.metrics.IncCompactedDays(numSourceFiles)
+	}
+}
+
+func (s *CompactionSvc) Shutdown(ctx context.Context) {
+	s.logger.Info("Start shutdown")
+	close(s.stop)
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+	s.logger.Info("End shutdown")
+}
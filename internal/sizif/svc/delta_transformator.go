@@ -27,41 +27,32 @@ func NewDeltaTransformator(dwarfClient *web.DwarfHttpClient, marketType bmodel.D
 
 const millisInHour = 60 * 60 * 1000
 
-func (s DeltaTransformator) Transform(deltas []model.Delta, key *model.ProcessingKey) ([][]model.Delta, bool) {
+func (s DeltaTransformator) Transform(deltas []model.Delta, key *model.ProcessingKey) ([][]model.Delta, *model.ValidationReport) {
+	report := model.NewValidationReport(key, len(deltas))
 	if len(deltas) == 0 {
 		s.logger.Warn(fmt.Sprintf("empty batch for key %s", key))
-		return nil, false
+		return nil, report
 	}
 	minAllowedTsMs := key.HourNo * millisInHour
 	maxAllowedTsMs := minAllowedTsMs + millisInHour - 1
-	deltasOutsideTimeRange := 0
 	for _, delta := range deltas {
 		if delta.Timestamp > maxAllowedTsMs || delta.Timestamp < minAllowedTsMs {
 			s.logger.Debug(delta.String())
-			deltasOutsideTimeRange++
+			report.AddOutOfRange(delta.Timestamp)
 		}
 	}
-	validByTimeRange := true
-	if deltasOutsideTimeRange > 0 {
-		validByTimeRange = false
-	}
 	sort.Slice(deltas, func(i, j int) bool {
 		return deltas[i].UpdateId < deltas[j].UpdateId || (deltas[i].UpdateId == deltas[j].UpdateId && deltas[i].FirstUpdateId < deltas[j].FirstUpdateId)
 	})
-	deltaHoles := 0
 	lastUpdateId := deltas[0].UpdateId
 	for i := 1; i < len(deltas); i++ {
 		if deltas[i].FirstUpdateId-lastUpdateId > 1 {
 			// ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 			// s.dwarfClient.SaveDeltaHole(ctx, model.NewDeltaHole(deltas[i].Symbol, lastUpdateId, deltas[i].FirstUpdateId, deltas[i].Timestamp, s.marketType))
 			// cancel()
-			deltaHoles++
+			report.AddHole(model.UpdateIdRange{LastUpdateId: lastUpdateId, FirstUpdateId: deltas[i].FirstUpdateId, TimestampMs: deltas[i].Timestamp})
 		}
 		lastUpdateId = deltas[i].UpdateId
 	}
-	validByHoles := true
-	if deltaHoles > 0 {
-		validByHoles = false
-	}
-	return [][]model.Delta{deltas}, validByHoles && validByTimeRange
+	return [][]model.Delta{deltas}, report
 }
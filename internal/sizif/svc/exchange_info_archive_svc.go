@@ -0,0 +1,220 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/conf"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Exchange info of a day is archived as two objects of the same storage, keyed by these
+// names instead of a symbol.
+const (
+	ExchangeInfoVersionsKey = "versions"
+	InstrumentsKey          = "instruments"
+)
+
+// ExchangeInfoArchiveSvc periodically archives exchange info versions of completed days
+// with the instruments of every version. Files of a day start with the version in effect at
+// the start of the day, so instruments of any moment can be looked up in the files of its
+// day only. Days without new versions are archived with only that version. Days are locked
+// with the KeyLocker and recorded in the ArchiveLedger once both objects are saved.
+type ExchangeInfoArchiveSvc struct {
+	logger             *zap.Logger
+	source             ExchangeInfoSource
+	versionsStorage    ParquetStorage[model.ExchangeInfo]
+	instrumentsStorage ParquetStorage[model.InstrumentInfo]
+	ledger             ArchiveLedger
+	keyLocker          KeyLocker
+	metrics            ExchangeInfoArchiveMetrics
+	period             time.Duration
+	dayDelay           time.Duration
+	stop               chan struct{}
+	done               chan struct{}
+}
+
+func NewExchangeInfoArchiveSvc(
+	serviceType string,
+	source ExchangeInfoSource,
+	versionsStorage ParquetStorage[model.ExchangeInfo],
+	instrumentsStorage ParquetStorage[model.InstrumentInfo],
+	ledger ArchiveLedger,
+	keyLocker KeyLocker,
+	metrics ExchangeInfoArchiveMetrics,
+	cfg *conf.ExchangeInfoArchiveCfg,
+) *ExchangeInfoArchiveSvc {
+	return &ExchangeInfoArchiveSvc{
+		logger:             log.GetLogger(fmt.Sprintf("ExchangeInfoArchiveSvc[%s]", serviceType)),
+		source:             source,
+		versionsStorage:    versionsStorage,
+		instrumentsStorage: instrumentsStorage,
+		ledger:             ledger,
+		keyLocker:          keyLocker,
+		metrics:            metrics,
+		period:             time.Duration(cfg.PeriodM) * time.Minute,
+		dayDelay:           time.Duration(cfg.DayDelayH) * time.Hour,
+		stop:               make(chan struct{}),
+		done:               make(chan struct{}),
+	}
+}
+
+func (s *ExchangeInfoArchiveSvc) Start(ctx context.Context) {
+	defer close(s.done)
+	s.logger.Info("Service started")
+	for {
+		s.archivePendingDays(ctx)
+		timer := time.NewTimer(s.period)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func dayKey(name string, day int64) model.ProcessingKey {
+	return model.ProcessingKey{Symbol: name, HourNo: day * 24}
+}
+
+// archivePendingDays archives every day since the first day with versions, days without
+// new versions are archived with the version carried from the last day which has them.
+func (s *ExchangeInfoArchiveSvc) archivePendingDays(ctx context.Context) {
+	days, err := s.source.GetDays(ctx)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	if len(days) == 0 {
+		return
+	}
+	slices.Sort(days)
+	lastCompletedDay := time.Now().Add(-s.dayDelay).Unix()/(24*3600) - 1
+	for day := days[0]; day <= lastCompletedDay; day++ {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		versionsKey := dayKey(ExchangeInfoVersionsKey, day)
+		archived, err := s.ledger.Get(ctx, &versionsKey)
+		if err != nil {
+			s.logger.Error(err.Error())
+			return
+		}
+		if len(archived) > 0 {
+			continue
+		}
+		prevDay := int64(-1)
+		if i, _ := slices.BinarySearch(days, day); i > 0 {
+			prevDay = days[i-1]
+		}
+		s.lockAndArchiveDay(ctx, day, prevDay)
+	}
+}
+
+func (s *ExchangeInfoArchiveSvc) lockAndArchiveDay(ctx context.Context, day, prevDay int64) {
+	lockKey := dayKey(ExchangeInfoVersionsKey, day)
+	lockStatus, err := s.keyLocker.Lock(ctx, &lockKey)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	if lockStatus != LockedSuccessfully {
+		s.logger.Debug(fmt.Sprintf("day %d already archiving", day))
+		return
+	}
+	defer func() {
+		if err := s.keyLocker.Unlock(ctx, &lockKey); err != nil {
+			s.logger.Warn(fmt.Sprintf("day %d not unlocked: %s", day, err.Error()))
+		}
+	}()
+	numVersions, err := s.archiveDay(ctx, day, prevDay)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("day %d not archived: %s", day, err.Error()))
+		s.metrics.IncFailedDays()
+		return
+	}
+	s.metrics.IncArchivedDays(numVersions)
+}
+
+// archiveDay prepends the last version of prevDay, the last day with versions before the
+// day, if there is one, to versions of the day.
+func (s *ExchangeInfoArchiveSvc) archiveDay(ctx context.Context, day, prevDay int64) (int, error) {
+	versions, err := s.source.GetDay(ctx, day)
+	if err != nil {
+		return 0, err
+	}
+	if prevDay >= 0 {
+		prevVersions, err := s.source.GetDay(ctx, prevDay)
+		if err != nil {
+			return 0, err
+		}
+		if len(prevVersions) > 0 {
+			versions = append([]model.ExchangeInfo{prevVersions[len(prevVersions)-1]}, versions...)
+		}
+	}
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	var instruments []model.InstrumentInfo
+	for i := range versions {
+		versionInstruments, err := model.NewInstrumentInfos(&versions[i])
+		if err != nil {
+			return 0, fmt.Errorf("version %d not flattened: %w", versions[i].ServerTime, err)
+		}
+		instruments = append(instruments, versionInstruments...)
+	}
+	versionsKey := dayKey(ExchangeInfoVersionsKey, day)
+	instrumentsKey := dayKey(InstrumentsKey, day)
+	versionsObj, err := saveAndVerify(ctx, s.versionsStorage, versions, &versionsKey)
+	if err != nil {
+		return 0, err
+	}
+	instrumentsObj, err := saveAndVerify(ctx, s.instrumentsStorage, instruments, &instrumentsKey)
+	if err != nil {
+		return 0, err
+	}
+	if err = s.keyLocker.CheckLocked(ctx, &versionsKey); err != nil {
+		return 0, err
+	}
+	// versions are recorded last, as they mark the day archived
+	if err = s.ledger.Record(ctx, &instrumentsKey, []ArchivedObject{*instrumentsObj}); err != nil {
+		return 0, err
+	}
+	if err = s.ledger.Record(ctx, &versionsKey, []ArchivedObject{*versionsObj}); err != nil {
+		return 0, err
+	}
+	s.logger.Info(fmt.Sprintf("day %d archived with %d versions and %d instrument rows", day, len(versions), len(instruments)))
+	return len(versions), nil
+}
+
+func saveAndVerify[T any](ctx context.Context, storage ParquetStorage[T], data []T, key *model.ProcessingKey) (*ArchivedObject, error) {
+	var err error
+	for i := 0; i < 3; i++ {
+		var obj *ArchivedObject
+		obj, err = storage.Save(ctx, data, key.GetStartTime().UnixMilli(), key, 0)
+		if err != nil {
+			continue
+		}
+		if err = storage.Verify(ctx, obj); err == nil {
+			return obj, nil
+		}
+	}
+	return nil, err
+}
+
+func (s *ExchangeInfoArchiveSvc) Shutdown(ctx context.Context) {
+	s.logger.Info("Start shutdown")
+	close(s.stop)
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+	s.logger.Info("End shutdown")
+}
@@ -3,10 +3,20 @@ package svc
 import (
 	"DeltaReceiver/internal/common/model"
 	"context"
+	"errors"
+	"fmt"
+	"time"
 )
 
 type ParquetStorage[T any] interface {
-	Save(context.Context, []T, int64, *model.ProcessingKey) error
+	// Save writes the data of the key revision and returns the written object. Revision 0
+	// is the first archive of the key, later revisions hold late data in separate objects.
+	Save(ctx context.Context, data []T, timestampMs int64, key *model.ProcessingKey, revision int) (*ArchivedObject, error)
+	// Verify reads back the saved object and fails if it does not hold exactly its rows.
+	// Only a verified object is listed in the day manifest.
+	Verify(context.Context, *ArchivedObject) error
+	// Checksum is the checksum Save records for the data.
+	Checksum([]T) string
 }
 
 type SocratesStorage[T any] interface {
@@ -17,7 +27,12 @@ type SocratesStorage[T any] interface {
 }
 
 type DataTransformator[T any] interface {
-	Transform([]T, *model.ProcessingKey) ([][]T, bool)
+	// Transform groups the data into objects to save and validates it.
+	Transform([]T, *model.ProcessingKey) ([][]T, *model.ValidationReport)
+}
+
+type ValidationReportStorage interface {
+	SaveValidationReport(context.Context, *model.ValidationReport) error
 }
 
 type LockOpStatus int8
@@ -25,15 +40,112 @@ type LockOpStatus int8
 const (
 	LockedSuccessfully LockOpStatus = 0
 	AlreadyLocked      LockOpStatus = 1
-	AlreadyProcessed   LockOpStatus = 2
 )
 
+// KeyLocker locks keys for a worker. Locks are released by Unlock or when the worker
+// holding them dies, whether a key is archived is kept by an ArchiveLedger.
 type KeyLocker interface {
 	Lock(context.Context, *model.ProcessingKey) (LockOpStatus, error)
 	Unlock(context.Context, *model.ProcessingKey) error
-	MarkProcessed(context.Context, *model.ProcessingKey) error
+	// CheckLocked fails with LockLostErr if the lock of the key is not held anymore.
+	CheckLocked(context.Context, *model.ProcessingKey) error
+}
+
+var LockLostErr = errors.New("key lock lost")
+
+// ArchivedObject is an object written for a key, Checksum is a checksum of its rows.
+type ArchivedObject struct {
+	ObjectKey    string
+	Checksum     string
+	Rows         int64
+	Revision     int
+	ArchivedAtMs int64
+}
+
+// ArchiveLedger is the durable record of archived keys.
+type ArchiveLedger interface {
+	// Get returns objects archived for the key ordered by revision.
+	Get(context.Context, *model.ProcessingKey) ([]ArchivedObject, error)
+	Record(context.Context, *model.ProcessingKey, []ArchivedObject) error
+	// Clear forgets objects of the key, so it is archived again from revision 0. False is
+	// returned if the key was not archived.
+	Clear(context.Context, *model.ProcessingKey) (bool, error)
+}
+
+// KeyWatermark is the last hour all keys of which were discovered by a complete scan.
+type KeyWatermark interface {
+	Get(context.Context) (int64, error)
+	Advance(ctx context.Context, hourNo int64) error
+}
+
+type NamedMutex interface {
+	// Lock blocks until the mutex is acquired and returns the function releasing it.
+	Lock(ctx context.Context, name string) (func(), error)
 }
 
 type Metrics interface {
 	IncInvalidDataCounter()
+	IncVerificationFailedCounter()
+	IncKeyScanErrors()
+	SetKeyWatermark(hourNo int64)
+	SetScheduledKeys(queued, inFlight int)
+}
+
+// ArchiveDay is a UTC day of archived data of one symbol.
+type ArchiveDay struct {
+	Symbol string
+	Date   time.Time
+}
+
+// LockKey maps the day to the processing key of its first hour, so days can be locked
+// with a KeyLocker.
+func (s ArchiveDay) LockKey() model.ProcessingKey {
+	return model.ProcessingKey{Symbol: s.Symbol, HourNo: s.Date.Unix() / 3600}
+}
+
+func (s ArchiveDay) String() string {
+	return fmt.Sprintf("[%s, %s]", s.Symbol, s.Date.Format(time.DateOnly))
+}
+
+type ParquetCompactor interface {
+	// PendingDays returns days ended before completedBefore which have not compacted files.
+	PendingDays(ctx context.Context, completedBefore time.Time) ([]ArchiveDay, error)
+	// Compact returns the number of merged source files.
+	Compact(context.Context, ArchiveDay) (int, error)
+}
+
+type CompactionMetrics interface {
+	IncCompactedDays(numSourceFiles int)
+	IncFailedDays()
+}
+
+type ExchangeInfoSource interface {
+	GetDays(context.Context) ([]int64, error)
+	// GetDay returns versions of the day ordered by time.
+	GetDay(ctx context.Context, day int64) ([]model.ExchangeInfo, error)
+}
+
+type ExchangeInfoArchiveMetrics interface {
+	IncArchivedDays(numVersions int)
+	IncFailedDays()
+}
+
+type ArchiveReader[T any] interface {
+	// Read returns archived rows of the symbol in [from, to) ordered by time.
+	Read(ctx context.Context, symbol string, from, to time.Time) ([]T, error)
+	// SymbolHours returns keys with archived rows in the UTC day.
+	SymbolHours(ctx context.Context, date time.Time) ([]model.ProcessingKey, error)
+	// HoleCount returns the number of update id holes reported by manifests of the files of
+	// the symbol which may hold rows in [from, to).
+	HoleCount(ctx context.Context, symbol string, from, to time.Time) (int, error)
+}
+
+type BookReconstructionMetrics interface {
+	IncReconstructedHours(numUnsyncedStates int)
+	IncFailedHours()
+}
+
+type MidBarsMetrics interface {
+	IncAggregatedHours(numBars int)
+	IncFailedHours()
 }
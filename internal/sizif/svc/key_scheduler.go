@@ -0,0 +1,181 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"cmp"
+	"container/heap"
+	"strings"
+	"sync"
+)
+
+// KeyScheduler hands keys to workers, oldest hours first. Prioritized keys go before all
+// others. A key is kept once while it is queued or processed, so repeated scans do not
+// schedule it twice.
+type KeyScheduler struct {
+	mut      sync.Mutex
+	queue    scheduledKeys
+	keys     map[model.ProcessingKey]*scheduledKey
+	wake     chan struct{}
+	inFlight int
+	closed   bool
+}
+
+type scheduledKey struct {
+	key         model.ProcessingKey
+	prioritized bool
+	inFlight    bool
+	results     []chan<- KeyResult
+	index       int
+}
+
+func NewKeyScheduler() *KeyScheduler {
+	return &KeyScheduler{
+		keys: make(map[model.ProcessingKey]*scheduledKey),
+		wake: make(chan struct{}),
+	}
+}
+
+// Add queues keys which are not queued or processed yet and returns the number of added keys.
+func (s *KeyScheduler) Add(keys []model.ProcessingKey) int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.closed {
+		return 0
+	}
+	added := 0
+	for _, key := range keys {
+		if _, ok := s.keys[key]; ok {
+			continue
+		}
+		item := &scheduledKey{key: key}
+		s.keys[key] = item
+		heap.Push(&s.queue, item)
+		added++
+	}
+	if added > 0 {
+		s.notify()
+	}
+	return added
+}
+
+// Prioritize moves the key before all not prioritized keys, queueing it if needed. The
+// result of its processing is sent to result, which must not block.
+func (s *KeyScheduler) Prioritize(key model.ProcessingKey, result chan<- KeyResult) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.closed {
+		return false
+	}
+	item, ok := s.keys[key]
+	if !ok {
+		item = &scheduledKey{key: key}
+		s.keys[key] = item
+		heap.Push(&s.queue, item)
+	}
+	item.results = append(item.results, result)
+	if !item.inFlight && !item.prioritized {
+		item.prioritized = true
+		heap.Fix(&s.queue, item.index)
+	}
+	s.notify()
+	return true
+}
+
+// Next blocks until a key is available and returns false once the scheduler is closed.
+func (s *KeyScheduler) Next() (model.ProcessingKey, bool) {
+	for {
+		s.mut.Lock()
+		if s.closed {
+			s.mut.Unlock()
+			return model.ProcessingKey{}, false
+		}
+		if s.queue.Len() > 0 {
+			item := heap.Pop(&s.queue).(*scheduledKey)
+			item.inFlight = true
+			s.inFlight++
+			s.mut.Unlock()
+			return item.key, true
+		}
+		wake := s.wake
+		s.mut.Unlock()
+		<-wake
+	}
+}
+
+// Done releases the processed key and sends the result to waiters of prioritized keys.
+func (s *KeyScheduler) Done(result KeyResult) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	item, ok := s.keys[result.Key]
+	if !ok || !item.inFlight {
+		return
+	}
+	delete(s.keys, result.Key)
+	s.inFlight--
+	for _, waiter := range item.results {
+		waiter <- result
+	}
+}
+
+// Len returns the numbers of queued and processed keys.
+func (s *KeyScheduler) Len() (int, int) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.queue.Len(), s.inFlight
+}
+
+// Close drops queued keys and releases workers waiting in Next.
+func (s *KeyScheduler) Close() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.queue = nil
+	close(s.wake)
+}
+
+func (s *KeyScheduler) IsClosed() bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.closed
+}
+
+// notify wakes all workers waiting in Next.
+func (s *KeyScheduler) notify() {
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+type scheduledKeys []*scheduledKey
+
+func (s scheduledKeys) Len() int {
+	return len(s)
+}
+
+func (s scheduledKeys) Less(i, j int) bool {
+	if s[i].prioritized != s[j].prioritized {
+		return s[i].prioritized
+	}
+	return cmp.Or(cmp.Compare(s[i].key.HourNo, s[j].key.HourNo), strings.Compare(s[i].key.Symbol, s[j].key.Symbol)) < 0
+}
+
+func (s scheduledKeys) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+	s[i].index = i
+	s[j].index = j
+}
+
+func (s *scheduledKeys) Push(x any) {
+	item := x.(*scheduledKey)
+	item.index = len(*s)
+	*s = append(*s, item)
+}
+
+func (s *scheduledKeys) Pop() any {
+	old := *s
+	item := old[len(old)-1]
+	*s = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,127 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"slices"
+	"testing"
+	"time"
+)
+
+func drainScheduler(scheduler *KeyScheduler) []model.ProcessingKey {
+	var keys []model.ProcessingKey
+	for {
+		if queued, _ := scheduler.Len(); queued == 0 {
+			return keys
+		}
+		key, _ := scheduler.Next()
+		keys = append(keys, key)
+	}
+}
+
+func TestKeySchedulerOrder(t *testing.T) {
+	btc10 := model.ProcessingKey{Symbol: "BTCUSDT", HourNo: 10}
+	eth10 := model.ProcessingKey{Symbol: "ETHUSDT", HourNo: 10}
+	btc11 := model.ProcessingKey{Symbol: "BTCUSDT", HourNo: 11}
+	btc12 := model.ProcessingKey{Symbol: "BTCUSDT", HourNo: 12}
+	tests := []struct {
+		name        string
+		batches     [][]model.ProcessingKey
+		prioritized []model.ProcessingKey
+		wantAdded   []int
+		want        []model.ProcessingKey
+	}{
+		{
+			name:      "oldest hour first then symbol",
+			batches:   [][]model.ProcessingKey{{btc12, eth10, btc11, btc10}},
+			wantAdded: []int{4},
+			want:      []model.ProcessingKey{btc10, eth10, btc11, btc12},
+		},
+		{
+			name:      "repeated scans deduplicated",
+			batches:   [][]model.ProcessingKey{{btc11, btc10}, {btc10, btc12, btc11}},
+			wantAdded: []int{2, 1},
+			want:      []model.ProcessingKey{btc10, btc11, btc12},
+		},
+		{
+			name:        "prioritized before older keys",
+			batches:     [][]model.ProcessingKey{{btc10, eth10, btc11}},
+			prioritized: []model.ProcessingKey{btc12, btc11},
+			wantAdded:   []int{3},
+			want:        []model.ProcessingKey{btc11, btc12, btc10, eth10},
+		},
+		{
+			name:        "prioritized key not queued twice",
+			batches:     [][]model.ProcessingKey{{btc10}},
+			prioritized: []model.ProcessingKey{btc12, btc12},
+			wantAdded:   []int{1},
+			want:        []model.ProcessingKey{btc12, btc10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := NewKeyScheduler()
+			for i, batch := range tt.batches {
+				if added := scheduler.Add(batch); added != tt.wantAdded[i] {
+					t.Fatalf("batch %d added %d keys, want %d", i, added, tt.wantAdded[i])
+				}
+			}
+			for _, key := range tt.prioritized {
+				scheduler.Prioritize(key, make(chan KeyResult, 1))
+			}
+			if got := drainScheduler(scheduler); !slices.Equal(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeySchedulerInFlightKey(t *testing.T) {
+	key := model.ProcessingKey{Symbol: "BTCUSDT", HourNo: 10}
+	scheduler := NewKeyScheduler()
+	scheduler.Add([]model.ProcessingKey{key})
+	if next, _ := scheduler.Next(); next != key {
+		t.Fatalf("got %v", next)
+	}
+	if added := scheduler.Add([]model.ProcessingKey{key}); added != 0 {
+		t.Fatal("key in flight queued again")
+	}
+	result := make(chan KeyResult, 1)
+	scheduler.Prioritize(key, result)
+	if queued, inFlight := scheduler.Len(); queued != 0 || inFlight != 1 {
+		t.Fatalf("queued %d, in flight %d", queued, inFlight)
+	}
+	scheduler.Done(NewKeyResult(key, KeyProcessed, nil))
+	if got := <-result; got.Key != key || got.Outcome != KeyProcessed {
+		t.Fatalf("got %+v", got)
+	}
+	if added := scheduler.Add([]model.ProcessingKey{key}); added != 1 {
+		t.Fatal("processed key not released")
+	}
+}
+
+func TestKeySchedulerWakesAndCloses(t *testing.T) {
+	key := model.ProcessingKey{Symbol: "BTCUSDT", HourNo: 10}
+	scheduler := NewKeyScheduler()
+	nextCh := make(chan bool)
+	go func() {
+		next, ok := scheduler.Next()
+		nextCh <- ok && next == key
+	}()
+	time.Sleep(10 * time.Millisecond)
+	scheduler.Add([]model.ProcessingKey{key})
+	if ok := <-nextCh; !ok {
+		t.Fatal("waiting worker not woken by added key")
+	}
+	go func() {
+		_, ok := scheduler.Next()
+		nextCh <- ok
+	}()
+	time.Sleep(10 * time.Millisecond)
+	scheduler.Close()
+	if ok := <-nextCh; ok {
+		t.Fatal("key returned by closed scheduler")
+	}
+	if scheduler.Add([]model.ProcessingKey{key}) != 0 || scheduler.Prioritize(key, make(chan KeyResult, 1)) {
+		t.Fatal("closed scheduler accepted keys")
+	}
+}
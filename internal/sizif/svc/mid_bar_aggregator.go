@@ -0,0 +1,84 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"cmp"
+	"slices"
+	"strconv"
+)
+
+type midTick struct {
+	timestampMs int64
+	mid         float64
+	spread      float64
+}
+
+// parseTicks orders ticks by time, ticks with unparsable prices are skipped.
+func parseTicks(ticks []bmodel.SymbolTick) []midTick {
+	slices.SortStableFunc(ticks, func(a, b bmodel.SymbolTick) int {
+		return cmp.Or(cmp.Compare(a.Timestamp, b.Timestamp), cmp.Compare(a.UpdateId, b.UpdateId))
+	})
+	parsed := make([]midTick, 0, len(ticks))
+	for _, tick := range ticks {
+		bid, err := strconv.ParseFloat(tick.BidPrice, 64)
+		if err != nil {
+			continue
+		}
+		ask, err := strconv.ParseFloat(tick.AskPrice, 64)
+		if err != nil {
+			continue
+		}
+		parsed = append(parsed, midTick{timestampMs: tick.Timestamp, mid: (bid + ask) / 2, spread: ask - bid})
+	}
+	return parsed
+}
+
+// aggregateMidBars returns bars of the key for every interval, bars without ticks are not
+// emitted. Spread before the first tick of the hour is unknown, so it is not weighted.
+func aggregateMidBars(key *model.ProcessingKey, ticks []bmodel.SymbolTick, intervalsMs []int64) []model.MidBar {
+	parsed := parseTicks(ticks)
+	startMs := key.GetStartTime().UnixMilli()
+	var bars []model.MidBar
+	for _, intervalMs := range intervalsMs {
+		tickNo := 0
+		var spread float64
+		hasSpread := false
+		for barStartMs := startMs; barStartMs < startMs+millisInHour; barStartMs += intervalMs {
+			barEndMs := barStartMs + intervalMs
+			bar := model.MidBar{Timestamp: barStartMs, Symbol: key.Symbol, IntervalMs: intervalMs}
+			var spreadSum, twaSum float64
+			var twaMs int64
+			spreadFromMs := barStartMs
+			for ; tickNo < len(parsed) && parsed[tickNo].timestampMs < barEndMs; tickNo++ {
+				tick := parsed[tickNo]
+				if tick.timestampMs < barStartMs {
+					continue
+				}
+				if hasSpread {
+					twaSum += spread * float64(tick.timestampMs-spreadFromMs)
+					twaMs += tick.timestampMs - spreadFromMs
+				}
+				spread, hasSpread, spreadFromMs = tick.spread, true, tick.timestampMs
+				if bar.TickCount == 0 {
+					bar.Open, bar.High, bar.Low = tick.mid, tick.mid, tick.mid
+					bar.MinSpread, bar.MaxSpread = tick.spread, tick.spread
+				}
+				bar.High, bar.Low = max(bar.High, tick.mid), min(bar.Low, tick.mid)
+				bar.MinSpread, bar.MaxSpread = min(bar.MinSpread, tick.spread), max(bar.MaxSpread, tick.spread)
+				bar.Close = tick.mid
+				bar.TickCount++
+				spreadSum += tick.spread
+			}
+			if bar.TickCount == 0 {
+				continue
+			}
+			twaSum += spread * float64(barEndMs-spreadFromMs)
+			twaMs += barEndMs - spreadFromMs
+			bar.MeanSpread = spreadSum / float64(bar.TickCount)
+			bar.TwaSpread = twaSum / float64(twaMs)
+			bars = append(bars, bar)
+		}
+	}
+	return bars
+}
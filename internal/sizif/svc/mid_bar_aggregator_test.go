@@ -0,0 +1,93 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"math"
+	"testing"
+)
+
+const testMinuteMs = 60 * 1000
+
+func testTick(offsetMs int64, bid, ask string) bmodel.SymbolTick {
+	return bmodel.SymbolTick{Symbol: "BTCUSDT", Timestamp: testHourStartMs + offsetMs, BidPrice: bid, AskPrice: ask}
+}
+
+func TestAggregateMidBars(t *testing.T) {
+	tests := []struct {
+		name        string
+		ticks       []bmodel.SymbolTick
+		intervalsMs []int64
+		want        []model.MidBar
+	}{
+		{
+			name: "spreads weighted by time in effect",
+			ticks: []bmodel.SymbolTick{
+				testTick(0, "99", "101"),
+				testTick(15*testMinuteMs, "100", "101"),
+				testTick(45*testMinuteMs, "98", "102"),
+			},
+			intervalsMs: []int64{millisInHour},
+			want: []model.MidBar{
+				{Timestamp: testHourStartMs, IntervalMs: millisInHour, Open: 100, High: 100.5, Low: 100, Close: 100, TickCount: 3, MinSpread: 1, MaxSpread: 4, MeanSpread: 7.0 / 3, TwaSpread: 2},
+			},
+		},
+		{
+			name: "time before first tick of hour not weighted",
+			ticks: []bmodel.SymbolTick{
+				testTick(30*testMinuteMs, "99", "101"),
+				testTick(45*testMinuteMs, "98", "102"),
+			},
+			intervalsMs: []int64{millisInHour},
+			want: []model.MidBar{
+				{Timestamp: testHourStartMs, IntervalMs: millisInHour, Open: 100, High: 100, Low: 100, Close: 100, TickCount: 2, MinSpread: 2, MaxSpread: 4, MeanSpread: 3, TwaSpread: 3},
+			},
+		},
+		{
+			name: "spread carried over bar edges and empty bars skipped",
+			ticks: []bmodel.SymbolTick{
+				testTick(10*testMinuteMs, "99", "101"),
+				testTick(20*testMinuteMs, "99.5", "100.5"),
+				testTick(45*testMinuteMs, "98", "102"),
+			},
+			intervalsMs: []int64{10 * testMinuteMs},
+			want: []model.MidBar{
+				{Timestamp: testHourStartMs + 10*testMinuteMs, IntervalMs: 10 * testMinuteMs, Open: 100, High: 100, Low: 100, Close: 100, TickCount: 1, MinSpread: 2, MaxSpread: 2, MeanSpread: 2, TwaSpread: 2},
+				{Timestamp: testHourStartMs + 20*testMinuteMs, IntervalMs: 10 * testMinuteMs, Open: 100, High: 100, Low: 100, Close: 100, TickCount: 1, MinSpread: 1, MaxSpread: 1, MeanSpread: 1, TwaSpread: 1},
+				{Timestamp: testHourStartMs + 40*testMinuteMs, IntervalMs: 10 * testMinuteMs, Open: 100, High: 100, Low: 100, Close: 100, TickCount: 1, MinSpread: 4, MaxSpread: 4, MeanSpread: 4, TwaSpread: 2.5},
+			},
+		},
+		{
+			name: "unordered ticks sorted and unparsable skipped",
+			ticks: []bmodel.SymbolTick{
+				testTick(40*testMinuteMs, "101", "103"),
+				testTick(35*testMinuteMs, "", "101"),
+				testTick(20*testMinuteMs, "99", "101"),
+			},
+			intervalsMs: []int64{30 * testMinuteMs},
+			want: []model.MidBar{
+				{Timestamp: testHourStartMs, IntervalMs: 30 * testMinuteMs, Open: 100, High: 100, Low: 100, Close: 100, TickCount: 1, MinSpread: 2, MaxSpread: 2, MeanSpread: 2, TwaSpread: 2},
+				{Timestamp: testHourStartMs + 30*testMinuteMs, IntervalMs: 30 * testMinuteMs, Open: 102, High: 102, Low: 102, Close: 102, TickCount: 1, MinSpread: 2, MaxSpread: 2, MeanSpread: 2, TwaSpread: 2},
+			},
+		},
+	}
+	key := &model.ProcessingKey{Symbol: "BTCUSDT", HourNo: 1}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bars := aggregateMidBars(key, tt.ticks, tt.intervalsMs)
+			if len(bars) != len(tt.want) {
+				t.Fatalf("got %d bars %+v, want %d", len(bars), bars, len(tt.want))
+			}
+			for i, bar := range bars {
+				want := tt.want[i]
+				want.Symbol = key.Symbol
+				if math.Abs(bar.MeanSpread-want.MeanSpread) < 1e-9 && math.Abs(bar.TwaSpread-want.TwaSpread) < 1e-9 {
+					bar.MeanSpread, bar.TwaSpread = want.MeanSpread, want.TwaSpread
+				}
+				if bar != want {
+					t.Fatalf("bar %d is %+v, want %+v", i, bar, want)
+				}
+			}
+		})
+	}
+}
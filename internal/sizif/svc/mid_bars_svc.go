@@ -0,0 +1,173 @@
+package svc
+
+import (
+	"DeltaReceiver/internal/common/model"
+	"DeltaReceiver/internal/sizif/conf"
+	bmodel "DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MidBarsSvc periodically aggregates archived book ticks of each symbol hour into mid price
+// bars. An hour is aggregated after its book ticks are recorded in ticksLedger, the bars are
+// recorded in ledger of the bars storage under the same key with the latest revision of the
+// ticks. Late ticks archived in a new revision make the hour aggregated again, its bars
+// object is replaced then. Hours are locked with the KeyLocker of the bars storage.
+type MidBarsSvc struct {
+	logger      *zap.Logger
+	ticks       ArchiveReader[bmodel.SymbolTick]
+	ticksLedger ArchiveLedger
+	storage     ParquetStorage[model.MidBar]
+	ledger      ArchiveLedger
+	keyLocker   KeyLocker
+	metrics     MidBarsMetrics
+	cfg         *conf.MidBarsCfg
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+func NewMidBarsSvc(
+	serviceType string,
+	ticks ArchiveReader[bmodel.SymbolTick],
+	ticksLedger ArchiveLedger,
+	storage ParquetStorage[model.MidBar],
+	ledger ArchiveLedger,
+	keyLocker KeyLocker,
+	metrics MidBarsMetrics,
+	cfg *conf.MidBarsCfg,
+) *MidBarsSvc {
+	return &MidBarsSvc{
+		logger:      log.GetLogger(fmt.Sprintf("MidBarsSvc[%s]", serviceType)),
+		ticks:       ticks,
+		ticksLedger: ticksLedger,
+		storage:     storage,
+		ledger:      ledger,
+		keyLocker:   keyLocker,
+		metrics:     metrics,
+		cfg:         cfg,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+func (s *MidBarsSvc) Start(ctx context.Context) {
+	defer close(s.done)
+	s.logger.Info("Service started")
+	for {
+		s.aggregatePendingHours(ctx)
+		timer := time.NewTimer(time.Duration(s.cfg.PeriodM) * time.Minute)
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *MidBarsSvc) aggregatePendingHours(ctx context.Context) {
+	completedBefore := time.Now().Add(-time.Duration(s.cfg.DelayH) * time.Hour)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for date := today.AddDate(0, 0, -s.cfg.LookbackDays); !date.After(today); date = date.AddDate(0, 0, 1) {
+		keys, err := s.ticks.SymbolHours(ctx, date)
+		if err != nil {
+			s.logger.Error(err.Error())
+			return
+		}
+		for _, key := range keys {
+			select {
+			case <-s.stop:
+				return
+			default:
+			}
+			if key.GetEndTime().After(completedBefore) {
+				continue
+			}
+			ticksRevision, pending, err := s.isPending(ctx, &key)
+			if err != nil {
+				s.logger.Error(err.Error())
+				return
+			}
+			if pending {
+				s.lockAndAggregateHour(ctx, key, ticksRevision)
+			}
+		}
+	}
+}
+
+// isPending reports whether book ticks of the key are archived and its bars are not aggregated
+// from their latest revision, which is returned.
+func (s *MidBarsSvc) isPending(ctx context.Context, key *model.ProcessingKey) (int, bool, error) {
+	archivedTicks, err := s.ticksLedger.Get(ctx, key)
+	if err != nil || len(archivedTicks) == 0 {
+		return 0, false, err
+	}
+	archivedBars, err := s.ledger.Get(ctx, key)
+	if err != nil {
+		return 0, false, err
+	}
+	ticksRevision := archivedTicks[len(archivedTicks)-1].Revision
+	return ticksRevision, len(archivedBars) == 0 || archivedBars[len(archivedBars)-1].Revision < ticksRevision, nil
+}
+
+func (s *MidBarsSvc) lockAndAggregateHour(ctx context.Context, key model.ProcessingKey, ticksRevision int) {
+	lockStatus, err := s.keyLocker.Lock(ctx, &key)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return
+	}
+	if lockStatus != LockedSuccessfully {
+		s.logger.Debug(fmt.Sprintf("key %s already aggregating", &key))
+		return
+	}
+	defer func() {
+		if err := s.keyLocker.Unlock(ctx, &key); err != nil {
+			s.logger.Warn(fmt.Sprintf("key %s not unlocked: %s", &key, err.Error()))
+		}
+	}()
+	numBars, err := s.aggregateHour(ctx, &key, ticksRevision)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("key %s not aggregated: %s", &key, err.Error()))
+		s.metrics.IncFailedHours()
+		return
+	}
+	s.metrics.IncAggregatedHours(numBars)
+}
+
+// aggregateHour saves bars of all archived ticks of the key, including late ones. Bars are
+// always saved as the first revision object, so aggregating again replaces the object instead
+// of adding bars next to the outdated ones.
+func (s *MidBarsSvc) aggregateHour(ctx context.Context, key *model.ProcessingKey, ticksRevision int) (int, error) {
+	ticks, err := s.ticks.Read(ctx, key.Symbol, key.GetStartTime(), key.GetEndTime())
+	if err != nil {
+		return 0, err
+	}
+	bars := aggregateMidBars(key, ticks, s.cfg.IntervalsMs)
+	obj, err := saveAndVerify(ctx, s.storage, bars, key)
+	if err != nil {
+		return 0, err
+	}
+	obj.Revision = ticksRevision
+	if err = s.keyLocker.CheckLocked(ctx, key); err != nil {
+		return 0, err
+	}
+	if err = s.ledger.Record(ctx, key, []ArchivedObject{*obj}); err != nil {
+		return 0, err
+	}
+	s.logger.Info(fmt.Sprintf("key %s aggregated from %d ticks of revision %d into %d bars", key, len(ticks), ticksRevision, len(bars)))
+	return len(bars), nil
+}
+
+func (s *MidBarsSvc) Shutdown(ctx context.Context) {
+	s.logger.Info("Start shutdown")
+	close(s.stop)
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+	s.logger.Info("End shutdown")
+}
@@ -3,6 +3,7 @@ package svc
 import (
 	"DeltaReceiver/internal/common/model"
 	"DeltaReceiver/internal/common/repo/cs"
+	"DeltaReceiver/internal/sizif/conf"
 	"DeltaReceiver/pkg/log"
 	"context"
 	"fmt"
@@ -14,54 +15,93 @@ import (
 type SizifSvc[T model.WithTimestampMs] struct {
 	logger          *zap.Logger
 	socratesStorage SocratesStorage[T]
+	ledger          ArchiveLedger
+	watermark       KeyWatermark
+	metrics         Metrics
+	scheduler       *KeyScheduler
+	cfg             *conf.KeySchedulingCfg
 	workers         []*SizifWorker[T]
 	done            chan struct{}
-	taskQueue       chan<- model.ProcessingKey
 }
 
-func NewSizifSvc[T model.WithTimestampMs](serviceType string, socratesStorage SocratesStorage[T], parquetStorage ParquetStorage[T], dataTransformator DataTransformator[T], keyLocker KeyLocker, numWorkers int, metrics Metrics) *SizifSvc[T] {
-	taskQueue := make(chan model.ProcessingKey, 1024)
+func NewSizifSvc[T model.WithTimestampMs](serviceType string, socratesStorage SocratesStorage[T], parquetStorage ParquetStorage[T], quarantineStorage ParquetStorage[T], reportStorages []ValidationReportStorage, dataTransformator DataTransformator[T], keyLocker KeyLocker, ledger ArchiveLedger, watermark KeyWatermark, cfg *conf.KeySchedulingCfg, numWorkers int, metrics Metrics) *SizifSvc[T] {
+	scheduler := NewKeyScheduler()
 	workers := make([]*SizifWorker[T], numWorkers)
 	done := make(chan struct{}, numWorkers)
 	for i := 0; i < numWorkers; i++ {
-		workers[i] = NewSizifWorker(serviceType, socratesStorage, parquetStorage, dataTransformator, taskQueue, keyLocker, metrics, done)
+		workers[i] = NewSizifWorker(serviceType, socratesStorage, parquetStorage, quarantineStorage, reportStorages, dataTransformator, scheduler, keyLocker, ledger, metrics, done)
 	}
 	return &SizifSvc[T]{
 		logger:          log.GetLogger(fmt.Sprintf("SizifSvc[%s]", serviceType)),
 		socratesStorage: socratesStorage,
+		ledger:          ledger,
+		watermark:       watermark,
+		metrics:         metrics,
+		scheduler:       scheduler,
+		cfg:             cfg,
 		workers:         workers,
 		done:            done,
-		taskQueue:       taskQueue,
 	}
 }
 
-func (s *SizifSvc[T]) Start(ctx context.Context) {
+func (s *SizifSvc[T]) StartWorkers(ctx context.Context) {
 	for _, worker := range s.workers {
 		go worker.Start(ctx)
 	}
+}
+
+func (s *SizifSvc[T]) Start(ctx context.Context) {
+	s.StartWorkers(ctx)
 	s.logger.Info("Service started")
-	for {
-		newKeys := 0
-		keys, err := s.socratesStorage.GetKeys(ctx)
-		if err != nil {
-			s.logger.Error(err.Error())
-		} else {
-			maxAllowedHourNo := cs.GetHourNo(time.Now().UnixMilli()) - 3
-			for _, key := range keys {
-				if key.HourNo <= maxAllowedHourNo {
-					s.taskQueue <- key
-					newKeys++
-				}
+	for !s.scheduler.IsClosed() {
+		s.scheduleKeys(ctx)
+		sleep(int64(s.cfg.PeriodS))
+	}
+}
+
+// scheduleKeys schedules keys of hours which do not get new data anymore. Every replica
+// scans in every period, so keys left by failures, lock conflicts or late data are picked
+// again. Keys found in a partial scan are scheduled as well, but the watermark is advanced
+// only by complete scans, so it is the last hour all keys of which were discovered. It
+// never moves back and is only reported, scans are not bounded by it.
+func (s *SizifSvc[T]) scheduleKeys(ctx context.Context) {
+	maxAllowedHourNo := cs.GetHourNo(time.Now().UnixMilli()) - int64(s.cfg.CutoffH)
+	keys, err := s.socratesStorage.GetKeys(ctx)
+	if err != nil {
+		s.logger.Error(err.Error())
+		s.metrics.IncKeyScanErrors()
+	}
+	watermark, watermarkErr := s.watermark.Get(ctx)
+	if watermarkErr != nil {
+		s.logger.Warn(fmt.Sprintf("watermark not read: %s", watermarkErr.Error()))
+	}
+	var eligibleKeys []model.ProcessingKey
+	scannedHourKeys := 0
+	for _, key := range keys {
+		if key.HourNo <= maxAllowedHourNo && key.HourNo >= s.cfg.FromHourNo() {
+			eligibleKeys = append(eligibleKeys, key)
+			if key.HourNo <= watermark {
+				scannedHourKeys++
 			}
 		}
-		s.logger.Info(fmt.Sprintf("scheduled %d new keys", newKeys))
-		sleep(60 * 5)
 	}
+	newKeys := s.scheduler.Add(eligibleKeys)
+	queued, inFlight := s.scheduler.Len()
+	s.metrics.SetScheduledKeys(queued, inFlight)
+	s.logger.Info(fmt.Sprintf("scheduled %d new keys of %d eligible, %d of them up to watermark hour %d, %d keys queued", newKeys, len(eligibleKeys), scannedHourKeys, watermark, queued))
+	if err != nil {
+		return
+	}
+	if err = s.watermark.Advance(ctx, maxAllowedHourNo); err != nil {
+		s.logger.Warn(fmt.Sprintf("watermark not advanced: %s", err.Error()))
+		return
+	}
+	s.metrics.SetKeyWatermark(max(watermark, maxAllowedHourNo))
 }
 
 func (s *SizifSvc[T]) Shutdown(ctx context.Context) {
 	s.logger.Info("Start shutdown")
-	close(s.taskQueue)
+	s.scheduler.Close()
 	for i := range len(s.workers) {
 		<-s.done
 		s.logger.Info(fmt.Sprintf("Shutdowned %d workers", i))
@@ -4,40 +4,54 @@ import (
 	"DeltaReceiver/internal/common/model"
 	"DeltaReceiver/pkg/log"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type SizifWorker[T model.WithTimestampMs] struct {
 	logger            *zap.Logger
+	storageName       string
 	done              chan<- struct{}
 	socratesStorage   SocratesStorage[T]
 	parquetStorage    ParquetStorage[T]
+	quarantineStorage ParquetStorage[T]
+	reportStorages    []ValidationReportStorage
 	dataTransformator DataTransformator[T]
 	metrics           Metrics
-	taskQueue         <-chan model.ProcessingKey
+	scheduler         *KeyScheduler
 	keyLocker         KeyLocker
+	ledger            ArchiveLedger
 }
 
 func NewSizifWorker[T model.WithTimestampMs](
 	serviceType string,
 	socratesStorage SocratesStorage[T],
 	parquetStorage ParquetStorage[T],
+	quarantineStorage ParquetStorage[T],
+	reportStorages []ValidationReportStorage,
 	dataTransformator DataTransformator[T],
-	taskQueue <-chan model.ProcessingKey,
+	scheduler *KeyScheduler,
 	keyLocker KeyLocker,
+	ledger ArchiveLedger,
 	metrics Metrics,
 	done chan<- struct{},
 ) *SizifWorker[T] {
 	return &SizifWorker[T]{
 		logger:            log.GetLogger(fmt.Sprintf("SizifWorker[%s]", serviceType)),
+		storageName:       serviceType,
 		done:              done,
 		socratesStorage:   socratesStorage,
 		parquetStorage:    parquetStorage,
+		quarantineStorage: quarantineStorage,
+		reportStorages:    reportStorages,
 		dataTransformator: dataTransformator,
-		taskQueue:         taskQueue,
+		scheduler:         scheduler,
 		keyLocker:         keyLocker,
+		ledger:            ledger,
 		metrics:           metrics,
 	}
 }
@@ -45,41 +59,41 @@ func NewSizifWorker[T model.WithTimestampMs](
 func (s *SizifWorker[T]) Start(ctx context.Context) {
 	s.logger.Info("Worker started")
 	for {
-		select {
-		case key, ok := <-s.taskQueue:
-			if !ok {
-				s.logger.Info("Gracefully shutdown worker")
-				s.done <- struct{}{}
-				return
-			}
-			s.lockAndProcessKey(ctx, key)
-		default:
-			sleep(5)
+		key, ok := s.scheduler.Next()
+		if !ok {
+			s.logger.Info("Gracefully shutdown worker")
+			s.done <- struct{}{}
+			return
 		}
+		outcome, err := s.lockAndProcessKey(ctx, key)
+		s.scheduler.Done(NewKeyResult(key, outcome, err))
 	}
 }
 
-func (s *SizifWorker[T]) lockAndProcessKey(ctx context.Context, key model.ProcessingKey) {
+func (s *SizifWorker[T]) lockAndProcessKey(ctx context.Context, key model.ProcessingKey) (KeyOutcome, error) {
 	s.logger.Debug(fmt.Sprintf("Start processing key %s", key.String()))
 	lockStatus := s.lock(ctx, key)
 	if lockStatus == AlreadyLocked {
 		s.logger.Debug(fmt.Sprintf("Key %s already processing", key.String()))
-		return
-	} else if lockStatus == AlreadyProcessed {
-		s.logger.Debug(fmt.Sprintf("Key %s already processed, delete it's data", key.String()))
-		s.deleteKeyData(ctx, &key)
-	} else {
-		s.logger.Debug(fmt.Sprintf("Key %s not processed, start processing", key.String()))
-		for i := 0; i < 3; i++ {
-			err := s.processKey(ctx, key)
-			if err != nil {
-				s.logger.Error(err.Error())
-				sleep(3)
-				continue
-			}
-			return
+		return KeyLocked, nil
+	}
+	defer s.unlock(ctx, key)
+	var outcome KeyOutcome
+	var err error
+	for i := 0; i < 3; i++ {
+		outcome, err = s.processKey(ctx, key)
+		if errors.Is(err, LockLostErr) {
+			s.logger.Error(err.Error())
+			return KeyFailed, err
+		}
+		if err != nil {
+			s.logger.Error(err.Error())
+			sleep(3)
+			continue
 		}
+		return outcome, nil
 	}
+	return KeyFailed, err
 }
 
 func (s *SizifWorker[T]) lock(ctx context.Context, key model.ProcessingKey) LockOpStatus {
@@ -95,7 +109,18 @@ func (s *SizifWorker[T]) lock(ctx context.Context, key model.ProcessingKey) Lock
 	return AlreadyLocked
 }
 
-func (s *SizifWorker[T]) processKey(ctx context.Context, key model.ProcessingKey) error {
+func (s *SizifWorker[T]) unlock(ctx context.Context, key model.ProcessingKey) {
+	if err := s.keyLocker.Unlock(ctx, &key); err == nil {
+		s.logger.Debug(fmt.Sprintf("key unlocked %s", &key))
+	} else {
+		s.logger.Warn(fmt.Sprintf("key not unlocked %s", &key))
+	}
+}
+
+// processKey archives the key as the next revision after the ones in the ledger. Hot data
+// matching the last revision is left by a worker which died before deleting it, so it is
+// deleted without archiving it again.
+func (s *SizifWorker[T]) processKey(ctx context.Context, key model.ProcessingKey) (KeyOutcome, error) {
 	var data []T
 	var err error
 	for i := 0; i < 3; i++ {
@@ -106,43 +131,125 @@ func (s *SizifWorker[T]) processKey(ctx context.Context, key model.ProcessingKey
 		s.logger.Error(err.Error())
 	}
 	if err != nil {
-		errUnlock := s.keyLocker.Unlock(ctx, &key)
-		if errUnlock == nil {
-			s.logger.Info(fmt.Sprintf("key unlocked %s", &key))
-		} else {
-			s.logger.Warn(fmt.Sprintf("key not unlocked %s", &key))
-		}
-		return err
+		return KeyFailed, err
 	}
 	if len(data) == 0 {
 		s.logger.Info(fmt.Sprintf("no data for key %s, delete it", &key))
-		return s.socratesStorage.DeleteKey(ctx, &key)
+		return KeyProcessed, s.socratesStorage.DeleteKey(ctx, &key)
 	}
 	s.logger.Info(fmt.Sprintf("key %s got %d raw data", &key, len(data)))
-	transformedData, isDataValid := s.dataTransformator.Transform(data, &key)
+	transformedData, report := s.dataTransformator.Transform(data, &key)
 	s.logger.Info(fmt.Sprintf("key %s got %d data after transform", &key, len(transformedData)))
-	if !isDataValid {
-		s.logger.Warn(fmt.Sprintf("Invalid data for key %s", &key))
+	if len(transformedData) == 0 {
+		return KeyProcessed, nil
+	}
+	storage := s.parquetStorage
+	if !report.IsValid() {
+		s.logger.Warn(fmt.Sprintf("Invalid data for key %s, quarantine it", &key))
 		s.metrics.IncInvalidDataCounter()
+		storage = s.quarantineStorage
 	}
+	archived, err := s.ledger.Get(ctx, &key)
+	if err != nil {
+		return KeyFailed, err
+	}
+	revision := 0
+	if len(archived) > 0 {
+		lastRevision := archived[len(archived)-1].Revision
+		if sameChecksums(lastRevisionObjects(archived, lastRevision), storage, transformedData) {
+			s.logger.Info(fmt.Sprintf("key %s already archived in revision %d, delete it's data", &key, lastRevision))
+			return KeyAlreadyProcessed, s.deleteKeyData(ctx, &key)
+		}
+		revision = lastRevision + 1
+		s.logger.Warn(fmt.Sprintf("late data for archived key %s, archive it in revision %d", &key, revision))
+	}
+	// hot data is deleted only after every group is saved, read back and recorded
+	objects := make([]ArchivedObject, 0, len(transformedData))
 	for _, dataGroup := range transformedData {
+		obj, err := s.saveDataGroup(ctx, storage, dataGroup, &key, revision)
+		if err != nil {
+			return KeyFailed, err
+		}
+		objects = append(objects, *obj)
+	}
+	if !report.IsValid() {
+		if err = s.saveReport(ctx, report); err != nil {
+			return KeyFailed, err
+		}
+	}
+	s.logger.Info(fmt.Sprintf("key %s saved to object store", &key))
+	// a worker which lost the lock leaves recording and deleting the key to the new holder
+	if err = s.keyLocker.CheckLocked(ctx, &key); err != nil {
+		return KeyFailed, err
+	}
+	for j := 0; j < 3; j++ {
+		err = s.ledger.Record(ctx, &key, objects)
+		if err == nil {
+			return KeyProcessed, s.deleteKeyData(ctx, &key)
+		}
+		s.logger.Error(err.Error())
+	}
+	return KeyFailed, err
+}
+
+func lastRevisionObjects(archived []ArchivedObject, revision int) []ArchivedObject {
+	return slices.DeleteFunc(slices.Clone(archived), func(obj ArchivedObject) bool {
+		return obj.Revision != revision
+	})
+}
+
+func sameChecksums[T any](objects []ArchivedObject, storage ParquetStorage[T], dataGroups [][]T) bool {
+	if len(objects) != len(dataGroups) {
+		return false
+	}
+	archivedChecksums := make([]string, len(objects))
+	for i, obj := range objects {
+		archivedChecksums[i] = obj.Checksum
+	}
+	checksums := make([]string, len(dataGroups))
+	for i, dataGroup := range dataGroups {
+		checksums[i] = storage.Checksum(dataGroup)
+	}
+	slices.Sort(archivedChecksums)
+	slices.Sort(checksums)
+	return slices.Equal(archivedChecksums, checksums)
+}
+
+func (s *SizifWorker[T]) saveDataGroup(ctx context.Context, storage ParquetStorage[T], dataGroup []T, key *model.ProcessingKey, revision int) (*ArchivedObject, error) {
+	var err error
+	for i := 0; i < 3; i++ {
+		var obj *ArchivedObject
+		obj, err = storage.Save(ctx, dataGroup, dataGroup[0].GetTimestampMs(), key, revision)
+		if err != nil {
+			s.logger.Error(err.Error())
+			continue
+		}
+		err = storage.Verify(ctx, obj)
+		if err == nil {
+			return obj, nil
+		}
+		s.logger.Error(fmt.Sprintf("key %s not verified: %s", key, err.Error()))
+		s.metrics.IncVerificationFailedCounter()
+	}
+	return nil, err
+}
+
+func (s *SizifWorker[T]) saveReport(ctx context.Context, report *model.ValidationReport) error {
+	report.StorageName = s.storageName
+	report.CreatedAtMs = time.Now().UnixMilli()
+	for _, reportStorage := range s.reportStorages {
+		var err error
 		for i := 0; i < 3; i++ {
-			err = s.parquetStorage.Save(ctx, dataGroup, dataGroup[0].GetTimestampMs(), &key)
-			if err == nil {
-				s.logger.Info(fmt.Sprintf("key %s saved to b2", &key))
-				for j := 0; j < 3; j++ {
-					err = s.keyLocker.MarkProcessed(ctx, &key)
-					if err == nil {
-						return s.deleteKeyData(ctx, &key)
-					}
-					s.logger.Error(err.Error())
-				}
-				return err
+			if err = reportStorage.SaveValidationReport(ctx, report); err == nil {
+				break
 			}
 			s.logger.Error(err.Error())
 		}
+		if err != nil {
+			return err
+		}
 	}
-	return err
+	return nil
 }
 
 func (s *SizifWorker[T]) deleteKeyData(ctx context.Context, key *model.ProcessingKey) error {
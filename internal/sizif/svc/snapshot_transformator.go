@@ -19,23 +19,21 @@ func NewDepthSnapshotTransformator() *DepthSnapshotTransformator {
 	}
 }
 
-func (s DepthSnapshotTransformator) Transform(snapshotParts []model.DepthSnapshotPart, key *model.ProcessingKey) ([][]model.DepthSnapshotPart, bool) {
+func (s DepthSnapshotTransformator) Transform(snapshotParts []model.DepthSnapshotPart, key *model.ProcessingKey) ([][]model.DepthSnapshotPart, *model.ValidationReport) {
+	report := model.NewValidationReport(key, len(snapshotParts))
 	if len(snapshotParts) == 0 {
 		s.logger.Warn(fmt.Sprintf("empty batch for key %s", key))
-		return nil, false
+		return nil, report
 	}
 	minAllowedTsMs := key.HourNo * millisInHour
 	maxAllowedTsMs := minAllowedTsMs + millisInHour - 1
-	snapshotPartsOutsideTimeRange := 0
 	for _, snapshotPart := range snapshotParts {
 		if snapshotPart.Timestamp > maxAllowedTsMs || snapshotPart.Timestamp < minAllowedTsMs {
-			snapshotPartsOutsideTimeRange++
+			report.AddOutOfRange(snapshotPart.Timestamp)
 		}
 	}
-	validByTimeRange := true
-	if snapshotPartsOutsideTimeRange > 0 {
+	if report.OutOfRangeCount > 0 {
 		s.logger.Warn(fmt.Sprintf("invalid time range for %s key", key))
-		validByTimeRange = false
 	}
 	tsToSnapshot := make(map[int64][]model.DepthSnapshotPart)
 	for _, snapshotPart := range snapshotParts {
@@ -55,5 +53,5 @@ func (s DepthSnapshotTransformator) Transform(snapshotParts []model.DepthSnapsho
 		transformedSnaphots[k] = snapshot
 		k++
 	}
-	return transformedSnaphots, validByTimeRange
+	return transformedSnaphots, report
 }
@@ -21,24 +21,22 @@ func NewBookTicksTransformator() *BookTicksTransformator {
 	}
 }
 
-func (s BookTicksTransformator) Transform(ticks []bmodel.SymbolTick, key *model.ProcessingKey) ([][]bmodel.SymbolTick, bool) {
+func (s BookTicksTransformator) Transform(ticks []bmodel.SymbolTick, key *model.ProcessingKey) ([][]bmodel.SymbolTick, *model.ValidationReport) {
+	report := model.NewValidationReport(key, len(ticks))
 	if len(ticks) == 0 {
 		s.logger.Warn(fmt.Sprintf("empty batch for key %s", key))
-		return nil, false
+		return nil, report
 	}
 	minAllowedTsMs := key.HourNo * millisInHour
 	maxAllowedTsMs := minAllowedTsMs + millisInHour - 1
-	ticksOutsideTimeRange := 0
 	for _, tick := range ticks {
 		if tick.Timestamp > maxAllowedTsMs || tick.Timestamp < minAllowedTsMs {
 			s.logger.Debug(tick.String())
-			ticksOutsideTimeRange++
+			report.AddOutOfRange(tick.Timestamp)
 		}
 	}
-	validByTimeRange := true
-	if ticksOutsideTimeRange > 0 {
+	if report.OutOfRangeCount > 0 {
 		s.logger.Warn(fmt.Sprintf("invalid time range for %s key", key))
-		validByTimeRange = false
 	}
 	sort.Slice(ticks, func(i, j int) bool {
 		return ticks[i].UpdateId < ticks[j].UpdateId || (ticks[i].UpdateId == ticks[j].UpdateId && ticks[i].Timestamp < ticks[j].UpdateId)
@@ -46,13 +44,12 @@ func (s BookTicksTransformator) Transform(ticks []bmodel.SymbolTick, key *model.
 	tickUpdateIds := make(map[int64]struct{})
 	var pqtTicks []bmodel.SymbolTick
 	pqtTicks = append(pqtTicks, ticks[0])
-	updateIdDuplicates := 0
 	for i := 1; i < len(ticks); i++ {
 		curTick := ticks[i]
 		if _, ok := tickUpdateIds[curTick.UpdateId]; ok {
 			prevTick := pqtTicks[len(pqtTicks)-1]
 			if prevTick.AskPrice != curTick.AskPrice || prevTick.AskQuantity != curTick.AskQuantity || prevTick.BidPrice != curTick.BidPrice || prevTick.BidQuantity != curTick.BidQuantity {
-				updateIdDuplicates++
+				report.AddDuplicate(curTick.UpdateId)
 				pqtTicks = append(pqtTicks, curTick)
 			}
 		} else {
@@ -60,12 +57,10 @@ func (s BookTicksTransformator) Transform(ticks []bmodel.SymbolTick, key *model.
 			pqtTicks = append(pqtTicks, curTick)
 		}
 	}
-	validByDuplicates := true
-	if updateIdDuplicates > 0 {
+	if report.DuplicateCount > 0 {
 		s.logger.Warn(fmt.Sprintf("invalid by duplicates for %s key", key))
 		data, _ := json.Marshal(pqtTicks)
 		s.logger.Info(fmt.Sprintf("ticks batch, %s", string(data)))
-		validByDuplicates = false
 	}
-	return [][]bmodel.SymbolTick{ticks}, validByDuplicates && validByTimeRange
+	return [][]bmodel.SymbolTick{ticks}, report
 }
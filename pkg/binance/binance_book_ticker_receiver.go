@@ -99,6 +99,7 @@ func (s *BookTickerClient) Recv(ctx context.Context) (model.SymbolTick, error) {
 				s.logger.Error(err.Error())
 				return model.SymbolTick{}, fmt.Errorf("error while unmarshaling tick message %w", err)
 			}
+			// spot book ticker events have no event time, ticks are stamped on receive
 			tick.Timestamp = time.Now().UnixMilli()
 			return tick, nil
 		}
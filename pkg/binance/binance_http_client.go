@@ -18,6 +18,7 @@ type BinanceHttpClient struct {
 	client         *http.Client
 	exInfoQ        string
 	depthSnapshotQ string
+	serverTimeQ    string
 }
 
 func NewBinanceHttpClient(dataType model.DataType, cfg *BinanceHttpClientConfig) *BinanceHttpClient {
@@ -27,6 +28,7 @@ func NewBinanceHttpClient(dataType model.DataType, cfg *BinanceHttpClientConfig)
 		client:         &http.Client{},
 		exInfoQ:        fmt.Sprintf("%s%s", baseURI, dataType.ExInfoQuery()),
 		depthSnapshotQ: fmt.Sprintf("%s%s", baseURI, dataType.DepthSnapshotQuery()),
+		serverTimeQ:    fmt.Sprintf("%s%s", baseURI, dataType.ServerTimeQuery()),
 	}
 }
 
@@ -152,6 +154,47 @@ func (s BinanceHttpClient) GetFullSnapshot(ctx context.Context, symbol string, d
 	return &snapshot, resp.Header.Get(fmt.Sprintf("X-Mbx-Used-Weight-%s", headerType)), nil
 }
 
+func (s BinanceHttpClient) GetServerTime(ctx context.Context) (*model.ServerTime, error) {
+	if isBanned() {
+		return nil, RequestRejectedErr
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.serverTimeQ, http.NoBody)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTeapot {
+		return nil, banBinanceRequests(resp, TeapotErr)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, banBinanceRequests(resp, WeightLimitExceededErr)
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	err = resp.Body.Close()
+	if err != nil {
+		s.logger.Warn(err.Error())
+	}
+	var serverTime model.ServerTime
+	err = json.Unmarshal(respBody, &serverTime)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return nil, err
+	}
+	if serverTime.ServerTime == 0 {
+		return nil, InvalidBinanceDataErr
+	}
+	return &serverTime, nil
+}
+
 var (
 	TeapotErr              = fmt.Errorf("got teapot http response status, current IP banned by binance")
 	WeightLimitExceededErr = fmt.Errorf("too many requests, weight limit exceeded")
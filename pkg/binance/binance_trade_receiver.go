@@ -0,0 +1,119 @@
+package binance
+
+import (
+	"DeltaReceiver/pkg/binance/model"
+	"DeltaReceiver/pkg/log"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// AggTradeClient receives aggregate trade streams of the symbols, which are offered by
+// spot and futures markets alike.
+type AggTradeClient struct {
+	logger    *zap.Logger
+	wsBaseUri string
+	symbols   []string
+	shutdown  *atomic.Bool
+	dialer    *websocket.Conn
+}
+
+func NewAggTradeClient(cfg *BinanceHttpClientConfig, symbols []string) *AggTradeClient {
+	var shutdown atomic.Bool
+	shutdown.Store(false)
+	return &AggTradeClient{
+		logger:    log.GetLogger("AggTradeClient"),
+		wsBaseUri: cfg.StreamBaseUriConfig.GetBaseUri() + "/ws",
+		symbols:   symbols,
+		shutdown:  &shutdown,
+	}
+}
+
+func (s *AggTradeClient) formWSUri() string {
+	return fmt.Sprintf("%s/%s@aggTrade", s.wsBaseUri, strings.Join(s.symbols, "@aggTrade/"))
+}
+
+func (s *AggTradeClient) ConnectWs(ctx context.Context) error {
+	d := websocket.Dialer{
+		Proxy:           http.ProxyFromEnvironment,
+		ReadBufferSize:  10240,
+		WriteBufferSize: 10240,
+	}
+	dialUri := s.formWSUri()
+	s.logger.Debug("start dial with uri " + dialUri)
+	dialer, resp, err := d.Dial(dialUri, nil)
+	if err != nil {
+		s.logger.Error(err.Error())
+		return err
+	}
+	if resp.StatusCode == http.StatusTeapot {
+		return banBinanceRequests(resp, TeapotErr)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return banBinanceRequests(resp, WeightLimitExceededErr)
+	}
+	s.dialer = dialer
+	return nil
+}
+
+func (s *AggTradeClient) Reconnect(ctx context.Context) error {
+	s.logger.Debug("start of reconnecting")
+	if s.shutdown.Load() {
+		s.logger.Warn("graceful shutdown processing")
+		return nil
+	}
+	s.dialer.Close()
+	if err := s.ConnectWs(ctx); err != nil {
+		s.logger.Warn(fmt.Errorf("connection was not reset %w", err).Error())
+		return err
+	}
+	return nil
+}
+
+// Recv returns an empty trade once the client is shut down or requests are banned.
+func (s *AggTradeClient) Recv(ctx context.Context) (model.AggTrade, error) {
+	if isBanned() || s.shutdown.Load() {
+		return model.AggTrade{}, nil
+	}
+	if s.dialer == nil {
+		if err := s.ConnectWs(ctx); err != nil {
+			return model.AggTrade{}, err
+		}
+	}
+	for i := 0; ; i++ {
+		_, msg, err := s.dialer.ReadMessage()
+		if err == nil {
+			var trade model.AggTrade
+			if err = json.Unmarshal(msg, &trade); err != nil {
+				s.logger.Error(err.Error())
+				return model.AggTrade{}, fmt.Errorf("error while unmarshaling trade message %w", err)
+			}
+			return trade, nil
+		}
+		if s.shutdown.Load() {
+			return model.AggTrade{}, nil
+		}
+		s.logger.Warn(err.Error())
+		s.logger.Warn("error while getting trade message, reconnect")
+		if err = s.Reconnect(ctx); err != nil && i == 3 {
+			return model.AggTrade{}, err
+		}
+	}
+}
+
+func (s *AggTradeClient) Shutdown(ctx context.Context) {
+	if !s.shutdown.Load() {
+		s.shutdown.Store(true)
+		if s.dialer != nil {
+			if err := s.dialer.Close(); err != nil {
+				s.logger.Error(err.Error())
+			}
+		}
+	}
+}
@@ -24,3 +24,7 @@ func (s SymbolTick) GetTimestampMs() int64 {
 func (s SymbolTick) GetSymbol() string {
 	return s.Symbol
 }
+
+func (s SymbolTick) GetUpdateId() int64 {
+	return s.UpdateId
+}
@@ -18,6 +18,10 @@ type DepthSnapshot struct {
 	Asks         [][2]string `json:"asks"`
 }
 
+type ServerTime struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
 type DataType string
 
 const (
@@ -47,3 +51,14 @@ func (s DataType) DepthSnapshotQuery() string {
 	}
 	panic(fmt.Sprintf("unexpected DataType %s", s))
 }
+
+func (s DataType) ServerTimeQuery() string {
+	if s == Spot {
+		return "/api/v3/time"
+	} else if s == FuturesUSD {
+		return "/fapi/v1/time"
+	} else if s == FuturesCoin {
+		return "/dapi/v1/time"
+	}
+	panic(fmt.Sprintf("unexpected DataType %s", s))
+}
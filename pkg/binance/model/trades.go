@@ -0,0 +1,25 @@
+package model
+
+// AggTrade is an aggregate trade event, i.e. fills of one taker order at one price. Keys
+// differing only in case are declared, so they are not matched to other fields.
+type AggTrade struct {
+	EventType    string `json:"e"`
+	EventTime    int64  `json:"E"`
+	Symbol       string `json:"s"`
+	AggTradeId   int64  `json:"a"`
+	Price        string `json:"p"`
+	Quantity     string `json:"q"`
+	FirstTradeId int64  `json:"f"`
+	LastTradeId  int64  `json:"l"`
+	TradeTime    int64  `json:"T"`
+	IsBuyerMaker bool   `json:"m"`
+	Ignore       bool   `json:"M"`
+}
+
+func (s AggTrade) GetTimestampMs() int64 {
+	return s.TradeTime
+}
+
+func (s AggTrade) GetSymbol() string {
+	return s.Symbol
+}
@@ -0,0 +1,63 @@
+package clickhouse
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ClickHouse/ch-go"
+)
+
+type deduplicationTokenKey struct{}
+
+// InsertSettings returns settings of an insert, with the deduplication token of the block
+// if the insert is made by InsertInBlocks.
+func InsertSettings(ctx context.Context, asyncInsert, waitForAsyncInsert bool) []ch.Setting {
+	var settings []ch.Setting
+	token, hasToken := ctx.Value(deduplicationTokenKey{}).(string)
+	if hasToken {
+		settings = append(settings, ch.Setting{Key: "insert_deduplication_token", Value: token, Important: true})
+	}
+	if !asyncInsert {
+		return settings
+	}
+	waitValue := "0"
+	if waitForAsyncInsert {
+		waitValue = "1"
+	}
+	settings = append(settings,
+		ch.Setting{Key: "async_insert", Value: "1", Important: true},
+		ch.Setting{Key: "wait_for_async_insert", Value: waitValue, Important: true},
+	)
+	if hasToken {
+		settings = append(settings, ch.Setting{Key: "async_insert_deduplicate", Value: "1", Important: true})
+	}
+	return settings
+}
+
+// InsertInBlocks splits rows into blocks of at most blockSize rows and inserts them one by one.
+// Every block gets a deduplication token derived from its rows, so when a failed batch is
+// retried or replayed from a spool, blocks inserted before the failure are dropped by
+// ClickHouse instead of duplicated. This needs a replicated table or a MergeTree table
+// with non_replicated_deduplication_window.
+func InsertInBlocks[T any](ctx context.Context, rows []T, blockSize int, insert func(context.Context, []T) error) error {
+	if blockSize <= 0 {
+		blockSize = len(rows)
+	}
+	for from := 0; from < len(rows); from += blockSize {
+		block := rows[from:min(from+blockSize, len(rows))]
+		if err := insert(context.WithValue(ctx, deduplicationTokenKey{}, deduplicationToken(block)), block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deduplicationToken[T any](block []T) string {
+	hash := sha256.New()
+	for _, row := range block {
+		_, _ = fmt.Fprintf(hash, "%+v\n", row)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -1,6 +1,10 @@
 package clickhouse
 
-import "DeltaReceiver/pkg/conf"
+import (
+	"DeltaReceiver/pkg/conf"
+	"DeltaReceiver/pkg/env"
+	"os"
+)
 
 type ChPoolCfg struct {
 	UriConf      *conf.BaseUriConfig `yaml:"base.uri"`
@@ -9,5 +13,17 @@ type ChPoolCfg struct {
 	ChMaxConns   int32               `yaml:"ch.max.conns"`
 	ChMinConns   int32               `yaml:"ch.min.conns"`
 	User         string              `yaml:"user"`
-	Password     string              `yaml:"password"`
+	Password     string              `yaml:"-"`
+}
+
+func NewChPoolCfgFromEnv(envPrefix string) *ChPoolCfg {
+	return &ChPoolCfg{
+		UriConf:      conf.NewBaseUriConfigFromEnv(envPrefix + ".base.uri"),
+		DialTimeoutS: env.GetInt64OrDefault(envPrefix+".dial.timeout.s", 5),
+		ReadTimeoutS: env.GetInt64OrDefault(envPrefix+".read.timeout.s", 30),
+		ChMaxConns:   int32(env.GetIntOrDefault(envPrefix+".ch.max.conns", 45)),
+		ChMinConns:   int32(env.GetIntOrDefault(envPrefix+".ch.min.conns", 15)),
+		User:         os.Getenv(envPrefix + ".user"),
+		Password:     os.Getenv(envPrefix + ".password"),
+	}
 }
@@ -13,10 +13,11 @@ import (
 )
 
 type ChPoolHolder struct {
-	logger *zap.Logger
-	mut    *sync.RWMutex
-	pool   *chpool.Pool
-	cfg    *ChPoolCfg
+	logger  *zap.Logger
+	mut     *sync.RWMutex
+	dialMut sync.Mutex
+	pool    *chpool.Pool
+	cfg     *ChPoolCfg
 }
 
 func NewChPoolHolder(cfg *ChPoolCfg) *ChPoolHolder {
@@ -58,6 +59,12 @@ func (s *ChPoolHolder) Reconnect(ctx context.Context, numTries int) error {
 }
 
 func (s *ChPoolHolder) Do(ctx context.Context, query ch.Query) error {
+	if s.isNil() {
+		// the pool was not dialed on start, retry lazily so writes recover without a restart
+		if err := s.connectIfNil(ctx); err != nil {
+			return errors.Join(NilConnPool, err)
+		}
+	}
 	s.mut.RLock()
 	defer s.mut.RUnlock()
 	if s.pool == nil {
@@ -66,6 +73,22 @@ func (s *ChPoolHolder) Do(ctx context.Context, query ch.Query) error {
 	return s.pool.Do(ctx, query)
 }
 
+func (s *ChPoolHolder) isNil() bool {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.pool == nil
+}
+
+// connectIfNil dials one pool for concurrent callers, so they do not close pools of each other.
+func (s *ChPoolHolder) connectIfNil(ctx context.Context) error {
+	s.dialMut.Lock()
+	defer s.dialMut.Unlock()
+	if !s.isNil() {
+		return nil
+	}
+	return s.Connect(ctx)
+}
+
 func (s *ChPoolHolder) SetPool(newPool *chpool.Pool) {
 	s.mut.Lock()
 	defer s.mut.Unlock()
@@ -76,7 +99,11 @@ func (s *ChPoolHolder) SetPool(newPool *chpool.Pool) {
 }
 
 func (s *ChPoolHolder) Disconnect() {
-	s.pool.Close()
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	if s.pool != nil {
+		s.pool.Close()
+	}
 }
 
 var NilConnPool = errors.New("nil conn pool")
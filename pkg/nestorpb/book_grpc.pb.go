@@ -0,0 +1,180 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: book.proto
+
+package nestorpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BookService_StreamBook_FullMethodName = "/nestor.v1.BookService/StreamBook"
+	BookService_GetBook_FullMethodName    = "/nestor.v1.BookService/GetBook"
+)
+
+// BookServiceClient is the client API for BookService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BookServiceClient interface {
+	// StreamBook sends the top depth levels of the book followed by incremental updates.
+	// A new snapshot is sent whenever the book is rebuilt from a fresh depth snapshot.
+	StreamBook(ctx context.Context, in *BookRequest, opts ...grpc.CallOption) (BookService_StreamBookClient, error)
+	// GetBook returns the current top depth levels of the book.
+	GetBook(ctx context.Context, in *BookRequest, opts ...grpc.CallOption) (*BookSnapshot, error)
+}
+
+type bookServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBookServiceClient(cc grpc.ClientConnInterface) BookServiceClient {
+	return &bookServiceClient{cc}
+}
+
+func (c *bookServiceClient) StreamBook(ctx context.Context, in *BookRequest, opts ...grpc.CallOption) (BookService_StreamBookClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BookService_ServiceDesc.Streams[0], BookService_StreamBook_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bookServiceStreamBookClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BookService_StreamBookClient interface {
+	Recv() (*BookUpdate, error)
+	grpc.ClientStream
+}
+
+type bookServiceStreamBookClient struct {
+	grpc.ClientStream
+}
+
+func (x *bookServiceStreamBookClient) Recv() (*BookUpdate, error) {
+	m := new(BookUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *bookServiceClient) GetBook(ctx context.Context, in *BookRequest, opts ...grpc.CallOption) (*BookSnapshot, error) {
+	out := new(BookSnapshot)
+	err := c.cc.Invoke(ctx, BookService_GetBook_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BookServiceServer is the server API for BookService service.
+// All implementations must embed UnimplementedBookServiceServer
+// for forward compatibility
+type BookServiceServer interface {
+	// StreamBook sends the top depth levels of the book followed by incremental updates.
+	// A new snapshot is sent whenever the book is rebuilt from a fresh depth snapshot.
+	StreamBook(*BookRequest, BookService_StreamBookServer) error
+	// GetBook returns the current top depth levels of the book.
+	GetBook(context.Context, *BookRequest) (*BookSnapshot, error)
+	mustEmbedUnimplementedBookServiceServer()
+}
+
+// UnimplementedBookServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBookServiceServer struct {
+}
+
+func (UnimplementedBookServiceServer) StreamBook(*BookRequest, BookService_StreamBookServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBook not implemented")
+}
+func (UnimplementedBookServiceServer) GetBook(context.Context, *BookRequest) (*BookSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBook not implemented")
+}
+func (UnimplementedBookServiceServer) mustEmbedUnimplementedBookServiceServer() {}
+
+// UnsafeBookServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BookServiceServer will
+// result in compilation errors.
+type UnsafeBookServiceServer interface {
+	mustEmbedUnimplementedBookServiceServer()
+}
+
+func RegisterBookServiceServer(s grpc.ServiceRegistrar, srv BookServiceServer) {
+	s.RegisterService(&BookService_ServiceDesc, srv)
+}
+
+func _BookService_StreamBook_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BookRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BookServiceServer).StreamBook(m, &bookServiceStreamBookServer{stream})
+}
+
+type BookService_StreamBookServer interface {
+	Send(*BookUpdate) error
+	grpc.ServerStream
+}
+
+type bookServiceStreamBookServer struct {
+	grpc.ServerStream
+}
+
+func (x *bookServiceStreamBookServer) Send(m *BookUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BookService_GetBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookServiceServer).GetBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookService_GetBook_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookServiceServer).GetBook(ctx, req.(*BookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BookService_ServiceDesc is the grpc.ServiceDesc for BookService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BookService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "nestor.v1.BookService",
+	HandlerType: (*BookServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBook",
+			Handler:    _BookService_GetBook_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamBook",
+			Handler:       _BookService_StreamBook_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "book.proto",
+}
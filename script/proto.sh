@@ -0,0 +1,3 @@
+#!/bin/bash
+
+cd pkg/nestorpb && protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative book.proto